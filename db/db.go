@@ -3,9 +3,13 @@ package db
 import (
 	"github.com/kamva/mgm/v3"
 	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// AttachmentBucketName is the GridFS bucket holding the secret attachments.
+const AttachmentBucketName = "attachment_files"
+
 type DB struct {
 }
 
@@ -21,3 +25,15 @@ func New(cfg MongoCfg, logger *logrus.Logger) (*DB, error) {
 	logger.Debug("Connected to MongoDB")
 	return db, err
 }
+
+// AttachmentBucket opens the GridFS bucket of the attachments.
+// Buckets keep state for the stream in use so every operation should open its own.
+func (d *DB) AttachmentBucket() (*gridfs.Bucket, error) {
+	_, _, database, err := mgm.DefaultConfigs()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return gridfs.NewBucket(database, options.GridFSBucket().SetName(AttachmentBucketName))
+}
@@ -0,0 +1,30 @@
+package doc
+
+import (
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AccessRequestEvent struct {
+	Status string    `bson:"status"`
+	At     time.Time `bson:"at"`
+	By     string    `bson:"by,omitempty"`
+	Note   string    `bson:"note,omitempty"`
+}
+
+type AccessRequest struct {
+	mgm.DefaultModel `bson:",inline"`
+	SecretID         primitive.ObjectID   `bson:"secretId"`
+	SecretOwnerID    string               `bson:"secretOwnerId"`
+	OrganizationID   string               `bson:"organizationId"`
+	RequesterID      string               `bson:"requesterId"`
+	Role             string               `bson:"role"`
+	Reason           string               `bson:"reason"`
+	DurationMinutes  int                  `bson:"durationMinutes"`
+	Status           string               `bson:"status"`
+	DecidedBy        string               `bson:"decidedBy,omitempty"`
+	AccessExpiresAt  time.Time            `bson:"accessExpiresAt,omitempty"`
+	History          []AccessRequestEvent `bson:"history"`
+}
@@ -0,0 +1,41 @@
+package doc
+
+import (
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Attachment struct {
+	mgm.DefaultModel `bson:",inline"`
+	SecretID         primitive.ObjectID `bson:"secretId"`
+	OrganizationID   string             `bson:"organizationId"`
+	FileID           primitive.ObjectID `bson:"fileId"`
+	Name             string             `bson:"name,omitempty"`
+	ContentType      string             `bson:"contentType,omitempty"`
+	Size             int64              `bson:"size"`
+	SHA256           string             `bson:"sha256"`
+	UploadedBy       string             `bson:"uploadedBy,omitempty"`
+}
+
+type AttachmentUpload struct {
+	mgm.DefaultModel `bson:",inline"`
+	SecretID         primitive.ObjectID `bson:"secretId"`
+	OrganizationID   string             `bson:"organizationId"`
+	Name             string             `bson:"name,omitempty"`
+	ContentType      string             `bson:"contentType,omitempty"`
+	Size             int64              `bson:"size"`
+	SHA256           string             `bson:"sha256"`
+	ReceivedSize     int64              `bson:"receivedSize"`
+	ExpiresAt        time.Time          `bson:"expiresAt"`
+	UploadedBy       string             `bson:"uploadedBy,omitempty"`
+}
+
+// AttachmentChunk holds the bytes received for an upload until it is completed into GridFS.
+type AttachmentChunk struct {
+	mgm.DefaultModel `bson:",inline"`
+	UploadID         primitive.ObjectID `bson:"uploadId"`
+	Offset           int64              `bson:"offset"`
+	Data             []byte             `bson:"data"`
+}
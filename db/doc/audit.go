@@ -0,0 +1,13 @@
+package doc
+
+import "github.com/kamva/mgm/v3"
+
+type AuditEvent struct {
+	mgm.DefaultModel `bson:",inline"`
+	OrganizationID   string            `bson:"organizationId"`
+	ActorID          string            `bson:"actorId,omitempty"`
+	Action           string            `bson:"action"`
+	TargetType       string            `bson:"targetType"`
+	TargetID         string            `bson:"targetId"`
+	Data             map[string]string `bson:"data,omitempty"`
+}
@@ -0,0 +1,45 @@
+package doc
+
+import (
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BreakGlassCustodian struct {
+	UserID         string `bson:"userId"`
+	EncryptedShare string `bson:"encryptedShare"`
+}
+
+type BreakGlassVault struct {
+	mgm.DefaultModel `bson:",inline"`
+	OrganizationID   string                `bson:"organizationId"`
+	Name             string                `bson:"name"`
+	Description      string                `bson:"description,omitempty"`
+	Threshold        int                   `bson:"threshold"`
+	EncryptedPayload string                `bson:"encryptedPayload"`
+	Custodians       []BreakGlassCustodian `bson:"custodians"`
+	CreatedBy        string                `bson:"createdBy"`
+}
+
+type BreakGlassSubmission struct {
+	UserID         string    `bson:"userId"`
+	SubmittedAt    time.Time `bson:"submittedAt"`
+	EncryptedShare string    `bson:"encryptedShare,omitempty"`
+}
+
+type BreakGlassUnlock struct {
+	mgm.DefaultModel   `bson:",inline"`
+	VaultID            primitive.ObjectID     `bson:"vaultId"`
+	OrganizationID     string                 `bson:"organizationId"`
+	RequestedBy        string                 `bson:"requestedBy"`
+	Reason             string                 `bson:"reason"`
+	RecipientPublicKey string                 `bson:"recipientPublicKey"`
+	Threshold          int                    `bson:"threshold"`
+	Custodians         []string               `bson:"custodians"`
+	Status             string                 `bson:"status"`
+	Submissions        []BreakGlassSubmission `bson:"submissions"`
+	ExpiresAt          time.Time              `bson:"expiresAt"`
+	UnlockedAt         time.Time              `bson:"unlockedAt,omitempty"`
+}
@@ -0,0 +1,23 @@
+package doc
+
+import (
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CollectionGrant struct {
+	UserID string `bson:"userId,omitempty"`
+	TeamID string `bson:"teamId,omitempty"`
+	Role   string `bson:"role"`
+}
+
+type Collection struct {
+	mgm.DefaultModel     `bson:",inline"`
+	OrganizationID       string               `bson:"organizationId"`
+	Name                 string               `bson:"name"`
+	ParentID             *primitive.ObjectID  `bson:"parentId,omitempty"`
+	Ancestors            []primitive.ObjectID `bson:"ancestors"`
+	Path                 string               `bson:"path"`
+	Grants               []CollectionGrant    `bson:"grants"`
+	RotationIntervalDays int                  `bson:"rotationIntervalDays,omitempty"`
+}
@@ -0,0 +1,27 @@
+package doc
+
+import (
+	"time"
+
+	"github.com/kamva/mgm/v3"
+)
+
+type EmergencyAccessEvent struct {
+	Status string    `bson:"status"`
+	At     time.Time `bson:"at"`
+	By     string    `bson:"by,omitempty"`
+}
+
+type EmergencyAccess struct {
+	mgm.DefaultModel `bson:",inline"`
+	GrantorID        string                 `bson:"grantorId"`
+	GranteeID        string                 `bson:"granteeId"`
+	WaitDays         int                    `bson:"waitDays"`
+	WrappedKey       string                 `bson:"wrappedKey,omitempty"`
+	Status           string                 `bson:"status"`
+	RequestedAt      time.Time              `bson:"requestedAt,omitempty"`
+	AutoApproveAt    time.Time              `bson:"autoApproveAt,omitempty"`
+	ReminderSent     bool                   `bson:"reminderSent,omitempty"`
+	LastAccessedAt   time.Time              `bson:"lastAccessedAt,omitempty"`
+	History          []EmergencyAccessEvent `bson:"history"`
+}
@@ -0,0 +1,8 @@
+package doc
+
+// Fields mgm.DefaultModel stores its timestamps in. Queries and raw updates have to use these names,
+// the Go field names differ.
+const (
+	CreatedAtField = "created_at"
+	UpdatedAtField = "updated_at"
+)
@@ -0,0 +1,12 @@
+package doc
+
+import "github.com/kamva/mgm/v3"
+
+type Notification struct {
+	mgm.DefaultModel `bson:",inline"`
+	UserID           string            `bson:"userId"`
+	Type             string            `bson:"type"`
+	Message          string            `bson:"message,omitempty"`
+	Data             map[string]string `bson:"data,omitempty"`
+	Read             bool              `bson:"read"`
+}
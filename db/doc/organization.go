@@ -8,10 +8,21 @@ import (
 
 type Organization struct {
 	mgm.DefaultModel `bson:",inline"`
-	Name             string    `bson:"name,omitempty"`
-	BillingEmail     string    `bson:"billingEmail,omitempty"`
-	AdminEmail       string    `bson:"adminEmail,omitempty"`
-	SymmKey          SymKey    `bson:"symKey,omitempty"`
-	SoftDelete       bool      `bson:"softDelete,omitempty"`
-	DeleteTimeStamp  time.Time `bson:"deleteTs,omitempty"`
+	Name             string        `bson:"name,omitempty"`
+	BillingEmail     string        `bson:"billingEmail,omitempty"`
+	AdminEmail       string        `bson:"adminEmail,omitempty"`
+	SymmKey          SymKey        `bson:"symKey,omitempty"`
+	SoftDelete       bool          `bson:"softDelete,omitempty"`
+	DeleteTimeStamp  time.Time     `bson:"deleteTs,omitempty"`
+	AttachmentQuota  int64         `bson:"attachmentQuota,omitempty"`
+	Version          int64         `bson:"version,omitempty"`
+	HealthPolicy     *HealthPolicy `bson:"healthPolicy,omitempty"`
+	// AttachmentBytes counts the bytes of the stored attachments and open uploads against the quota.
+	// Organizations from before the counter have none until their next upload starts it.
+	AttachmentBytes *int64 `bson:"attachmentBytes,omitempty"`
+}
+
+type HealthPolicy struct {
+	MaxAgeDays int `bson:"maxAgeDays"`
+	MaxShares  int `bson:"maxShares"`
 }
@@ -0,0 +1,18 @@
+package doc
+
+import "github.com/kamva/mgm/v3"
+
+type ProjectEnvironment struct {
+	Slug     string `bson:"slug"`
+	Name     string `bson:"name,omitempty"`
+	Position int    `bson:"position"`
+}
+
+type Project struct {
+	mgm.DefaultModel `bson:",inline"`
+	OrganizationID   string               `bson:"organizationId"`
+	Name             string               `bson:"name"`
+	Slug             string               `bson:"slug"`
+	Description      string               `bson:"description,omitempty"`
+	Environments     []ProjectEnvironment `bson:"environments"`
+}
@@ -8,20 +8,79 @@ import (
 )
 
 type SecretUser struct {
+	ID                 string    `bson:"id,omitempty"`
+	Role               string    `bson:"role,omitempty"`
+	AccessExpiresAt    time.Time `bson:"accessExpiresAt,omitempty"`
+	ExpiryReminderSent bool      `bson:"expiryReminderSent,omitempty"`
+	// RestoreRole is the role a temporarily upgraded share falls back to when its access expires.
+	RestoreRole            string    `bson:"restoreRole,omitempty"`
+	RestoreAccessExpiresAt time.Time `bson:"restoreAccessExpiresAt,omitempty"`
+}
+
+type SecretTeam struct {
 	ID   string `bson:"id,omitempty"`
 	Role string `bson:"role,omitempty"`
 }
 
 type Secret struct {
-	mgm.DefaultModel `bson:",inline"`
-	EncryptedData    string              `bson:"encryptedData,omitempty"`
-	User             SecretUser          `bson:"user,omitempty"`
-	Name             string              `bson:"name,omitempty"`
-	Description      string              `bson:"description,omitempty"`
-	Tags             []string            `bson:"tags,omitempty"`
-	CreatorEmail     string              `bson:"creatorEmail,omitempty"`
-	Type             string              `bson:"type,omitempty"`
-	ReferenceKey     *primitive.ObjectID `bson:"referenceKey,omitempty"`
-	OrganizationID   string              `bson:"organizationId"`
-	ExpiresAt        time.Time           `bson:"expiresAt,omitempty"`
+	mgm.DefaultModel     `bson:",inline"`
+	EncryptedData        string              `bson:"encryptedData,omitempty"`
+	User                 SecretUser          `bson:"user,omitempty"`
+	Name                 string              `bson:"name,omitempty"`
+	Description          string              `bson:"description,omitempty"`
+	Tags                 []string            `bson:"tags,omitempty"`
+	CreatorEmail         string              `bson:"creatorEmail,omitempty"`
+	Type                 string              `bson:"type,omitempty"`
+	Metadata             map[string]string   `bson:"metadata,omitempty"`
+	ReferenceKey         *primitive.ObjectID `bson:"referenceKey,omitempty"`
+	Team                 *SecretTeam         `bson:"team,omitempty"`
+	CollectionID         *primitive.ObjectID `bson:"collectionId,omitempty"`
+	ProjectID            *primitive.ObjectID `bson:"projectId,omitempty"`
+	Environment          string              `bson:"environment,omitempty"`
+	OrganizationID       string              `bson:"organizationId"`
+	ExpiresAt            time.Time           `bson:"expiresAt,omitempty"`
+	Checkout             *SecretCheckout     `bson:"checkout,omitempty"`
+	RotationRequired     bool                `bson:"rotationRequired,omitempty"`
+	RotationIntervalDays int                 `bson:"rotationIntervalDays,omitempty"`
+	LastRotatedAt        time.Time           `bson:"lastRotatedAt,omitempty"`
+	RotationDueAt        time.Time           `bson:"rotationDueAt,omitempty"`
+	RotationRemindedAt   time.Time           `bson:"rotationRemindedAt,omitempty"`
+	Version              int64               `bson:"version,omitempty"`
+	ValueFingerprint     string              `bson:"valueFingerprint,omitempty"`
+	Signature            *SecretSignature    `bson:"signature,omitempty"`
+	CertificateChain     string              `bson:"certificateChain,omitempty"`
+	Certificate          *SecretCertificate  `bson:"certificate,omitempty"`
+}
+
+type SecretCertificate struct {
+	Subject      string    `bson:"subject"`
+	Issuer       string    `bson:"issuer"`
+	SerialNumber string    `bson:"serialNumber"`
+	SANs         []string  `bson:"sans"`
+	NotBefore    time.Time `bson:"notBefore"`
+	NotAfter     time.Time `bson:"notAfter"`
+	Fingerprint  string    `bson:"fingerprint"`
+	ChainLength  int       `bson:"chainLength"`
+}
+
+type SecretSignature struct {
+	Value    string    `bson:"value"`
+	KeyID    string    `bson:"keyId"`
+	Alg      string    `bson:"alg"`
+	SignedBy string    `bson:"signedBy"`
+	SignedAt time.Time `bson:"signedAt"`
+}
+
+type SecretCheckoutQueueEntry struct {
+	UserID      string    `bson:"userId"`
+	RequestedAt time.Time `bson:"requestedAt"`
+}
+
+type SecretCheckout struct {
+	Enabled        bool                       `bson:"enabled"`
+	LeaseMinutes   int                        `bson:"leaseMinutes"`
+	UserID         string                     `bson:"userId,omitempty"`
+	CheckedOutAt   time.Time                  `bson:"checkedOutAt,omitempty"`
+	LeaseExpiresAt time.Time                  `bson:"leaseExpiresAt,omitempty"`
+	Queue          []SecretCheckoutQueueEntry `bson:"queue"`
 }
@@ -0,0 +1,21 @@
+package doc
+
+import (
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SecretAccess counts the reads of an original secret by one consumer.
+type SecretAccess struct {
+	mgm.DefaultModel `bson:",inline"`
+	SecretID         primitive.ObjectID `bson:"secretId"`
+	OrganizationID   string             `bson:"organizationId"`
+	ConsumerKind     string             `bson:"consumerKind"`
+	ConsumerID       string             `bson:"consumerId"`
+	UserID           string             `bson:"userId"`
+	AccessCount      int64              `bson:"accessCount"`
+	FirstAccessedAt  time.Time          `bson:"firstAccessedAt"`
+	LastAccessedAt   time.Time          `bson:"lastAccessedAt"`
+}
@@ -0,0 +1,30 @@
+package doc
+
+import (
+	"time"
+
+	"github.com/kamva/mgm/v3"
+)
+
+type ShareLinkEvent struct {
+	Type      string    `bson:"type"`
+	At        time.Time `bson:"at"`
+	IP        string    `bson:"ip,omitempty"`
+	UserAgent string    `bson:"userAgent,omitempty"`
+}
+
+type ShareLink struct {
+	mgm.DefaultModel  `bson:",inline"`
+	LinkID            string           `bson:"linkId"`
+	CreatedBy         string           `bson:"createdBy"`
+	OrganizationID    string           `bson:"organizationId"`
+	Description       string           `bson:"description,omitempty"`
+	EncryptedData     string           `bson:"encryptedData,omitempty"`
+	MaxViews          int              `bson:"maxViews"`
+	Views             int              `bson:"views"`
+	ExpiresAt         time.Time        `bson:"expiresAt"`
+	PassphraseHash    string           `bson:"passphraseHash,omitempty"`
+	FailedPassphrases int              `bson:"failedPassphrases,omitempty"`
+	Burned            bool             `bson:"burned"`
+	Events            []ShareLinkEvent `bson:"events"`
+}
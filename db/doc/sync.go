@@ -0,0 +1,19 @@
+package doc
+
+import "github.com/kamva/mgm/v3"
+
+// SyncRevision holds the last revision handed out to the user.
+type SyncRevision struct {
+	mgm.DefaultModel `bson:",inline"`
+	UserID           string `bson:"userId"`
+	Revision         int64  `bson:"revision"`
+}
+
+// SyncEvent records that an object visible to the user changed at the revision.
+type SyncEvent struct {
+	mgm.DefaultModel `bson:",inline"`
+	UserID           string `bson:"userId"`
+	Revision         int64  `bson:"revision"`
+	Type             string `bson:"type"`
+	ObjectID         string `bson:"objectId"`
+}
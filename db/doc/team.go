@@ -0,0 +1,22 @@
+package doc
+
+import (
+	"time"
+
+	"github.com/kamva/mgm/v3"
+)
+
+type TeamMember struct {
+	UserID     string    `bson:"userId"`
+	WrappedKey SymKey    `bson:"wrappedKey,omitempty"`
+	Admin      bool      `bson:"admin,omitempty"`
+	AddedAt    time.Time `bson:"addedAt,omitempty"`
+}
+
+type Team struct {
+	mgm.DefaultModel `bson:",inline"`
+	OrganizationID   string       `bson:"organizationId"`
+	Name             string       `bson:"name,omitempty"`
+	Description      string       `bson:"description,omitempty"`
+	Members          []TeamMember `bson:"members"`
+}
@@ -20,6 +20,7 @@ type User struct {
 	PassHash         PassHash           `bson:"passHash,omitempty"`
 	SymKey           SymKey             `bson:"symKey,omitempty"`
 	AsymmKey         AsymmKey           `bson:"asymmKey,omitempty"`
+	SigningKeys      []SigningKey       `bson:"signingKeys,omitempty"`
 	CreatedAt        time.Time          `bson:"createdAt,omitempty"`
 	UpdatedAt        time.Time          `bson:"updatedAt,omitempty"`
 	IsBlackListed    bool               `bson:"isBlackListed,omitempty"`
@@ -41,3 +42,10 @@ type AsymmKey struct {
 	EncryptedPvtKey string `bson:"encryptedPvtKey,omitempty"`
 	Alg             string `bson:"alg,omitempty"`
 }
+
+type SigningKey struct {
+	KeyID        string    `bson:"keyId"`
+	Public       string    `bson:"public"`
+	Alg          string    `bson:"alg"`
+	RegisteredAt time.Time `bson:"registeredAt"`
+}
@@ -0,0 +1,291 @@
+package db
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the indexes the queries rely on. Existing indexes are left as they are.
+func (d *DB) EnsureIndexes(ctx context.Context) error {
+	secretIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "name", Value: "text"},
+				{Key: "description", Value: "text"},
+			},
+			Options: options.Index().SetName("secret_text").SetWeights(bson.M{
+				"name":        10,
+				"description": 2,
+			}),
+		},
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "tags", Value: 1}},
+			Options: options.Index().SetName("secret_org_tags"),
+		},
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "name", Value: 1}},
+			Options: options.Index().SetName("secret_org_name"),
+		},
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "type", Value: 1}},
+			Options: options.Index().SetName("secret_org_type"),
+		},
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("secret_org_updated"),
+		},
+		{
+			Keys:    bson.D{{Key: "user.id", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("secret_user_updated"),
+		},
+		{
+			Keys:    bson.D{{Key: "referenceKey", Value: 1}},
+			Options: options.Index().SetName("secret_reference"),
+		},
+		{
+			Keys:    bson.D{{Key: "team.id", Value: 1}},
+			Options: options.Index().SetName("secret_team").SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "collectionId", Value: 1}},
+			Options: options.Index().SetName("secret_collection").SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "projectId", Value: 1}, {Key: "environment", Value: 1}, {Key: "name", Value: 1}},
+			Options: options.Index().SetName("secret_project_env_name").SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "checkout.leaseExpiresAt", Value: 1}},
+			Options: options.Index().SetName("secret_checkout_lease").SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "rotationDueAt", Value: 1}},
+			Options: options.Index().SetName("secret_rotation_due").SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "certificate.notAfter", Value: 1}},
+			Options: options.Index().SetName("secret_org_certificate_expiry").SetSparse(true),
+		},
+	}
+
+	_, err := mgm.Coll(&doc.Secret{}).Indexes().CreateMany(ctx, secretIndexes)
+
+	if err != nil {
+		return err
+	}
+
+	attachmentIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "secretId", Value: 1}},
+			Options: options.Index().SetName("attachment_secret"),
+		},
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}},
+			Options: options.Index().SetName("attachment_org"),
+		},
+	}
+
+	_, err = mgm.Coll(&doc.Attachment{}).Indexes().CreateMany(ctx, attachmentIndexes)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = mgm.Coll(&doc.AttachmentUpload{}).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("attachment_upload_org_expiry"),
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = mgm.Coll(&doc.ShareLink{}).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "linkId", Value: 1}},
+			Options: options.Index().SetName("share_link_id").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "createdBy", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("share_link_creator"),
+		},
+		{
+			Keys:    bson.D{{Key: "burned", Value: 1}, {Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("share_link_expiry"),
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = mgm.Coll(&doc.AccessRequest{}).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "secretId", Value: 1}, {Key: "status", Value: 1}},
+			Options: options.Index().SetName("access_request_secret_status"),
+		},
+		{
+			Keys:    bson.D{{Key: "requesterId", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("access_request_requester"),
+		},
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "status", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("access_request_org_status"),
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = mgm.Coll(&doc.AuditEvent{}).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("audit_org_updated"),
+		},
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "targetId", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("audit_org_target"),
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = mgm.Coll(&doc.BreakGlassVault{}).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+		Options: options.Index().SetName("break_glass_vault_org"),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = mgm.Coll(&doc.BreakGlassUnlock{}).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// A vault can only have one pending unlock at a time.
+			Keys: bson.D{{Key: "vaultId", Value: 1}},
+			Options: options.Index().SetName("break_glass_unlock_pending").SetUnique(true).SetPartialFilterExpression(bson.M{
+				"status": model.BreakGlassUnlockPending,
+			}),
+		},
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "status", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("break_glass_unlock_org_status"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("break_glass_unlock_expiry"),
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = mgm.Coll(&doc.EmergencyAccess{}).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "grantorId", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("emergency_access_grantor"),
+		},
+		{
+			Keys:    bson.D{{Key: "granteeId", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("emergency_access_grantee"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "autoApproveAt", Value: 1}},
+			Options: options.Index().SetName("emergency_access_wait"),
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Every consumer of a secret has one access document.
+	_, err = mgm.Coll(&doc.SecretAccess{}).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "secretId", Value: 1}, {Key: "consumerKind", Value: 1}, {Key: "consumerId", Value: 1}},
+			Options: options.Index().SetName("secret_access_consumer").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: "lastAccessedAt", Value: -1}},
+			Options: options.Index().SetName("secret_access_org_last"),
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Lists page by updated_at and _id, newest first.
+	_, err = mgm.Coll(&doc.User{}).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "organizations.id", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+		Options: options.Index().SetName("user_org_updated"),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = mgm.Coll(&doc.Invite{}).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "organizationId", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("invite_org_updated"),
+		},
+		{
+			Keys:    bson.D{{Key: "toUserEmail", Value: 1}, {Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("invite_recipient_updated"),
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = mgm.Coll(&doc.SyncRevision{}).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}},
+		Options: options.Index().SetName("sync_revision_user").SetUnique(true),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Sync events are dropped after the retention, clients behind it have to sync from scratch.
+	_, err = mgm.Coll(&doc.SyncEvent{}).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "revision", Value: 1}},
+			Options: options.Index().SetName("sync_event_user_revision").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "objectId", Value: 1}, {Key: "revision", Value: 1}},
+			Options: options.Index().SetName("sync_event_user_object"),
+		},
+		{
+			Keys:    bson.D{{Key: doc.CreatedAtField, Value: 1}},
+			Options: options.Index().SetName("sync_event_retention").SetExpireAfterSeconds(int32(model.SyncEventRetention.Seconds())),
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// A chunk can only be stored once for every offset of an upload.
+	_, err = mgm.Coll(&doc.AttachmentChunk{}).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "uploadId", Value: 1}, {Key: "offset", Value: 1}},
+		Options: options.Index().SetName("attachment_chunk_upload_offset").SetUnique(true),
+	})
+
+	return err
+}
@@ -56,8 +56,16 @@ func main() {
 		return
 	}
 
+	err = db.EnsureIndexes(ctx)
+	if err != nil {
+		logger.WithContext(ctx).WithError(err).Error("failed to create mongodb indexes")
+		return
+	}
+
 	svc := svc.New(logger, db)
 
+	svc.Scheduler.Start(ctx)
+
 	controller := controller.New(logger, svc)
 
 	httpRouter, err := router.Init(logger, controller)
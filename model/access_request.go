@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// Statuses of an access request.
+const (
+	AccessRequestRequested = "requested"
+	AccessRequestApproved  = "approved"
+	AccessRequestDenied    = "denied"
+	AccessRequestCancelled = "cancelled"
+	AccessRequestExpired   = "expired"
+)
+
+type AccessRequestID string
+
+func (a AccessRequestID) String() string {
+	return string(a)
+}
+
+// AccessRequestEvent is one step in the lifecycle of an access request.
+type AccessRequestEvent struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+	By     UserID    `json:"by,omitempty"`
+	Note   string    `json:"note,omitempty"`
+}
+
+// AccessRequest asks for time bounded access to a secret. Approval creates a share which lapses at AccessExpiresAt.
+type AccessRequest struct {
+	ID              AccessRequestID      `json:"id"`
+	CreatedAt       time.Time            `json:"createdAt"`
+	UpdatedAt       time.Time            `json:"updatedAt"`
+	SecretID        SecretID             `json:"secretId"`
+	OrganizationID  string               `json:"organizationId"`
+	RequesterID     UserID               `json:"requesterId"`
+	Role            string               `json:"role"`
+	Reason          string               `json:"reason"`
+	DurationMinutes int                  `json:"durationMinutes"`
+	Status          string               `json:"status"`
+	DecidedBy       UserID               `json:"decidedBy,omitempty"`
+	AccessExpiresAt time.Time            `json:"accessExpiresAt,omitempty"`
+	History         []AccessRequestEvent `json:"history"`
+}
+
+// AccessRequestDecision is sent by an approver. DurationMinutes can shorten the requested access.
+type AccessRequestDecision struct {
+	Note            string `json:"note"`
+	DurationMinutes int    `json:"durationMinutes"`
+}
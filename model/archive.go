@@ -0,0 +1,108 @@
+package model
+
+import "time"
+
+// ArchiveFormatVersion is the version of the organization archives written by this deployment.
+const ArchiveFormatVersion = 1
+
+// Files of an organization archive. The manifest is the last entry and holds the hashes of the others.
+const (
+	ArchiveManifestFile     = "manifest.json"
+	ArchiveOrganizationFile = "organization.json"
+	ArchiveMembersFile      = "members.json"
+	ArchiveInvitesFile      = "invites.json"
+	ArchiveSecretsFile      = "secrets.json"
+	ArchiveSharesFile       = "shares.json"
+)
+
+// ArchiveFile is the manifest entry of one file of the archive.
+type ArchiveFile struct {
+	Name    string `json:"name"`
+	SHA256  string `json:"sha256"`
+	Size    int64  `json:"size"`
+	Records int    `json:"records"`
+}
+
+type ArchiveManifest struct {
+	FormatVersion  int           `json:"formatVersion"`
+	OrganizationID string        `json:"organizationId"`
+	ExportedAt     time.Time     `json:"exportedAt"`
+	ExportedBy     UserID        `json:"exportedBy"`
+	Files          []ArchiveFile `json:"files"`
+}
+
+type ArchiveOrganization struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	BillingEmail    string `json:"billingEmail"`
+	AdminEmail      string `json:"adminEmail"`
+	SymmKey         SymKey `json:"symKey"`
+	AttachmentQuota int64  `json:"attachmentQuota,omitempty"`
+}
+
+// ArchiveMember is a member of the organization with the organization key wrapped for its public key.
+type ArchiveMember struct {
+	UserID    string `json:"userId"`
+	Email     Email  `json:"email"`
+	IsAdmin   bool   `json:"isAdmin"`
+	PvtKey    string `json:"pvtKey"`
+	PublicKey string `json:"publicKey"`
+}
+
+type ArchiveInvite struct {
+	ID            string    `json:"id"`
+	FromUserEmail string    `json:"fromUserEmail"`
+	ToUserEmail   string    `json:"toUserEmail"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+	SymKey        SymKey    `json:"symKey"`
+}
+
+// ArchiveSecret is an original secret or, with a reference key, the share envelope of a member or a team.
+// Teams, collections and projects are not part of the archive, the secrets only keep their ids.
+type ArchiveSecret struct {
+	ID                   string            `json:"id"`
+	ReferenceKey         string            `json:"referenceKey,omitempty"`
+	UserID               string            `json:"userId"`
+	Role                 string            `json:"role"`
+	AccessExpiresAt      time.Time         `json:"accessExpiresAt,omitempty"`
+	EncryptedData        string            `json:"encryptedData"`
+	Name                 string            `json:"name"`
+	Description          string            `json:"description,omitempty"`
+	Tags                 []string          `json:"tags,omitempty"`
+	CreatorEmail         string            `json:"creatorEmail"`
+	Type                 string            `json:"type,omitempty"`
+	Metadata             map[string]string `json:"metadata,omitempty"`
+	ExpiresAt            time.Time         `json:"expiresAt,omitempty"`
+	RotationIntervalDays int               `json:"rotationIntervalDays,omitempty"`
+	LastRotatedAt        time.Time         `json:"lastRotatedAt,omitempty"`
+	RotationDueAt        time.Time         `json:"rotationDueAt,omitempty"`
+	CertificateChain     string            `json:"certificateChain,omitempty"`
+	TeamID               string            `json:"teamId,omitempty"`
+	CollectionID         string            `json:"collectionId,omitempty"`
+	ProjectID            string            `json:"projectId,omitempty"`
+	Environment          string            `json:"environment,omitempty"`
+	Signature            *SecretSignature  `json:"signature,omitempty"`
+	CreatedAt            time.Time         `json:"createdAt"`
+	UpdatedAt            time.Time         `json:"updatedAt"`
+}
+
+// ArchiveImportReport sums up what an import created, or would create on a dry run.
+// Members are matched by email and public key, secrets of unmatched owners go to the importing user.
+// Matched members other than the importing user are invited rather than added. Teams, collections and
+// projects are not imported, so team shares and placements are dropped, and signatures are dropped since
+// they cover the id of the archived organization.
+type ArchiveImportReport struct {
+	DryRun            bool           `json:"dryRun"`
+	OrganizationID    OrganizationID `json:"organizationId,omitempty"`
+	Members           int            `json:"members"`
+	InvitedMembers    int            `json:"invitedMembers"`
+	UnmatchedMembers  []Email        `json:"unmatchedMembers"`
+	Secrets           int            `json:"secrets"`
+	ReassignedSecrets int            `json:"reassignedSecrets"`
+	Shares            int            `json:"shares"`
+	DroppedShares     int            `json:"droppedShares"`
+	DroppedTeamShares int            `json:"droppedTeamShares"`
+	DroppedPlacements int            `json:"droppedPlacements"`
+	DroppedSignatures int            `json:"droppedSignatures"`
+	Invites           int            `json:"invites"`
+}
@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+type AttachmentID string
+
+func (a AttachmentID) String() string {
+	return string(a)
+}
+
+type AttachmentUploadID string
+
+func (a AttachmentUploadID) String() string {
+	return string(a)
+}
+
+// Attachment is a client encrypted file stored next to a secret. Size and SHA256 are computed over the encrypted bytes.
+type Attachment struct {
+	ID             AttachmentID `json:"id"`
+	CreatedAt      time.Time    `json:"createdAt"`
+	SecretID       SecretID     `json:"secretId"`
+	OrganizationID string       `json:"organizationId"`
+	Name           string       `json:"name"`
+	ContentType    string       `json:"contentType"`
+	Size           int64        `json:"size"`
+	SHA256         string       `json:"sha256"`
+	UploadedBy     UserID       `json:"uploadedBy"`
+}
+
+// AttachmentUpload is a resumable upload session. Chunks are appended at ReceivedSize until Size bytes are received.
+type AttachmentUpload struct {
+	ID             AttachmentUploadID `json:"id"`
+	CreatedAt      time.Time          `json:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt"`
+	SecretID       SecretID           `json:"secretId"`
+	OrganizationID string             `json:"organizationId"`
+	Name           string             `json:"name"`
+	ContentType    string             `json:"contentType"`
+	Size           int64              `json:"size"`
+	SHA256         string             `json:"sha256"`
+	ReceivedSize   int64              `json:"receivedSize"`
+	ExpiresAt      time.Time          `json:"expiresAt"`
+	UploadedBy     UserID             `json:"uploadedBy"`
+}
+
+// AttachmentUsage is the attachment storage used by an organization. Reserved counts the open upload sessions.
+type AttachmentUsage struct {
+	OrganizationID string `json:"organizationId"`
+	Used           int64  `json:"used"`
+	Reserved       int64  `json:"reserved"`
+	Quota          int64  `json:"quota"`
+}
+
+type AttachmentQuota struct {
+	Quota int64 `json:"quota"`
+}
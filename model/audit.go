@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Actions recorded in the audit log.
+const (
+	AuditBreakGlassVaultCreated    = "break-glass/vault-created"
+	AuditBreakGlassVaultDeleted    = "break-glass/vault-deleted"
+	AuditBreakGlassUnlockStarted   = "break-glass/unlock-started"
+	AuditBreakGlassShareSubmitted  = "break-glass/share-submitted"
+	AuditBreakGlassUnlocked        = "break-glass/unlocked"
+	AuditBreakGlassSharesCollected = "break-glass/shares-collected"
+	AuditBreakGlassUnlockCancelled = "break-glass/unlock-cancelled"
+	AuditBreakGlassUnlockExpired   = "break-glass/unlock-expired"
+	AuditSecretOwnershipTransfer   = "secret/ownership-transferred"
+	AuditSecretOwnersReassigned    = "secret/owners-reassigned"
+	AuditOrganizationExported      = "organization/exported"
+	AuditOrganizationImported      = "organization/imported"
+)
+
+// Types of the resources audit events point at.
+const (
+	AuditTargetBreakGlassVault  = "break-glass-vault"
+	AuditTargetBreakGlassUnlock = "break-glass-unlock"
+	AuditTargetSecret           = "secret"
+	AuditTargetUser             = "user"
+	AuditTargetOrganization     = "organization"
+)
+
+type AuditEventID string
+
+func (a AuditEventID) String() string {
+	return string(a)
+}
+
+// AuditEvent records who did what on a resource of an organization. ActorID is empty for the actions of the system.
+type AuditEvent struct {
+	ID             AuditEventID      `json:"id"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	OrganizationID string            `json:"organizationId"`
+	ActorID        UserID            `json:"actorId,omitempty"`
+	Action         string            `json:"action"`
+	TargetType     string            `json:"targetType"`
+	TargetID       string            `json:"targetId"`
+	Data           map[string]string `json:"data,omitempty"`
+}
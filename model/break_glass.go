@@ -0,0 +1,88 @@
+package model
+
+import "time"
+
+// Statuses of a break-glass unlock.
+const (
+	BreakGlassUnlockPending   = "pending"
+	BreakGlassUnlockUnlocked  = "unlocked"
+	BreakGlassUnlockCancelled = "cancelled"
+	BreakGlassUnlockExpired   = "expired"
+)
+
+type BreakGlassVaultID string
+
+func (b BreakGlassVaultID) String() string {
+	return string(b)
+}
+
+type BreakGlassUnlockID string
+
+func (b BreakGlassUnlockID) String() string {
+	return string(b)
+}
+
+// BreakGlassCustodian is an admin holding one Shamir share of the emergency key, encrypted with the public key of the admin.
+// The share is only returned to the custodian who holds it.
+type BreakGlassCustodian struct {
+	UserID         UserID `json:"userId"`
+	EncryptedShare string `json:"encryptedShare,omitempty"`
+}
+
+// BreakGlassVault is sealed emergency material of an organization. EncryptedPayload is encrypted with the emergency key,
+// which is split among the custodians so that Threshold of them are needed to rebuild it.
+type BreakGlassVault struct {
+	ID               BreakGlassVaultID     `json:"id"`
+	CreatedAt        time.Time             `json:"createdAt"`
+	UpdatedAt        time.Time             `json:"updatedAt"`
+	OrganizationID   string                `json:"organizationId"`
+	Name             string                `json:"name"`
+	Description      string                `json:"description"`
+	Threshold        int                   `json:"threshold"`
+	EncryptedPayload string                `json:"encryptedPayload,omitempty"`
+	Custodians       []BreakGlassCustodian `json:"custodians"`
+	CreatedBy        UserID                `json:"createdBy"`
+}
+
+// BreakGlassSubmission is the share of a custodian for an unlock, encrypted with the recipient key of the unlock.
+type BreakGlassSubmission struct {
+	UserID         UserID    `json:"userId"`
+	SubmittedAt    time.Time `json:"submittedAt"`
+	EncryptedShare string    `json:"encryptedShare,omitempty"`
+}
+
+// BreakGlassUnlock collects the shares of the custodians until the quorum of the vault is reached.
+type BreakGlassUnlock struct {
+	ID                 BreakGlassUnlockID     `json:"id"`
+	CreatedAt          time.Time              `json:"createdAt"`
+	UpdatedAt          time.Time              `json:"updatedAt"`
+	VaultID            BreakGlassVaultID      `json:"vaultId"`
+	OrganizationID     string                 `json:"organizationId"`
+	RequestedBy        UserID                 `json:"requestedBy"`
+	Reason             string                 `json:"reason"`
+	RecipientPublicKey string                 `json:"recipientPublicKey"`
+	Threshold          int                    `json:"threshold"`
+	Status             string                 `json:"status"`
+	Submissions        []BreakGlassSubmission `json:"submissions"`
+	ExpiresAt          time.Time              `json:"expiresAt"`
+	UnlockedAt         time.Time              `json:"unlockedAt,omitempty"`
+}
+
+// BreakGlassUnlockStart opens an unlock. Custodians encrypt their shares with RecipientPublicKey.
+type BreakGlassUnlockStart struct {
+	Reason             string `json:"reason"`
+	RecipientPublicKey string `json:"recipientPublicKey"`
+}
+
+// BreakGlassShareSubmission is sent by a custodian to approve an unlock.
+type BreakGlassShareSubmission struct {
+	EncryptedShare string `json:"encryptedShare"`
+}
+
+// BreakGlassMaterial is handed to the requester of an unlocked vault to rebuild the emergency key and open the payload.
+type BreakGlassMaterial struct {
+	UnlockID         BreakGlassUnlockID     `json:"unlockId"`
+	VaultID          BreakGlassVaultID      `json:"vaultId"`
+	EncryptedPayload string                 `json:"encryptedPayload"`
+	Shares           []BreakGlassSubmission `json:"shares"`
+}
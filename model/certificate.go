@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// CertificateInfo is what the server read from the leaf certificate of the chain of a TLS secret.
+type CertificateInfo struct {
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serialNumber"`
+	SANs         []string  `json:"sans"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	Fingerprint  string    `json:"fingerprint"`
+	ChainLength  int       `json:"chainLength"`
+}
+
+// ExpiringCertificate is a TLS secret whose certificate expires within the asked days, or already expired.
+type ExpiringCertificate struct {
+	HealthSecret
+	Certificate   CertificateInfo `json:"certificate"`
+	ExpiresInDays int             `json:"expiresInDays"`
+}
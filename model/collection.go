@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+type CollectionID string
+
+func (c CollectionID) String() string {
+	return string(c)
+}
+
+// CollectionGrant gives a member or a team of the organization a role on a collection and on everything nested in it.
+// The role is editor, viewer or use-only, ownership of a secret is never granted through a collection.
+type CollectionGrant struct {
+	UserID UserID `json:"userId,omitempty"`
+	TeamID TeamID `json:"teamId,omitempty"`
+	Role   string `json:"role"`
+}
+
+type Collection struct {
+	ID                   CollectionID      `json:"id"`
+	CreatedAt            time.Time         `json:"createdAt"`
+	UpdatedAt            time.Time         `json:"updatedAt"`
+	OrganizationID       string            `json:"organizationId"`
+	Name                 string            `json:"name"`
+	ParentID             *CollectionID     `json:"parentId"`
+	Path                 string            `json:"path"`
+	Grants               []CollectionGrant `json:"grants"`
+	RotationIntervalDays int               `json:"rotationIntervalDays,omitempty"`
+}
+
+// RotationPolicy sets how many days a secret value can live before it must be rotated. Zero removes the policy.
+type RotationPolicy struct {
+	IntervalDays int `json:"intervalDays"`
+}
+
+type CollectionMove struct {
+	ParentID *CollectionID `json:"parentId"`
+}
+
+type SecretCollectionMove struct {
+	CollectionID *CollectionID `json:"collectionId"`
+}
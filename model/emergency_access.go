@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// Statuses of an emergency access grant.
+const (
+	EmergencyAccessGranted   = "granted"
+	EmergencyAccessRequested = "requested"
+	EmergencyAccessApproved  = "approved"
+	EmergencyAccessRejected  = "rejected"
+	EmergencyAccessRevoked   = "revoked"
+)
+
+type EmergencyAccessID string
+
+func (e EmergencyAccessID) String() string {
+	return string(e)
+}
+
+// EmergencyAccessEvent is one step in the lifecycle of an emergency access grant.
+type EmergencyAccessEvent struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+	By     UserID    `json:"by,omitempty"`
+}
+
+// EmergencyAccess names a trusted contact who can ask for the vault of the grantor. A request is approved
+// automatically after WaitDays unless the grantor rejects it. WrappedKey is the key of the grantor encrypted
+// with the public key of the grantee and is only handed out once the request is approved.
+type EmergencyAccess struct {
+	ID             EmergencyAccessID      `json:"id"`
+	CreatedAt      time.Time              `json:"createdAt"`
+	UpdatedAt      time.Time              `json:"updatedAt"`
+	GrantorID      UserID                 `json:"grantorId"`
+	GranteeID      UserID                 `json:"granteeId"`
+	WaitDays       int                    `json:"waitDays"`
+	WrappedKey     string                 `json:"wrappedKey,omitempty"`
+	Status         string                 `json:"status"`
+	RequestedAt    time.Time              `json:"requestedAt,omitempty"`
+	AutoApproveAt  time.Time              `json:"autoApproveAt,omitempty"`
+	LastAccessedAt time.Time              `json:"lastAccessedAt,omitempty"`
+	History        []EmergencyAccessEvent `json:"history"`
+}
+
+// EmergencyAccessKey replaces the wrapped key of a grant after the grantor rotated their key.
+type EmergencyAccessKey struct {
+	WrappedKey string `json:"wrappedKey"`
+}
+
+// EmergencyAccessVault is what the grantee gets once access is approved. Secrets holds one page,
+// HasMore and NextCursor tell how to ask for the next one.
+type EmergencyAccessVault struct {
+	Grant      EmergencyAccess `json:"grant"`
+	WrappedKey string          `json:"wrappedKey"`
+	Secrets    []Secret        `json:"secrets"`
+	HasMore    bool            `json:"hasMore"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// HealthPolicy sets the limits the password health report checks the secrets of an organization against.
+// A zero limit turns its check off.
+type HealthPolicy struct {
+	// MaxAgeDays is how old a secret value can get before it counts as stale, on top of the rotation policies.
+	MaxAgeDays int `json:"maxAgeDays"`
+	// MaxShares is how many users can hold a secret besides its owner, team members included.
+	MaxShares int `json:"maxShares"`
+}
+
+// HealthSecret identifies a secret in the health report.
+type HealthSecret struct {
+	ID           SecretID      `json:"id"`
+	Name         string        `json:"name"`
+	OwnerID      UserID        `json:"ownerId"`
+	CollectionID *CollectionID `json:"collectionId,omitempty"`
+}
+
+// ReusedCredential groups the secrets whose values have the same fingerprint.
+type ReusedCredential struct {
+	Secrets []HealthSecret `json:"secrets"`
+}
+
+// StaleSecret is a secret whose value is older than the rotation policies or the max age allow.
+type StaleSecret struct {
+	HealthSecret
+	LastRotatedAt    time.Time `json:"lastRotatedAt"`
+	AgeDays          int       `json:"ageDays"`
+	RotationDueAt    time.Time `json:"rotationDueAt,omitempty"`
+	RotationRequired bool      `json:"rotationRequired,omitempty"`
+}
+
+// OverSharedSecret is a secret held by more users than the policy allows.
+type OverSharedSecret struct {
+	HealthSecret
+	Shares int `json:"shares"`
+}
+
+// HealthReport is built from the fingerprints and the metadata of the secrets, nothing is decrypted.
+// Secrets without a fingerprint can not be checked for reuse and are only counted.
+type HealthReport struct {
+	OrganizationID string             `json:"organizationId"`
+	GeneratedAt    time.Time          `json:"generatedAt"`
+	Policy         HealthPolicy       `json:"policy"`
+	TotalSecrets   int                `json:"totalSecrets"`
+	Fingerprinted  int                `json:"fingerprinted"`
+	Reused         []ReusedCredential `json:"reused"`
+	Stale          []StaleSecret      `json:"stale"`
+	OverShared     []OverSharedSecret `json:"overShared"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// Notification types sent to the users.
+const (
+	NotificationShareExpiring            = "share/expiring"
+	NotificationShareRevoked             = "share/revoked"
+	NotificationShareLinkOpened          = "share-link/opened"
+	NotificationAccessRequested          = "access-request/requested"
+	NotificationAccessDecided            = "access-request/decided"
+	NotificationCheckoutGranted          = "checkout/granted"
+	NotificationRotationDue              = "rotation/due"
+	NotificationCheckoutForced           = "checkout/forced-checkin"
+	NotificationOwnershipTransferred     = "secret/ownership-transferred"
+	NotificationBreakGlassUnlockStarted  = "break-glass/unlock-started"
+	NotificationBreakGlassUnlocked       = "break-glass/unlocked"
+	NotificationEmergencyAccessGranted   = "emergency-access/granted"
+	NotificationEmergencyAccessRequested = "emergency-access/requested"
+	NotificationEmergencyAccessReminder  = "emergency-access/reminder"
+	NotificationEmergencyAccessDecided   = "emergency-access/decided"
+	NotificationEmergencyAccessRevoked   = "emergency-access/revoked"
+)
+
+type NotificationID string
+
+func (n NotificationID) String() string {
+	return string(n)
+}
+
+type Notification struct {
+	ID        NotificationID    `json:"id"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UserID    UserID            `json:"userId"`
+	Type      string            `json:"type"`
+	Message   string            `json:"message"`
+	Data      map[string]string `json:"data"`
+	Read      bool              `json:"read"`
+}
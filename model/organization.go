@@ -14,4 +14,6 @@ type Organization struct {
 	BillingEmail string         `json:"billingEmail"`
 	AdminEmail   string         `json:"adminEmail"`
 	SymmKey      SymKey         `json:"symKey"`
+	Version      int64          `json:"version"`
+	HealthPolicy HealthPolicy   `json:"healthPolicy"`
 }
@@ -1,14 +1,36 @@
 package model
 
+import "time"
+
 type PaginationParams struct {
 	Page  int
 	Limit int
 	Skip  int
+	// Cursor continues the list after the last item of the previous page, Skip is ignored with it.
+	Cursor *PageCursor
+	// WithTotal asks for the number of items on all the pages.
+	WithTotal bool
+}
+
+// PageCursor is the sort key of the last item of a page. Lists are ordered by updatedAt and _id, newest first.
+type PageCursor struct {
+	UpdatedAt time.Time
+	ID        string
+}
+
+// PageInfo tells where a page ends. NextCursor is only set while HasMore is, Total only when it was asked for.
+type PageInfo struct {
+	HasMore    bool
+	NextCursor string
+	Total      *int64
 }
 
 type PaginationResponse struct {
-	CurrentPage int `json:"page"`
-	Data        any `json:"data"`
-	Limit       int `json:"limit"`
-	NextPage    int `json:"nextPage"`
+	CurrentPage int    `json:"page"`
+	Data        any    `json:"data"`
+	Limit       int    `json:"limit"`
+	NextPage    int    `json:"nextPage,omitempty"`
+	HasMore     bool   `json:"hasMore"`
+	NextCursor  string `json:"nextCursor,omitempty"`
+	Total       *int64 `json:"total,omitempty"`
 }
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+type ProjectID string
+
+func (p ProjectID) String() string {
+	return string(p)
+}
+
+// ProjectEnvironment is a stage of a project, secrets are promoted in the order of their position.
+type ProjectEnvironment struct {
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+type Project struct {
+	ID             ProjectID            `json:"id"`
+	CreatedAt      time.Time            `json:"createdAt"`
+	UpdatedAt      time.Time            `json:"updatedAt"`
+	OrganizationID string               `json:"organizationId"`
+	Name           string               `json:"name"`
+	Slug           string               `json:"slug"`
+	Description    string               `json:"description"`
+	Environments   []ProjectEnvironment `json:"environments"`
+}
+
+// EnvironmentBundle holds every secret of a project environment, still encrypted,
+// so clients can render it as a dotenv or JSON file.
+type EnvironmentBundle struct {
+	ProjectID   ProjectID `json:"projectId"`
+	ProjectSlug string    `json:"projectSlug"`
+	Environment string    `json:"environment"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Secrets     []Secret  `json:"secrets"`
+}
+
+// SecretPromotion names the environment to promote to. The signature is the one of the promoter over the
+// secret as written to the target, see SecretSignature.
+type SecretPromotion struct {
+	TargetEnvironment string           `json:"targetEnvironment"`
+	Signature         *SecretSignature `json:"signature,omitempty"`
+}
@@ -2,8 +2,38 @@ package model
 
 import "time"
 
+// Roles a user can hold on a secret.
+const (
+	SecretRoleOwner   = "owner"
+	SecretRoleEditor  = "editor"
+	SecretRoleViewer  = "viewer"
+	SecretRoleUseOnly = "use-only"
+)
+
+// SecretRoleRank orders the roles so the strongest grant wins when a user has several.
+func SecretRoleRank(role string) int {
+	switch role {
+	case SecretRoleOwner:
+		return 4
+	case SecretRoleEditor:
+		return 3
+	case SecretRoleViewer:
+		return 2
+	case SecretRoleUseOnly:
+		return 1
+	}
+
+	return 0
+}
+
 type SecretUser struct {
-	ID   UserID `json:"id"`
+	ID              UserID    `json:"id"`
+	Role            string    `json:"role"`
+	AccessExpiresAt time.Time `json:"accessExpiresAt,omitempty"`
+}
+
+type SecretTeam struct {
+	ID   TeamID `json:"id"`
 	Role string `json:"role"`
 }
 
@@ -14,17 +44,141 @@ func (sec SecretID) String() string {
 }
 
 type Secret struct {
-	ID             SecretID   `json:"id"`
-	EncryptedData  string     `json:"encryptedData"`
-	CreatedAt      time.Time  `json:"createdAt"`
-	UpdatedAt      time.Time  `json:"updatedAt"`
-	User           SecretUser `json:"user"`
-	Name           string     `json:"name"`
-	Description    string     `json:"description"`
-	Tags           []string   `json:"tags"`
-	CreatorEmail   string     `json:"creatorEmail"`
-	Type           string     `json:"type"`
-	ReferenceKey   *string    `json:"referenceKey"`
-	OrganizationID string     `json:"organizationId"`
-	ExpiresAt      time.Time  `json:"expiresAt,omitempty"`
+	ID                   SecretID             `json:"id"`
+	EncryptedData        string               `json:"encryptedData"`
+	CreatedAt            time.Time            `json:"createdAt"`
+	UpdatedAt            time.Time            `json:"updatedAt"`
+	User                 SecretUser           `json:"user"`
+	Name                 string               `json:"name"`
+	Description          string               `json:"description"`
+	Tags                 []string             `json:"tags"`
+	CreatorEmail         string               `json:"creatorEmail"`
+	Type                 string               `json:"type"`
+	Metadata             map[string]string    `json:"metadata,omitempty"`
+	ReferenceKey         *string              `json:"referenceKey"`
+	Team                 *SecretTeam          `json:"team,omitempty"`
+	CollectionID         *CollectionID        `json:"collectionId,omitempty"`
+	ProjectID            *ProjectID           `json:"projectId,omitempty"`
+	Environment          string               `json:"environment,omitempty"`
+	OrganizationID       string               `json:"organizationId"`
+	ExpiresAt            time.Time            `json:"expiresAt,omitempty"`
+	Checkout             *SecretCheckoutState `json:"checkout,omitempty"`
+	RotationRequired     bool                 `json:"rotationRequired,omitempty"`
+	RotationIntervalDays int                  `json:"rotationIntervalDays,omitempty"`
+	LastRotatedAt        time.Time            `json:"lastRotatedAt,omitempty"`
+	RotationDueAt        time.Time            `json:"rotationDueAt,omitempty"`
+	// Version counts the updates of the secret through Update, it is the ETag of the secret.
+	Version int64 `json:"version"`
+	// ValueFingerprint is an HMAC of the plain value under a key of the organization, computed by the client
+	// for the password health report. It is only written, reads never return it.
+	ValueFingerprint string `json:"valueFingerprint,omitempty"`
+	// Signature is the signature of the author over the payload of the secret. Writes without one leave
+	// the secret unsigned.
+	Signature *SecretSignature `json:"signature,omitempty"`
+	// CertificateChain is the PEM encoded public certificate chain of a TLS certificate, leaf first. It is
+	// stored unencrypted next to the encrypted private key, the certificate expiry and fingerprint metadata
+	// are filled in from it.
+	CertificateChain string `json:"certificateChain,omitempty"`
+	// Certificate is read from the chain by the server, it is ignored on writes.
+	Certificate *CertificateInfo `json:"certificate,omitempty"`
+}
+
+// SecretSignature signs the secret as written by SignedBy. Clients send the value and optionally the key id
+// and the time they signed at, at most a day before the write, the rest is filled in from the signing key the
+// author had at that time. Writes to a signed secret, and writes by an author with a signing key, have to be
+// signed.
+//
+// The signed payload is "secaas-secret-v1" followed by the organization id, name, description, type,
+// encrypted data, certificate chain, metadata, tags, expiry, version and author id, each written as its length
+// in bytes, a colon and the bytes. The certificate chain is written as stored, every certificate PEM encoded
+// again without headers, and is empty for secrets without one. The metadata is written as the number of its
+// fields in decimal followed by the name and value of each field sorted by name, each of them written the
+// same way, as stored: values are trimmed and empty ones left out. With a certificate chain the
+// certificateExpiresAt and fingerprint fields are derived from it and left out. The tags are written as their
+// number in decimal followed by each tag in the order given, the expiry as milliseconds since the Unix epoch
+// in decimal, or empty without one. The version is the one the write creates, 1 for a new secret and the
+// current version plus one for an update.
+type SecretSignature struct {
+	Value    string    `json:"value"`
+	KeyID    string    `json:"keyId"`
+	Alg      string    `json:"alg"`
+	SignedBy UserID    `json:"signedBy"`
+	SignedAt time.Time `json:"signedAt"`
+}
+
+type SecretRoleChange struct {
+	Role string `json:"role"`
+}
+
+// SecretOwnershipTransfer hands the secret to a new owner. The previous owner keeps a share
+// with PreviousOwnerRole, or loses access when it is empty.
+type SecretOwnershipTransfer struct {
+	NewOwnerID        UserID `json:"newOwnerId"`
+	PreviousOwnerRole string `json:"previousOwnerRole,omitempty"`
+}
+
+// SecretOwnershipReassignment moves every secret of the organization owned by FromUserID to ToUserID.
+type SecretOwnershipReassignment struct {
+	FromUserID UserID `json:"fromUserId"`
+	ToUserID   UserID `json:"toUserId"`
+}
+
+// SecretOwnershipReassignmentResult lists the secrets which were moved and the ones which could not be.
+type SecretOwnershipReassignmentResult struct {
+	Transferred []SecretID `json:"transferred"`
+	Failed      []SecretID `json:"failed"`
+}
+
+// SecretShareRenewal moves the access expiry of a share. A renewed temporary upgrade becomes the role of the
+// share until the new expiry, unless KeepUpgrade is set and it falls back to the role from before the upgrade.
+type SecretShareRenewal struct {
+	AccessExpiresAt time.Time `json:"accessExpiresAt"`
+	KeepUpgrade     bool      `json:"keepUpgrade,omitempty"`
+}
+
+// Tag filter modes for secret search.
+const (
+	TagModeAll = "all"
+	TagModeAny = "any"
+)
+
+// SecretSearch filters the secrets by their plaintext metadata.
+type SecretSearch struct {
+	OrganizationID string    `form:"organizationId"`
+	Query          string    `form:"q"`
+	NamePrefix     string    `form:"prefix"`
+	Tags           []string  `form:"tags"`
+	TagMode        string    `form:"tagMode"`
+	Types          []string  `form:"types"`
+	CreatedAfter   time.Time `form:"createdAfter" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore  time.Time `form:"createdBefore" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedAfter   time.Time `form:"updatedAfter" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedBefore  time.Time `form:"updatedBefore" time_format:"2006-01-02T15:04:05Z07:00"`
+	Sort           string    `form:"sort"`
+}
+
+// SecretCheckout is the exclusive lease one user holds on a checkout secret.
+type SecretCheckout struct {
+	UserID         UserID    `json:"userId"`
+	CheckedOutAt   time.Time `json:"checkedOutAt"`
+	LeaseExpiresAt time.Time `json:"leaseExpiresAt"`
+}
+
+type SecretCheckoutQueueEntry struct {
+	UserID      UserID    `json:"userId"`
+	RequestedAt time.Time `json:"requestedAt"`
+}
+
+// SecretCheckoutState describes the checkout policy of a secret and who holds it.
+// The encrypted data of a checkout secret is only handed out to the current holder.
+type SecretCheckoutState struct {
+	Enabled      bool                       `json:"enabled"`
+	LeaseMinutes int                        `json:"leaseMinutes"`
+	Holder       *SecretCheckout            `json:"holder,omitempty"`
+	Queue        []SecretCheckoutQueueEntry `json:"queue"`
+}
+
+type SecretCheckoutPolicy struct {
+	Enabled      bool `json:"enabled"`
+	LeaseMinutes int  `json:"leaseMinutes"`
 }
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// Kinds of consumers which read secrets. Service accounts and devices read through the user they act for
+// and name themselves in the X-Consumer-Kind and X-Consumer-Id headers.
+const (
+	SecretConsumerUser           = "user"
+	SecretConsumerServiceAccount = "service-account"
+	SecretConsumerDevice         = "device"
+)
+
+// SecretConsumer is who read a secret. The id of a user consumer is the id of the user.
+type SecretConsumer struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+}
+
+// SecretConsumerAccess counts the reads of a secret by one consumer.
+type SecretConsumerAccess struct {
+	SecretConsumer
+	UserID          UserID    `json:"userId"`
+	AccessCount     int64     `json:"accessCount"`
+	FirstAccessedAt time.Time `json:"firstAccessedAt"`
+	LastAccessedAt  time.Time `json:"lastAccessedAt"`
+}
+
+// SecretAccessSummary tells how often and by whom a secret was read, most recent consumer first.
+type SecretAccessSummary struct {
+	SecretID       SecretID               `json:"secretId"`
+	AccessCount    int64                  `json:"accessCount"`
+	LastAccessedAt time.Time              `json:"lastAccessedAt,omitempty"`
+	Consumers      []SecretConsumerAccess `json:"consumers"`
+}
+
+// UnusedSecret is a secret nobody read for the days of the unused report. LastAccessedAt is zero when it was never read.
+type UnusedSecret struct {
+	HealthSecret
+	CreatedAt      time.Time `json:"createdAt"`
+	LastAccessedAt time.Time `json:"lastAccessedAt,omitempty"`
+	AccessCount    int64     `json:"accessCount"`
+}
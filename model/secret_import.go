@@ -0,0 +1,94 @@
+package model
+
+import "time"
+
+// Formats of the exports an import profile reads.
+const (
+	ImportFormatJSON   = "json"
+	ImportFormatCSV    = "csv"
+	ImportFormatDotenv = "dotenv"
+)
+
+// Secret fields an import profile can map a source field to. Metadata fields are
+// targeted with the ImportTargetMetadata prefix followed by the metadata field name.
+const (
+	ImportTargetName        = "name"
+	ImportTargetDescription = "description"
+	ImportTargetTags        = "tags"
+	ImportTargetMetadata    = "metadata."
+)
+
+// Outcomes of a single item of an import.
+const (
+	ImportItemCreated = "created"
+	ImportItemSkipped = "skipped"
+	ImportItemFailed  = "failed"
+	ImportItemAborted = "aborted"
+)
+
+// SecretImportField maps one field of an exported record to a field of the secret.
+type SecretImportField struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// SecretImportProfile describes how the records of a password manager export translate into secrets.
+// Nested source fields are addressed by their dotted path, e.g. login.uris.0.uri. SecretFields must be
+// encrypted by the client into the encrypted data and are never sent in the source of an item.
+type SecretImportProfile struct {
+	Name         string              `json:"name"`
+	Label        string              `json:"label"`
+	Format       string              `json:"format"`
+	Fields       []SecretImportField `json:"fields"`
+	SecretFields []string            `json:"secretFields"`
+	TypeField    string              `json:"typeField,omitempty"`
+	Types        map[string]string   `json:"types,omitempty"`
+	DefaultType  string              `json:"defaultType"`
+}
+
+// SecretImportItem is one client encrypted secret of an import. Fields left empty are filled from
+// the plaintext Source record through the profile of the import. Signature signs the secret as
+// created, like the signature of a single secret.
+type SecretImportItem struct {
+	EncryptedData        string            `json:"encryptedData"`
+	Name                 string            `json:"name,omitempty"`
+	Description          string            `json:"description,omitempty"`
+	Tags                 []string          `json:"tags,omitempty"`
+	Type                 string            `json:"type,omitempty"`
+	Metadata             map[string]string `json:"metadata,omitempty"`
+	CollectionID         *CollectionID     `json:"collectionId,omitempty"`
+	ProjectID            *ProjectID        `json:"projectId,omitempty"`
+	Environment          string            `json:"environment,omitempty"`
+	ExpiresAt            time.Time         `json:"expiresAt,omitempty"`
+	RotationIntervalDays int               `json:"rotationIntervalDays,omitempty"`
+	ValueFingerprint     string            `json:"valueFingerprint,omitempty"`
+	Source               map[string]string `json:"source,omitempty"`
+	Signature            *SecretSignature  `json:"signature,omitempty"`
+}
+
+// SecretImport is a batch of secrets created in one call. Atomic imports create nothing unless every item is valid.
+// Items whose name already exists are failures, or skipped when SkipDuplicates is set.
+type SecretImport struct {
+	OrganizationID string             `json:"organizationId"`
+	CreatorEmail   string             `json:"creatorEmail,omitempty"`
+	Profile        string             `json:"profile,omitempty"`
+	Atomic         bool               `json:"atomic"`
+	SkipDuplicates bool               `json:"skipDuplicates"`
+	Items          []SecretImportItem `json:"items"`
+}
+
+// SecretImportItemResult is the outcome of the item at Index of the import.
+type SecretImportItemResult struct {
+	Index  int     `json:"index"`
+	Name   string  `json:"name"`
+	Status string  `json:"status"`
+	Secret *Secret `json:"secret,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+type SecretImportReport struct {
+	Created int                      `json:"created"`
+	Skipped int                      `json:"skipped"`
+	Failed  int                      `json:"failed"`
+	Items   []SecretImportItemResult `json:"items"`
+}
@@ -0,0 +1,36 @@
+package model
+
+// Kinds of secrets known to the server.
+const (
+	SecretKindLogin              = "login"
+	SecretKindAPIKey             = "api-key"
+	SecretKindSSHKey             = "ssh-key"
+	SecretKindTLSCertificate     = "tls-certificate"
+	SecretKindDatabaseCredential = "database-credential"
+	SecretKindNote               = "note"
+)
+
+// Value formats of the public metadata fields.
+const (
+	MetadataFormatText        = "text"
+	MetadataFormatURL         = "url"
+	MetadataFormatHost        = "host"
+	MetadataFormatPort        = "port"
+	MetadataFormatFingerprint = "fingerprint"
+	MetadataFormatDateTime    = "date-time"
+)
+
+// SecretKindField describes one public metadata field of a secret kind.
+type SecretKindField struct {
+	Name     string `json:"name"`
+	Label    string `json:"label"`
+	Format   string `json:"format"`
+	Required bool   `json:"required"`
+}
+
+// SecretKind is the schema of the non secret metadata stored next to the encrypted data.
+type SecretKind struct {
+	Name   string            `json:"name"`
+	Label  string            `json:"label"`
+	Fields []SecretKindField `json:"fields"`
+}
@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+// Access events recorded on a share link.
+const (
+	ShareLinkEventViewed           = "viewed"
+	ShareLinkEventPassphraseFailed = "passphrase-failed"
+	ShareLinkEventDenied           = "denied"
+	ShareLinkEventBurned           = "burned"
+	ShareLinkEventExpired          = "expired"
+	ShareLinkEventRevoked          = "revoked"
+)
+
+type ShareLinkID string
+
+func (s ShareLinkID) String() string {
+	return string(s)
+}
+
+type ShareLinkEvent struct {
+	Type      string    `json:"type"`
+	At        time.Time `json:"at"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+}
+
+// ShareLink is a one time link for someone without an account. The key of the payload lives only in the URL fragment.
+type ShareLink struct {
+	ID             ShareLinkID      `json:"id"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	CreatedBy      UserID           `json:"createdBy"`
+	OrganizationID string           `json:"organizationId"`
+	Description    string           `json:"description,omitempty"`
+	MaxViews       int              `json:"maxViews"`
+	Views          int              `json:"views"`
+	ExpiresAt      time.Time        `json:"expiresAt"`
+	HasPassphrase  bool             `json:"hasPassphrase"`
+	Burned         bool             `json:"burned"`
+	Events         []ShareLinkEvent `json:"events"`
+}
+
+// ShareLinkCreate is the request to create a share link. EncryptedData is never returned to the creator.
+type ShareLinkCreate struct {
+	OrganizationID string    `json:"organizationId"`
+	Description    string    `json:"description"`
+	EncryptedData  string    `json:"encryptedData"`
+	MaxViews       int       `json:"maxViews"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+	Passphrase     string    `json:"passphrase"`
+}
+
+// ShareLinkInfo is what the recipient learns about a link before opening it.
+type ShareLinkInfo struct {
+	ID            ShareLinkID `json:"id"`
+	ExpiresAt     time.Time   `json:"expiresAt"`
+	HasPassphrase bool        `json:"hasPassphrase"`
+}
+
+type ShareLinkOpen struct {
+	Passphrase string `json:"passphrase"`
+}
+
+type ShareLinkPayload struct {
+	EncryptedData  string `json:"encryptedData"`
+	RemainingViews int    `json:"remainingViews"`
+}
+
+// ShareLinkAccess describes the client opening a link, it is stored in the access events.
+type ShareLinkAccess struct {
+	IP        string
+	UserAgent string
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// SyncEventRetention is how long the changes of a user are kept. Clients which did not sync for longer start over.
+const SyncEventRetention = 90 * 24 * time.Hour
+
+// Kinds of objects a sync change refers to.
+const (
+	SyncTypeSecret     = "secret"
+	SyncTypeMembership = "membership"
+	SyncTypeInvite     = "invite"
+)
+
+// Actions of a sync change. Deleted changes are tombstones which only carry the object id, they are
+// also sent when the user lost access to the object.
+const (
+	SyncActionCreated = "created"
+	SyncActionUpdated = "updated"
+	SyncActionDeleted = "deleted"
+)
+
+// SyncChange is the current state of an object which changed after the revision the client asked for.
+// The object id of a membership is the id of its organization.
+type SyncChange struct {
+	Revision       int64             `json:"revision"`
+	Type           string            `json:"type"`
+	Action         string            `json:"action"`
+	ObjectID       string            `json:"objectId"`
+	OrganizationID string            `json:"organizationId,omitempty"`
+	Secret         *Secret           `json:"secret,omitempty"`
+	Membership     *UserOrganization `json:"membership,omitempty"`
+	Invite         *Invite           `json:"invite,omitempty"`
+}
+
+// SyncResult lists the changes after a revision. Clients store Revision and pass it as since on their next
+// call, right away while HasMore is set.
+type SyncResult struct {
+	Revision int64        `json:"revision"`
+	HasMore  bool         `json:"hasMore"`
+	Changes  []SyncChange `json:"changes"`
+}
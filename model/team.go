@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+type TeamID string
+
+func (t TeamID) String() string {
+	return string(t)
+}
+
+// TeamMember holds the team key wrapped for the member's public key.
+// Members without a wrapped key are pending and have no access to the team's secrets.
+// Active admins manage the members of the team, next to the admins of the organization.
+type TeamMember struct {
+	UserID     UserID    `json:"userId"`
+	WrappedKey SymKey    `json:"wrappedKey"`
+	Admin      bool      `json:"admin"`
+	AddedAt    time.Time `json:"addedAt"`
+	Pending    bool      `json:"pending"`
+}
+
+type Team struct {
+	ID             TeamID       `json:"id"`
+	CreatedAt      time.Time    `json:"createdAt"`
+	UpdatedAt      time.Time    `json:"updatedAt"`
+	OrganizationID string       `json:"organizationId"`
+	Name           string       `json:"name"`
+	Description    string       `json:"description"`
+	Members        []TeamMember `json:"members"`
+}
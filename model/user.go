@@ -27,6 +27,7 @@ type User struct {
 	PassHash      PassHash           `json:"passHash"`
 	SymKey        SymKey             `json:"symKey"`
 	AsymmKey      AsymmKey           `json:"asymmKey"`
+	SigningKeys   []SigningKey       `json:"signingKeys,omitempty"`
 	CreatedAt     time.Time          `json:"createdAt"`
 	UpdatedAt     time.Time          `json:"updatedAt"`
 	IsBlackListed bool               `json:"isBlackListed"`
@@ -48,3 +49,18 @@ type AsymmKey struct {
 	EncryptedPvtKey string `json:"encryptedPvtKey"`
 	Alg             string `json:"alg"`
 }
+
+// Algorithms of the signing keys, read from the key itself.
+const (
+	SigningAlgEd25519 = "ed25519"
+	SigningAlgES256   = "ES256"
+)
+
+// SigningKey is a public key the user signs secrets with, as base64 of its DER encoded SubjectPublicKeyInfo.
+// The last registered key is the current one, older keys are kept so signatures made with them can still be checked.
+type SigningKey struct {
+	KeyID        string    `json:"keyId"`
+	Public       string    `json:"public"`
+	Alg          string    `json:"alg"`
+	RegisteredAt time.Time `json:"registeredAt"`
+}
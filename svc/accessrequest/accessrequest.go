@@ -0,0 +1,582 @@
+package accessrequest
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/notification"
+	"secaas_backend/svc/pagination"
+	"secaas_backend/svc/secret"
+	"secaas_backend/svc/user"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultAccessDuration is granted when the request does not ask for a duration.
+	DefaultAccessDuration = 60
+	// MaxAccessDuration is the longest access in minutes a request can grant.
+	MaxAccessDuration = 7 * 24 * 60
+	// PendingRequestTTL is how long a request waits for a decision before it expires.
+	PendingRequestTTL = 72 * time.Hour
+	// ExpiryCheckInterval is how often the scheduler expires requests.
+	ExpiryCheckInterval = time.Minute
+)
+
+type AccessRequestSVC struct {
+	logger          *logrus.Logger
+	userSvc         *user.UserSVC
+	secretSvc       *secret.SecretsSVC
+	notificationSvc *notification.NotificationSVC
+}
+
+func New(logger *logrus.Logger, userSvc *user.UserSVC, secretSvc *secret.SecretsSVC, notificationSvc *notification.NotificationSVC) *AccessRequestSVC {
+	a := &AccessRequestSVC{logger: logger, userSvc: userSvc, secretSvc: secretSvc, notificationSvc: notificationSvc}
+
+	secretSvc.RegisterDeleteHook(a.CancelForSecret)
+	secretSvc.RegisterOwnerHook(a.ReassignForSecret)
+
+	return a
+}
+
+// Create records a request of the user for time bounded access to the secret and notifies its owner.
+func (a *AccessRequestSVC) Create(ctx context.Context, userId model.UserID, data model.AccessRequest) (request model.AccessRequest, err error) {
+	role := data.Role
+
+	if role == "" {
+		role = model.SecretRoleViewer
+	}
+
+	duration := data.DurationMinutes
+
+	if duration == 0 {
+		duration = DefaultAccessDuration
+	}
+
+	if !secret.IsShareableRole(role) || strings.TrimSpace(data.Reason) == "" || duration < 0 || duration > MaxAccessDuration {
+		err = errors.ErrInvalidAccessRequest
+		return
+	}
+
+	original, currentRole, err := a.secretSvc.ResolveAccess(ctx, data.SecretID, userId)
+
+	if err != nil {
+		return
+	}
+
+	_, err = a.userSvc.GetOrganizationMembership(ctx, userId, model.OrganizationID(original.OrganizationID))
+
+	if err != nil {
+		return
+	}
+
+	if model.SecretRoleRank(currentRole) >= model.SecretRoleRank(role) {
+		err = errors.ErrSecretAlreadyShared
+		return
+	}
+
+	secretObjId, _ := primitive.ObjectIDFromHex(original.ID.String())
+
+	count, err := mgm.Coll(&doc.AccessRequest{}).CountDocuments(ctx, bson.M{
+		"secretId":    secretObjId,
+		"requesterId": userId.String(),
+		"status":      model.AccessRequestRequested,
+	})
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while checking pending access requests")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if count > 0 {
+		err = errors.ErrAccessRequestAlreadyOpen
+		return
+	}
+
+	docRequest := &doc.AccessRequest{
+		SecretID:        secretObjId,
+		SecretOwnerID:   original.User.ID.String(),
+		OrganizationID:  original.OrganizationID,
+		RequesterID:     userId.String(),
+		Role:            role,
+		Reason:          data.Reason,
+		DurationMinutes: duration,
+		Status:          model.AccessRequestRequested,
+		History: []doc.AccessRequestEvent{
+			{Status: model.AccessRequestRequested, At: time.Now(), By: userId.String(), Note: data.Reason},
+		},
+	}
+
+	err = mgm.Coll(docRequest).CreateWithCtx(ctx, docRequest)
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while creating access request")
+		err = errors.ErrUnknown
+		return
+	}
+
+	a.notificationSvc.Notify(ctx, original.User.ID, model.NotificationAccessRequested, "Access to your secret was requested", map[string]string{
+		"requestId":   docRequest.ID.Hex(),
+		"secretId":    original.ID.String(),
+		"requesterId": userId.String(),
+		"role":        role,
+	})
+
+	request = a.MapDocToAccessRequest(docRequest)
+
+	return
+}
+
+func (a *AccessRequestSVC) GetByID(ctx context.Context, requestId model.AccessRequestID, userId model.UserID) (request model.AccessRequest, err error) {
+	docRequest, err := a.getDoc(ctx, requestId)
+
+	if err != nil {
+		return
+	}
+
+	if docRequest.RequesterID != userId.String() && !a.canDecide(ctx, docRequest, userId) {
+		err = errors.ErrAccessRequestDenied
+		return
+	}
+
+	request = a.MapDocToAccessRequest(docRequest)
+
+	return
+}
+
+// GetForUser lists the requests made by the user.
+func (a *AccessRequestSVC) GetForUser(ctx context.Context, userId model.UserID, status string, params model.PaginationParams) ([]model.AccessRequest, model.PageInfo, error) {
+	if userId == "" {
+		return nil, model.PageInfo{}, errors.ErrInvalidID
+	}
+
+	filter := bson.M{"requesterId": userId.String()}
+
+	if status != "" {
+		filter["status"] = status
+	}
+
+	return a.find(ctx, filter, params)
+}
+
+// GetForApprover lists the requests of the organization the user can decide on.
+// Org admins see all of them, other members the requests for the secrets they own.
+func (a *AccessRequestSVC) GetForApprover(ctx context.Context, orgId model.OrganizationID, userId model.UserID, status string, params model.PaginationParams) ([]model.AccessRequest, model.PageInfo, error) {
+	membership, err := a.userSvc.GetOrganizationMembership(ctx, userId, orgId)
+
+	if err != nil {
+		return nil, model.PageInfo{}, err
+	}
+
+	filter := bson.M{"organizationId": orgId.String()}
+
+	if !membership.IsAdmin {
+		filter["secretOwnerId"] = userId.String()
+	}
+
+	if status != "" {
+		filter["status"] = status
+	}
+
+	return a.find(ctx, filter, params)
+}
+
+// GetForSecret lists the requests made for the secret. Only approvers of the secret can see them.
+func (a *AccessRequestSVC) GetForSecret(ctx context.Context, secretId model.SecretID, userId model.UserID, params model.PaginationParams) ([]model.AccessRequest, model.PageInfo, error) {
+	original, role, err := a.secretSvc.ResolveAccess(ctx, secretId, userId)
+
+	if err != nil {
+		return nil, model.PageInfo{}, err
+	}
+
+	if role != model.SecretRoleOwner && !a.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(original.OrganizationID)) {
+		return nil, model.PageInfo{}, errors.ErrAccessRequestDenied
+	}
+
+	secretObjId, _ := primitive.ObjectIDFromHex(original.ID.String())
+
+	return a.find(ctx, bson.M{"secretId": secretObjId}, params)
+}
+
+// Approve grants the requested access as a share which lapses after the approved duration. A share the
+// requester already holds is raised to the requested role for that duration instead.
+func (a *AccessRequestSVC) Approve(ctx context.Context, requestId model.AccessRequestID, userId model.UserID, decision model.AccessRequestDecision) (request model.AccessRequest, err error) {
+	logger := a.logger.WithContext(ctx).WithField("requestId", requestId.String())
+
+	docRequest, err := a.getDoc(ctx, requestId)
+
+	if err != nil {
+		return
+	}
+
+	if docRequest.RequesterID == userId.String() || !a.canDecide(ctx, docRequest, userId) {
+		err = errors.ErrAccessRequestDenied
+		return
+	}
+
+	duration := docRequest.DurationMinutes
+
+	if decision.DurationMinutes != 0 {
+		if decision.DurationMinutes < 0 || decision.DurationMinutes > duration {
+			err = errors.ErrInvalidAccessRequest
+			return
+		}
+		duration = decision.DurationMinutes
+	}
+
+	accessExpiresAt := time.Now().Add(time.Duration(duration) * time.Minute)
+
+	// Move the request out of the pending state first so two approvers cannot both grant it.
+	err = a.transition(ctx, docRequest.ID, model.AccessRequestRequested, model.AccessRequestApproved, userId, decision.Note, bson.M{
+		"decidedBy":       userId.String(),
+		"durationMinutes": duration,
+		"accessExpiresAt": accessExpiresAt,
+	})
+
+	if err != nil {
+		return
+	}
+
+	original, _, err := a.secretSvc.ResolveAccess(ctx, model.SecretID(docRequest.SecretID.Hex()), "")
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching secret of access request")
+
+		revertErr := a.revertApproval(ctx, docRequest)
+
+		if revertErr != nil {
+			logger.WithError(revertErr).Error("error while reverting access request approval")
+		}
+		return
+	}
+
+	// The share is made on behalf of the owner, org admins do not need to hold the secret themselves.
+	share := model.SecretUser{
+		ID:              model.UserID(docRequest.RequesterID),
+		Role:            docRequest.Role,
+		AccessExpiresAt: accessExpiresAt,
+	}
+
+	resultSet, err := a.secretSvc.ShareSecret(ctx, original.ID, original.User.ID, []model.SecretUser{share})
+
+	// A requester who already holds a share asked for a higher role, it is raised until the access expires.
+	if err == nil && !resultSet[docRequest.RequesterID] {
+		_, err = a.secretSvc.UpgradeShare(ctx, original.ID, original.User.ID, share.ID, share.Role, accessExpiresAt)
+	}
+
+	if err != nil {
+		logger.WithError(err).Error("error while sharing secret for approved access request")
+
+		revertErr := a.revertApproval(ctx, docRequest)
+
+		if revertErr != nil {
+			logger.WithError(revertErr).Error("error while reverting access request approval")
+		}
+		return
+	}
+
+	a.notificationSvc.Notify(ctx, model.UserID(docRequest.RequesterID), model.NotificationAccessDecided, "Your access request was approved", map[string]string{
+		"requestId": docRequest.ID.Hex(),
+		"secretId":  docRequest.SecretID.Hex(),
+		"status":    model.AccessRequestApproved,
+	})
+
+	return a.GetByID(ctx, requestId, userId)
+}
+
+func (a *AccessRequestSVC) Deny(ctx context.Context, requestId model.AccessRequestID, userId model.UserID, decision model.AccessRequestDecision) (request model.AccessRequest, err error) {
+	docRequest, err := a.getDoc(ctx, requestId)
+
+	if err != nil {
+		return
+	}
+
+	if docRequest.RequesterID == userId.String() || !a.canDecide(ctx, docRequest, userId) {
+		err = errors.ErrAccessRequestDenied
+		return
+	}
+
+	err = a.transition(ctx, docRequest.ID, model.AccessRequestRequested, model.AccessRequestDenied, userId, decision.Note, bson.M{
+		"decidedBy": userId.String(),
+	})
+
+	if err != nil {
+		return
+	}
+
+	a.notificationSvc.Notify(ctx, model.UserID(docRequest.RequesterID), model.NotificationAccessDecided, "Your access request was denied", map[string]string{
+		"requestId": docRequest.ID.Hex(),
+		"secretId":  docRequest.SecretID.Hex(),
+		"status":    model.AccessRequestDenied,
+	})
+
+	return a.GetByID(ctx, requestId, userId)
+}
+
+// Cancel withdraws a pending request. Only the requester can cancel it.
+func (a *AccessRequestSVC) Cancel(ctx context.Context, requestId model.AccessRequestID, userId model.UserID) (request model.AccessRequest, err error) {
+	docRequest, err := a.getDoc(ctx, requestId)
+
+	if err != nil {
+		return
+	}
+
+	if docRequest.RequesterID != userId.String() {
+		err = errors.ErrAccessRequestDenied
+		return
+	}
+
+	err = a.transition(ctx, docRequest.ID, model.AccessRequestRequested, model.AccessRequestCancelled, userId, "", nil)
+
+	if err != nil {
+		return
+	}
+
+	return a.GetByID(ctx, requestId, userId)
+}
+
+// CancelForSecret closes the pending requests of a deleted secret. The requests are kept for their history.
+func (a *AccessRequestSVC) CancelForSecret(ctx context.Context, secretId model.SecretID) {
+	secretObjId, err := primitive.ObjectIDFromHex(secretId.String())
+
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":           model.AccessRequestCancelled,
+			doc.UpdatedAtField: now,
+		},
+		"$push": bson.M{"history": doc.AccessRequestEvent{
+			Status: model.AccessRequestCancelled,
+			At:     now,
+			Note:   "secret was deleted",
+		}},
+	}
+
+	_, err = mgm.Coll(&doc.AccessRequest{}).UpdateMany(ctx, bson.M{"secretId": secretObjId, "status": model.AccessRequestRequested}, update)
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("error while cancelling access requests of deleted secret")
+	}
+}
+
+// ReassignForSecret makes the new owner of the secret the approver of its requests.
+func (a *AccessRequestSVC) ReassignForSecret(ctx context.Context, secretId model.SecretID, ownerId model.UserID) {
+	secretObjId, err := primitive.ObjectIDFromHex(secretId.String())
+
+	if err != nil {
+		return
+	}
+
+	_, err = mgm.Coll(&doc.AccessRequest{}).UpdateMany(ctx, bson.M{"secretId": secretObjId}, bson.M{
+		"$set": bson.M{"secretOwnerId": ownerId.String()},
+	})
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("error while reassigning access requests of secret")
+	}
+}
+
+// ExpireRequests closes the requests which waited too long for a decision and the approvals whose access lapsed.
+// The shares themselves are revoked by the secret share expiry job.
+func (a *AccessRequestSVC) ExpireRequests(ctx context.Context) {
+	now := time.Now()
+
+	a.expireMatching(ctx, bson.M{
+		"status":           model.AccessRequestRequested,
+		doc.CreatedAtField: bson.M{"$lte": now.Add(-PendingRequestTTL)},
+	}, model.AccessRequestRequested, "request was not decided in time")
+
+	a.expireMatching(ctx, bson.M{
+		"status":          model.AccessRequestApproved,
+		"accessExpiresAt": bson.M{"$lte": now},
+	}, model.AccessRequestApproved, "access window ended")
+}
+
+func (a *AccessRequestSVC) expireMatching(ctx context.Context, filter bson.M, from string, note string) {
+	cursor, err := mgm.Coll(&doc.AccessRequest{}).Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while fetching access requests to expire")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.AccessRequest
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			a.logger.WithContext(ctx).WithError(err).Error("error while decoding access request doc")
+			continue
+		}
+
+		err = a.transition(ctx, curDoc.ID, from, model.AccessRequestExpired, "", note, nil)
+
+		if err != nil && err != errors.ErrAccessRequestDecided {
+			a.logger.WithContext(ctx).WithField("requestId", curDoc.ID.Hex()).WithError(err).Error("error while expiring access request")
+		}
+	}
+}
+
+// transition moves the request from one status to another and records it in the history.
+// It fails with ErrAccessRequestDecided when the request is not in the expected status anymore.
+func (a *AccessRequestSVC) transition(ctx context.Context, requestId primitive.ObjectID, from string, to string, userId model.UserID, note string, set bson.M) error {
+	now := time.Now()
+
+	fields := bson.M{
+		"status":           to,
+		doc.UpdatedAtField: now,
+	}
+
+	for key, value := range set {
+		fields[key] = value
+	}
+
+	update := bson.M{
+		"$set": fields,
+		"$push": bson.M{"history": doc.AccessRequestEvent{
+			Status: to,
+			At:     now,
+			By:     userId.String(),
+			Note:   note,
+		}},
+	}
+
+	res, err := mgm.Coll(&doc.AccessRequest{}).UpdateOne(ctx, bson.M{"_id": requestId, "status": from}, update)
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while updating access request status")
+		return errors.ErrUnknown
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.ErrAccessRequestDecided
+	}
+
+	return nil
+}
+
+// revertApproval puts the request back to pending when the share could not be made.
+func (a *AccessRequestSVC) revertApproval(ctx context.Context, docRequest *doc.AccessRequest) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":           model.AccessRequestRequested,
+			"durationMinutes":  docRequest.DurationMinutes,
+			doc.UpdatedAtField: time.Now(),
+		},
+		"$unset": bson.M{"decidedBy": "", "accessExpiresAt": ""},
+		"$pop":   bson.M{"history": 1},
+	}
+
+	_, err := mgm.Coll(&doc.AccessRequest{}).UpdateOne(ctx, bson.M{"_id": docRequest.ID, "status": model.AccessRequestApproved}, update)
+
+	return err
+}
+
+// canDecide reports if the user is an approver of the request, which org admins and the owner of the secret are.
+func (a *AccessRequestSVC) canDecide(ctx context.Context, docRequest *doc.AccessRequest, userId model.UserID) bool {
+	if userId == "" {
+		return false
+	}
+
+	if docRequest.SecretOwnerID == userId.String() {
+		return true
+	}
+
+	return a.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(docRequest.OrganizationID))
+}
+
+func (a *AccessRequestSVC) find(ctx context.Context, filter bson.M, params model.PaginationParams) ([]model.AccessRequest, model.PageInfo, error) {
+	cursor, err := pagination.Find(ctx, mgm.Coll(&doc.AccessRequest{}), filter, params)
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while fetching access requests")
+		return nil, model.PageInfo{}, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	requests := make([]model.AccessRequest, 0)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.AccessRequest
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			a.logger.WithContext(ctx).WithError(err).Error("error while decoding access request doc")
+			continue
+		}
+
+		requests = append(requests, a.MapDocToAccessRequest(&curDoc))
+	}
+
+	return requests, cursor.Info(), nil
+}
+
+func (a *AccessRequestSVC) getDoc(ctx context.Context, requestId model.AccessRequestID) (*doc.AccessRequest, error) {
+	objId, err := primitive.ObjectIDFromHex(requestId.String())
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("invalid access request id found")
+		return nil, errors.ErrInvalidID
+	}
+
+	docRequest := &doc.AccessRequest{}
+
+	err = mgm.Coll(docRequest).FindByID(objId, docRequest)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrAccessRequestNotFound
+		}
+		a.logger.WithContext(ctx).WithError(err).Error("error while fetching access request")
+		return nil, errors.ErrUnknown
+	}
+
+	return docRequest, nil
+}
+
+func (a *AccessRequestSVC) MapDocToAccessRequest(docRequest *doc.AccessRequest) model.AccessRequest {
+	request := model.AccessRequest{
+		ID:              model.AccessRequestID(docRequest.ID.Hex()),
+		CreatedAt:       docRequest.CreatedAt,
+		UpdatedAt:       docRequest.UpdatedAt,
+		SecretID:        model.SecretID(docRequest.SecretID.Hex()),
+		OrganizationID:  docRequest.OrganizationID,
+		RequesterID:     model.UserID(docRequest.RequesterID),
+		Role:            docRequest.Role,
+		Reason:          docRequest.Reason,
+		DurationMinutes: docRequest.DurationMinutes,
+		Status:          docRequest.Status,
+		DecidedBy:       model.UserID(docRequest.DecidedBy),
+		AccessExpiresAt: docRequest.AccessExpiresAt,
+		History:         []model.AccessRequestEvent{},
+	}
+
+	for _, event := range docRequest.History {
+		request.History = append(request.History, model.AccessRequestEvent{
+			Status: event.Status,
+			At:     event.At,
+			By:     model.UserID(event.By),
+			Note:   event.Note,
+		})
+	}
+
+	return request
+}
@@ -0,0 +1,105 @@
+package accessrequest
+
+import (
+	"context"
+	"os"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"testing"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testService connects to the MongoDB named by MONGO_TEST_URI and returns a service over an empty
+// access request collection.
+func testService(t *testing.T) *AccessRequestSVC {
+	uri := os.Getenv("MONGO_TEST_URI")
+
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	err := mgm.SetDefaultConfig(&mgm.Config{CtxTimeout: 10 * time.Second}, "secaas_accessrequest_test", options.Client().ApplyURI(uri))
+
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+
+	coll := mgm.Coll(&doc.AccessRequest{})
+
+	t.Cleanup(func() {
+		coll.Drop(context.Background())
+	})
+
+	_, err = coll.DeleteMany(context.Background(), bson.M{})
+
+	if err != nil {
+		t.Fatalf("clearing collection: %v", err)
+	}
+
+	return &AccessRequestSVC{logger: logrus.New()}
+}
+
+func createRequest(t *testing.T, createdAt time.Time) primitive.ObjectID {
+	request := &doc.AccessRequest{
+		SecretID:       primitive.NewObjectID(),
+		SecretOwnerID:  primitive.NewObjectID().Hex(),
+		OrganizationID: primitive.NewObjectID().Hex(),
+		RequesterID:    primitive.NewObjectID().Hex(),
+		Role:           model.SecretRoleViewer,
+		Status:         model.AccessRequestRequested,
+		History:        []doc.AccessRequestEvent{{Status: model.AccessRequestRequested, At: createdAt}},
+	}
+
+	err := mgm.Coll(request).Create(request)
+
+	if err != nil {
+		t.Fatalf("creating access request: %v", err)
+	}
+
+	// mgm stamps the request with the current time, move it to when it was made.
+	_, err = mgm.Coll(request).UpdateOne(context.Background(), bson.M{"_id": request.ID}, bson.M{
+		"$set": bson.M{doc.CreatedAtField: createdAt, doc.UpdatedAtField: createdAt},
+	})
+
+	if err != nil {
+		t.Fatalf("backdating access request: %v", err)
+	}
+
+	return request.ID
+}
+
+func requestStatus(t *testing.T, requestId primitive.ObjectID) string {
+	request := &doc.AccessRequest{}
+
+	err := mgm.Coll(request).FindByID(requestId, request)
+
+	if err != nil {
+		t.Fatalf("reading access request: %v", err)
+	}
+
+	return request.Status
+}
+
+func TestExpireRequestsExpiresUndecidedRequests(t *testing.T) {
+	svc := testService(t)
+
+	now := time.Now()
+	stale := createRequest(t, now.Add(-PendingRequestTTL-time.Hour))
+	fresh := createRequest(t, now.Add(-time.Hour))
+
+	svc.ExpireRequests(context.Background())
+
+	if status := requestStatus(t, stale); status != model.AccessRequestExpired {
+		t.Errorf("request older than the TTL has status %q, want %q", status, model.AccessRequestExpired)
+	}
+
+	if status := requestStatus(t, fresh); status != model.AccessRequestRequested {
+		t.Errorf("request within the TTL has status %q, want %q", status, model.AccessRequestRequested)
+	}
+}
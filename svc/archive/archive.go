@@ -0,0 +1,966 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/audit"
+	"secaas_backend/svc/certificate"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// MaxArchiveSize bounds the extracted size of an imported archive.
+	MaxArchiveSize = 256 << 20
+	// MemberInviteExpiry is how long the members of an imported organization have to accept their invite.
+	MemberInviteExpiry = 7 * 24 * time.Hour
+)
+
+// archiveDataFiles are the files every archive carries next to its manifest.
+var archiveDataFiles = []string{
+	model.ArchiveOrganizationFile,
+	model.ArchiveMembersFile,
+	model.ArchiveInvitesFile,
+	model.ArchiveSecretsFile,
+	model.ArchiveSharesFile,
+}
+
+type ArchiveSVC struct {
+	logger      *logrus.Logger
+	userSvc     *user.UserSVC
+	auditSvc    *audit.AuditSVC
+	importHooks []ImportHook
+}
+
+// ImportHook is called after an organization was imported, with the members added to it and the ids
+// of the original secrets and invites created for it.
+type ImportHook func(ctx context.Context, orgId model.OrganizationID, memberIds []model.UserID, secretIds []model.SecretID, inviteIds []model.InviteID)
+
+func New(logger *logrus.Logger, userSvc *user.UserSVC, auditSvc *audit.AuditSVC) *ArchiveSVC {
+	a := &ArchiveSVC{logger: logger, userSvc: userSvc, auditSvc: auditSvc}
+	return a
+}
+
+// RegisterImportHook adds a hook which picks up the objects of imported organizations.
+func (a *ArchiveSVC) RegisterImportHook(hook ImportHook) {
+	a.importHooks = append(a.importHooks, hook)
+}
+
+// Export checks that the user can export the organization and returns the archive writer.
+// Only org admins can export. Nothing is read until the archive is written.
+func (a *ArchiveSVC) Export(ctx context.Context, orgId model.OrganizationID, userId model.UserID) (io.WriterTo, error) {
+	objId, err := primitive.ObjectIDFromHex(orgId.String())
+
+	if err != nil {
+		return nil, errors.ErrInvalidOrganizationID
+	}
+
+	if !a.userSvc.IsOrganizationAdmin(ctx, userId, orgId) {
+		return nil, errors.ErrArchiveAccessDenied
+	}
+
+	docOrg := &doc.Organization{}
+
+	err = mgm.Coll(docOrg).FindByIDWithCtx(ctx, objId, docOrg)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrOrganizationNotFound
+		}
+		a.logger.WithContext(ctx).WithError(err).Error("error while fetching organization to export")
+		return nil, errors.ErrUnknown
+	}
+
+	return &orgExport{svc: a, ctx: ctx, org: docOrg, userId: userId}, nil
+}
+
+// orgExport writes the archive of an organization as a gzipped tar.
+type orgExport struct {
+	svc    *ArchiveSVC
+	ctx    context.Context
+	org    *doc.Organization
+	userId model.UserID
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// WriteTo streams the archive one file at a time, the manifest with the hashes of the files comes last.
+func (e *orgExport) WriteTo(w io.Writer) (int64, error) {
+	logger := e.svc.logger.WithContext(e.ctx).WithField("organizationId", e.org.ID.Hex())
+
+	counter := &countingWriter{w: w}
+	gz := gzip.NewWriter(counter)
+	tw := tar.NewWriter(gz)
+
+	manifest := model.ArchiveManifest{
+		FormatVersion:  model.ArchiveFormatVersion,
+		OrganizationID: e.org.ID.Hex(),
+		ExportedAt:     time.Now(),
+		ExportedBy:     e.userId,
+		Files:          []model.ArchiveFile{},
+	}
+
+	for _, name := range archiveDataFiles {
+		file, err := writeArchiveFile(tw, name, manifest.ExportedAt, e.records(name))
+
+		if err != nil {
+			logger.WithField("file", name).WithError(err).Error("error while writing organization archive file")
+			return counter.n, err
+		}
+
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	_, err := writeArchiveFile(tw, model.ArchiveManifestFile, manifest.ExportedAt, encodeRecord(manifest))
+
+	if err == nil {
+		err = tw.Close()
+	}
+
+	if err == nil {
+		err = gz.Close()
+	}
+
+	if err != nil {
+		logger.WithError(err).Error("error while finishing organization archive")
+		return counter.n, err
+	}
+
+	e.svc.auditSvc.Record(e.ctx, model.AuditEvent{
+		OrganizationID: e.org.ID.Hex(),
+		ActorID:        e.userId,
+		Action:         model.AuditOrganizationExported,
+		TargetType:     model.AuditTargetOrganization,
+		TargetID:       e.org.ID.Hex(),
+		Data:           map[string]string{"formatVersion": strconv.Itoa(model.ArchiveFormatVersion)},
+	})
+
+	return counter.n, nil
+}
+
+// records returns the writer of the records of one file of the archive.
+func (e *orgExport) records(name string) fillFunc {
+	orgId := e.org.ID.Hex()
+
+	switch name {
+	case model.ArchiveOrganizationFile:
+		return encodeRecord(model.ArchiveOrganization{
+			ID:           orgId,
+			Name:         e.org.Name,
+			BillingEmail: e.org.BillingEmail,
+			AdminEmail:   e.org.AdminEmail,
+			SymmKey: model.SymKey{
+				EncryptedData: e.org.SymmKey.EncryptedData,
+				Alg:           e.org.SymmKey.Alg,
+			},
+			AttachmentQuota: e.org.AttachmentQuota,
+		})
+	case model.ArchiveMembersFile:
+		return encodeList(func(list *recordList) error {
+			return e.svc.exportMembers(e.ctx, orgId, list)
+		})
+	case model.ArchiveInvitesFile:
+		return encodeList(func(list *recordList) error {
+			return e.svc.exportInvites(e.ctx, orgId, list)
+		})
+	case model.ArchiveSecretsFile:
+		// Originals have no reference key, older documents store a zero object id instead.
+		return encodeList(func(list *recordList) error {
+			return e.svc.exportSecrets(e.ctx, bson.M{
+				"organizationId": orgId,
+				"$or": bson.A{
+					bson.M{"referenceKey": nil},
+					bson.M{"referenceKey": primitive.NilObjectID},
+				},
+			}, list)
+		})
+	default:
+		return encodeList(func(list *recordList) error {
+			return e.svc.exportSecrets(e.ctx, bson.M{
+				"organizationId": orgId,
+				"referenceKey":   bson.M{"$nin": bson.A{nil, primitive.NilObjectID}},
+			}, list)
+		})
+	}
+}
+
+// fillFunc writes the JSON of one file of the archive and returns the number of records written.
+type fillFunc func(w io.Writer) (int, error)
+
+// encodeRecord writes a single record.
+func encodeRecord(record interface{}) fillFunc {
+	return func(w io.Writer) (int, error) {
+		return 1, json.NewEncoder(w).Encode(record)
+	}
+}
+
+// recordList writes a JSON array one record at a time.
+type recordList struct {
+	w       io.Writer
+	enc     *json.Encoder
+	records int
+}
+
+func (l *recordList) Add(record interface{}) error {
+	separator := ","
+
+	if l.records == 0 {
+		separator = "["
+	}
+
+	_, err := io.WriteString(l.w, separator)
+
+	if err != nil {
+		return err
+	}
+
+	l.records++
+
+	return l.enc.Encode(record)
+}
+
+// encodeList writes the records which export adds to the list as a JSON array.
+func encodeList(export func(list *recordList) error) fillFunc {
+	return func(w io.Writer) (int, error) {
+		list := &recordList{w: w, enc: json.NewEncoder(w)}
+
+		err := export(list)
+
+		if err != nil {
+			return list.records, err
+		}
+
+		end := "]"
+
+		if list.records == 0 {
+			end = "[]"
+		}
+
+		_, err = io.WriteString(w, end)
+
+		return list.records, err
+	}
+}
+
+// writeArchiveFile adds the JSON written by fill to the archive and returns its manifest entry. A tar header
+// needs the size of the file up front, so the records are spooled to a temporary file as they are read
+// instead of being held in memory.
+func writeArchiveFile(tw *tar.Writer, name string, modTime time.Time, fill fillFunc) (model.ArchiveFile, error) {
+	spool, err := os.CreateTemp("", "secaas-archive-*")
+
+	if err != nil {
+		return model.ArchiveFile{}, err
+	}
+
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	hash := sha256.New()
+	buffered := bufio.NewWriter(io.MultiWriter(spool, hash))
+
+	records, err := fill(buffered)
+
+	if err == nil {
+		err = buffered.Flush()
+	}
+
+	if err != nil {
+		return model.ArchiveFile{}, err
+	}
+
+	size, err := spool.Seek(0, io.SeekCurrent)
+
+	if err == nil {
+		_, err = spool.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		return model.ArchiveFile{}, err
+	}
+
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    size,
+		ModTime: modTime,
+	}
+
+	err = tw.WriteHeader(header)
+
+	if err != nil {
+		return model.ArchiveFile{}, err
+	}
+
+	_, err = io.Copy(tw, spool)
+
+	if err != nil {
+		return model.ArchiveFile{}, err
+	}
+
+	return model.ArchiveFile{
+		Name:    name,
+		SHA256:  hex.EncodeToString(hash.Sum(nil)),
+		Size:    size,
+		Records: records,
+	}, nil
+}
+
+func (a *ArchiveSVC) exportMembers(ctx context.Context, orgId string, members *recordList) error {
+	cursor, err := mgm.Coll(&doc.User{}).Find(ctx, bson.M{"organizations.id": orgId})
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while fetching members to export")
+		return errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.User
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			a.logger.WithContext(ctx).WithError(err).Error("error while decoding user doc")
+			continue
+		}
+
+		for _, membership := range curDoc.Organization {
+			if membership.ID != orgId {
+				continue
+			}
+
+			err = members.Add(model.ArchiveMember{
+				UserID:    curDoc.ID.Hex(),
+				Email:     curDoc.Email,
+				IsAdmin:   membership.IsAdmin,
+				PvtKey:    membership.PvtKey,
+				PublicKey: curDoc.AsymmKey.Public,
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return cursor.Err()
+}
+
+// exportInvites lists the invites of the organization which can still be accepted.
+func (a *ArchiveSVC) exportInvites(ctx context.Context, orgId string, invites *recordList) error {
+	filter := bson.M{
+		"organizationId": orgId,
+		"expiresAt":      bson.M{"$gt": time.Now()},
+	}
+
+	cursor, err := mgm.Coll(&doc.Invite{}).Find(ctx, filter)
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while fetching invites to export")
+		return errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Invite
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			a.logger.WithContext(ctx).WithError(err).Error("error while decoding invite doc")
+			continue
+		}
+
+		err = invites.Add(model.ArchiveInvite{
+			ID:            curDoc.ID.Hex(),
+			FromUserEmail: curDoc.FromUserEmail,
+			ToUserEmail:   curDoc.ToUserEmail,
+			ExpiresAt:     curDoc.ExpiresAt,
+			SymKey: model.SymKey{
+				EncryptedData: curDoc.SymKey.EncryptedData,
+				Alg:           curDoc.SymKey.Alg,
+			},
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+func (a *ArchiveSVC) exportSecrets(ctx context.Context, filter bson.M, secrets *recordList) error {
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, filter)
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while fetching secrets to export")
+		return errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			a.logger.WithContext(ctx).WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		archived := model.ArchiveSecret{
+			ID:                   curDoc.ID.Hex(),
+			UserID:               curDoc.User.ID,
+			Role:                 curDoc.User.Role,
+			AccessExpiresAt:      curDoc.User.AccessExpiresAt,
+			EncryptedData:        curDoc.EncryptedData,
+			Name:                 curDoc.Name,
+			Description:          curDoc.Description,
+			Tags:                 curDoc.Tags,
+			CreatorEmail:         curDoc.CreatorEmail,
+			Type:                 curDoc.Type,
+			Metadata:             curDoc.Metadata,
+			ExpiresAt:            curDoc.ExpiresAt,
+			RotationIntervalDays: curDoc.RotationIntervalDays,
+			LastRotatedAt:        curDoc.LastRotatedAt,
+			RotationDueAt:        curDoc.RotationDueAt,
+			CertificateChain:     curDoc.CertificateChain,
+			Environment:          curDoc.Environment,
+			CreatedAt:            curDoc.CreatedAt,
+			UpdatedAt:            curDoc.UpdatedAt,
+		}
+
+		if curDoc.ReferenceKey != nil && !curDoc.ReferenceKey.IsZero() {
+			archived.ReferenceKey = curDoc.ReferenceKey.Hex()
+		}
+
+		if curDoc.Team != nil {
+			archived.TeamID = curDoc.Team.ID
+			archived.Role = curDoc.Team.Role
+		}
+
+		if curDoc.CollectionID != nil {
+			archived.CollectionID = curDoc.CollectionID.Hex()
+		}
+
+		if curDoc.ProjectID != nil {
+			archived.ProjectID = curDoc.ProjectID.Hex()
+		}
+
+		if curDoc.Signature != nil {
+			archived.Signature = &model.SecretSignature{
+				Value:    curDoc.Signature.Value,
+				KeyID:    curDoc.Signature.KeyID,
+				Alg:      curDoc.Signature.Alg,
+				SignedBy: model.UserID(curDoc.Signature.SignedBy),
+				SignedAt: curDoc.Signature.SignedAt,
+			}
+		}
+
+		err = secrets.Add(archived)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// orgArchive holds the decoded files of an archive being imported.
+type orgArchive struct {
+	manifest     model.ArchiveManifest
+	organization model.ArchiveOrganization
+	members      []model.ArchiveMember
+	invites      []model.ArchiveInvite
+	secrets      []model.ArchiveSecret
+	shares       []model.ArchiveSecret
+}
+
+// readArchive extracts the archive and checks every file against the hashes of the manifest.
+func readArchive(r io.Reader) (*orgArchive, error) {
+	gz, err := gzip.NewReader(r)
+
+	if err != nil {
+		return nil, errors.ErrInvalidArchive
+	}
+
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	files := map[string][]byte{}
+	var total int64
+
+	for {
+		header, err := tr.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil || header.Typeflag != tar.TypeReg {
+			return nil, errors.ErrInvalidArchive
+		}
+
+		if _, ok := files[header.Name]; ok {
+			return nil, errors.ErrInvalidArchive
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, MaxArchiveSize-total+1))
+
+		if err != nil {
+			return nil, errors.ErrInvalidArchive
+		}
+
+		total += int64(len(data))
+
+		if total > MaxArchiveSize {
+			return nil, errors.ErrInvalidArchive
+		}
+
+		files[header.Name] = data
+	}
+
+	archive := &orgArchive{}
+
+	err = json.Unmarshal(files[model.ArchiveManifestFile], &archive.manifest)
+
+	if err != nil {
+		return nil, errors.ErrInvalidArchive
+	}
+
+	if archive.manifest.FormatVersion != model.ArchiveFormatVersion {
+		return nil, errors.ErrArchiveVersion
+	}
+
+	// Every file has to be listed with a matching hash, and nothing else can be in the archive.
+	if len(archive.manifest.Files) != len(archiveDataFiles) || len(files) != len(archiveDataFiles)+1 {
+		return nil, errors.ErrArchiveIntegrity
+	}
+
+	for _, file := range archive.manifest.Files {
+		data, ok := files[file.Name]
+
+		if !ok || file.Name == model.ArchiveManifestFile {
+			return nil, errors.ErrArchiveIntegrity
+		}
+
+		sum := sha256.Sum256(data)
+
+		if hex.EncodeToString(sum[:]) != strings.ToLower(file.SHA256) {
+			return nil, errors.ErrArchiveIntegrity
+		}
+	}
+
+	targets := map[string]interface{}{
+		model.ArchiveOrganizationFile: &archive.organization,
+		model.ArchiveMembersFile:      &archive.members,
+		model.ArchiveInvitesFile:      &archive.invites,
+		model.ArchiveSecretsFile:      &archive.secrets,
+		model.ArchiveSharesFile:       &archive.shares,
+	}
+
+	for name, target := range targets {
+		err = json.Unmarshal(files[name], target)
+
+		if err != nil {
+			return nil, errors.ErrInvalidArchive
+		}
+	}
+
+	return archive, nil
+}
+
+// Import creates a new organization from an archive. Members are matched to the users of this deployment by
+// email and public key, since their wrapped organization keys only open with the same key pair. The user
+// importing must be a matched admin of the archived organization and becomes its only member, the other
+// matched members are invited with their wrapped key since anyone can write an archive naming them. All
+// object ids are replaced, including the reference keys of the shares. A dry run reports what would be
+// created without writing anything.
+func (a *ArchiveSVC) Import(ctx context.Context, userId model.UserID, r io.Reader, dryRun bool) (report model.ArchiveImportReport, err error) {
+	logger := a.logger.WithContext(ctx)
+
+	archive, err := readArchive(r)
+
+	if err != nil {
+		return
+	}
+
+	report = model.ArchiveImportReport{
+		DryRun:           dryRun,
+		UnmatchedMembers: []model.Email{},
+	}
+
+	now := time.Now()
+	newOrgId := primitive.NewObjectID()
+	orgId := newOrgId.Hex()
+
+	// Match the members, the old user ids map to the users of this deployment.
+	userIds := map[string]model.UserID{}
+	memberships := map[model.UserID]doc.UserOrganization{}
+	matched := map[model.UserID]model.User{}
+
+	for _, member := range archive.members {
+		found, userErr := a.userSvc.GetByEmail(ctx, member.Email)
+
+		if userErr != nil && userErr != errors.ErrUserNotFound && userErr != errors.ErrInvalidEmail {
+			err = userErr
+			return
+		}
+
+		if userErr != nil || member.PublicKey == "" || found.AsymmKey.Public != member.PublicKey {
+			report.UnmatchedMembers = append(report.UnmatchedMembers, member.Email)
+			continue
+		}
+
+		userIds[member.UserID] = found.ID
+		matched[found.ID] = found
+		memberships[found.ID] = doc.UserOrganization{
+			ID:      orgId,
+			IsAdmin: member.IsAdmin,
+			PvtKey:  member.PvtKey,
+		}
+	}
+
+	if !memberships[userId].IsAdmin {
+		err = errors.ErrArchiveAccessDenied
+		return
+	}
+
+	report.Members = len(memberships)
+
+	inviteDocs := []interface{}{}
+
+	for memberId, membership := range memberships {
+		if memberId == userId {
+			continue
+		}
+
+		inviteDoc := &doc.Invite{
+			ExpiresAt:        now.Add(MemberInviteExpiry),
+			FromUserEmail:    matched[userId].Email.String(),
+			ToUserEmail:      matched[memberId].Email.String(),
+			OrganizationID:   orgId,
+			OrganizationName: archive.organization.Name,
+			SymKey: doc.SymKey{
+				EncryptedData: membership.PvtKey,
+				Alg:           matched[memberId].AsymmKey.Alg,
+			},
+		}
+		inviteDoc.ID = primitive.NewObjectID()
+		inviteDoc.Creating()
+		inviteDoc.Saving()
+
+		inviteDocs = append(inviteDocs, inviteDoc)
+		delete(memberships, memberId)
+	}
+
+	report.InvitedMembers = len(inviteDocs)
+
+	// Originals get new ids first so the shares can point at them.
+	secretIds := map[string]primitive.ObjectID{}
+	owners := map[primitive.ObjectID]string{}
+	secretDocs := []interface{}{}
+
+	for _, archived := range archive.secrets {
+		if archived.ReferenceKey != "" || archived.ID == "" {
+			err = errors.ErrInvalidArchive
+			return
+		}
+
+		if _, ok := secretIds[archived.ID]; ok {
+			err = errors.ErrInvalidArchive
+			return
+		}
+
+		ownerId, ok := userIds[archived.UserID]
+
+		if !ok {
+			ownerId = userId
+			report.ReassignedSecrets++
+		}
+
+		if archived.CollectionID != "" || archived.ProjectID != "" {
+			report.DroppedPlacements++
+		}
+
+		if archived.Signature != nil {
+			report.DroppedSignatures++
+		}
+
+		secretDoc := importSecret(archived, orgId, ownerId.String(), model.SecretRoleOwner, nil)
+
+		secretIds[archived.ID] = secretDoc.ID
+		owners[secretDoc.ID] = ownerId.String()
+		secretDocs = append(secretDocs, secretDoc)
+	}
+
+	report.Secrets = len(secretDocs)
+
+	// Shares of unmatched members, or of members who own the secret now, are dropped.
+	shared := map[string]bool{}
+
+	for _, archived := range archive.shares {
+		refKey, ok := secretIds[archived.ReferenceKey]
+
+		if !ok {
+			err = errors.ErrInvalidArchive
+			return
+		}
+
+		if archived.TeamID != "" {
+			report.DroppedTeamShares++
+			continue
+		}
+
+		memberId, ok := userIds[archived.UserID]
+		shareKey := refKey.Hex() + "/" + memberId.String()
+
+		if !ok || owners[refKey] == memberId.String() || shared[shareKey] {
+			report.DroppedShares++
+			continue
+		}
+
+		shared[shareKey] = true
+
+		secretDocs = append(secretDocs, importSecret(archived, orgId, memberId.String(), archived.Role, &refKey))
+		report.Shares++
+	}
+
+	for _, archived := range archive.invites {
+		if !archived.ExpiresAt.After(now) {
+			continue
+		}
+
+		inviteDoc := &doc.Invite{
+			ExpiresAt:        archived.ExpiresAt,
+			FromUserEmail:    archived.FromUserEmail,
+			ToUserEmail:      archived.ToUserEmail,
+			OrganizationID:   orgId,
+			OrganizationName: archive.organization.Name,
+			SymKey: doc.SymKey{
+				EncryptedData: archived.SymKey.EncryptedData,
+				Alg:           archived.SymKey.Alg,
+			},
+		}
+		inviteDoc.ID = primitive.NewObjectID()
+		inviteDoc.Creating()
+		inviteDoc.Saving()
+
+		inviteDocs = append(inviteDocs, inviteDoc)
+	}
+
+	report.Invites = len(inviteDocs) - report.InvitedMembers
+
+	if dryRun {
+		return
+	}
+
+	docOrg := &doc.Organization{
+		Name:         archive.organization.Name,
+		BillingEmail: archive.organization.BillingEmail,
+		AdminEmail:   archive.organization.AdminEmail,
+		SymmKey: doc.SymKey{
+			EncryptedData: archive.organization.SymmKey.EncryptedData,
+			Alg:           archive.organization.SymmKey.Alg,
+		},
+		AttachmentQuota: archive.organization.AttachmentQuota,
+		Version:         1,
+	}
+	docOrg.ID = newOrgId
+
+	err = mgm.Coll(docOrg).CreateWithCtx(ctx, docOrg)
+
+	if err != nil {
+		logger.WithError(err).Error("error while creating imported organization")
+		err = errors.ErrUnknown
+		return
+	}
+
+	err = a.writeImport(ctx, memberships, secretDocs, inviteDocs)
+
+	if err != nil {
+		logger.WithField("organizationId", orgId).WithError(err).Error("error while importing organization, removing what was written")
+		a.removeImport(ctx, newOrgId)
+		err = errors.ErrUnknown
+		return
+	}
+
+	report.OrganizationID = model.OrganizationID(orgId)
+
+	a.auditSvc.Record(ctx, model.AuditEvent{
+		OrganizationID: orgId,
+		ActorID:        userId,
+		Action:         model.AuditOrganizationImported,
+		TargetType:     model.AuditTargetOrganization,
+		TargetID:       orgId,
+		Data: map[string]string{
+			"sourceOrganizationId": archive.manifest.OrganizationID,
+			"exportedAt":           archive.manifest.ExportedAt.Format(time.RFC3339),
+			"secrets":              strconv.Itoa(report.Secrets),
+			"shares":               strconv.Itoa(report.Shares),
+		},
+	})
+
+	if len(a.importHooks) > 0 {
+		memberIds := []model.UserID{}
+
+		for memberId := range memberships {
+			memberIds = append(memberIds, memberId)
+		}
+
+		originalIds := []model.SecretID{}
+
+		for _, secretId := range secretIds {
+			originalIds = append(originalIds, model.SecretID(secretId.Hex()))
+		}
+
+		inviteIds := []model.InviteID{}
+
+		for _, inviteDoc := range inviteDocs {
+			inviteIds = append(inviteIds, model.InviteID(inviteDoc.(*doc.Invite).ID.Hex()))
+		}
+
+		for _, hook := range a.importHooks {
+			hook(ctx, report.OrganizationID, memberIds, originalIds, inviteIds)
+		}
+	}
+
+	return
+}
+
+// importSecret builds the document of an archived secret with a new id. The timestamps of the archive are kept.
+func importSecret(archived model.ArchiveSecret, orgId string, userId string, role string, refKey *primitive.ObjectID) *doc.Secret {
+	secretDoc := &doc.Secret{
+		EncryptedData: archived.EncryptedData,
+		User: doc.SecretUser{
+			ID:              userId,
+			Role:            role,
+			AccessExpiresAt: archived.AccessExpiresAt,
+		},
+		Name:                 archived.Name,
+		Description:          archived.Description,
+		Tags:                 archived.Tags,
+		CreatorEmail:         archived.CreatorEmail,
+		Type:                 archived.Type,
+		Metadata:             archived.Metadata,
+		ReferenceKey:         refKey,
+		OrganizationID:       orgId,
+		ExpiresAt:            archived.ExpiresAt,
+		RotationIntervalDays: archived.RotationIntervalDays,
+		LastRotatedAt:        archived.LastRotatedAt,
+		RotationDueAt:        archived.RotationDueAt,
+		Version:              1,
+	}
+
+	// The certificate is read again from the chain, a chain which does not parse is left out.
+	if archived.CertificateChain != "" {
+		chain, info, err := certificate.ParseChain(archived.CertificateChain)
+
+		if err == nil {
+			cert := doc.SecretCertificate(info)
+			secretDoc.CertificateChain = chain
+			secretDoc.Certificate = &cert
+		}
+	}
+
+	secretDoc.ID = primitive.NewObjectID()
+	secretDoc.CreatedAt = archived.CreatedAt
+	secretDoc.UpdatedAt = archived.UpdatedAt
+
+	return secretDoc
+}
+
+func (a *ArchiveSVC) writeImport(ctx context.Context, memberships map[model.UserID]doc.UserOrganization, secretDocs []interface{}, inviteDocs []interface{}) error {
+	for memberId, membership := range memberships {
+		objId, _ := primitive.ObjectIDFromHex(memberId.String())
+
+		_, err := mgm.Coll(&doc.User{}).UpdateOne(ctx, bson.M{"_id": objId}, bson.M{
+			"$push": bson.M{"organizations": membership},
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(secretDocs) > 0 {
+		_, err := mgm.Coll(&doc.Secret{}).InsertMany(ctx, secretDocs)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(inviteDocs) > 0 {
+		_, err := mgm.Coll(&doc.Invite{}).InsertMany(ctx, inviteDocs)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeImport deletes everything a failed import wrote. The database has no transactions to roll back.
+func (a *ArchiveSVC) removeImport(ctx context.Context, orgObjId primitive.ObjectID) {
+	logger := a.logger.WithContext(ctx).WithField("organizationId", orgObjId.Hex())
+	orgId := orgObjId.Hex()
+
+	_, err := mgm.Coll(&doc.Secret{}).DeleteMany(ctx, bson.M{"organizationId": orgId})
+
+	if err != nil {
+		logger.WithError(err).Error("error while removing secrets of failed import")
+	}
+
+	_, err = mgm.Coll(&doc.Invite{}).DeleteMany(ctx, bson.M{"organizationId": orgId})
+
+	if err != nil {
+		logger.WithError(err).Error("error while removing invites of failed import")
+	}
+
+	_, err = mgm.Coll(&doc.User{}).UpdateMany(ctx, bson.M{"organizations.id": orgId}, bson.M{
+		"$pull": bson.M{"organizations": bson.M{"id": orgId}},
+	})
+
+	if err != nil {
+		logger.WithError(err).Error("error while removing memberships of failed import")
+	}
+
+	_, err = mgm.Coll(&doc.Organization{}).DeleteOne(ctx, bson.M{"_id": orgObjId})
+
+	if err != nil {
+		logger.WithError(err).Error("error while removing organization of failed import")
+	}
+}
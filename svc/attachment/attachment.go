@@ -0,0 +1,875 @@
+package attachment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"secaas_backend/db"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/secret"
+	"secaas_backend/svc/user"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultOrganizationQuota is the attachment storage of an organization without an explicit quota.
+	DefaultOrganizationQuota int64 = 1 << 30
+
+	// MaxChunkSize is the largest chunk accepted in a single upload request.
+	MaxChunkSize = 8 << 20
+
+	// UploadTTL is how long an upload session stays open without receiving a chunk.
+	UploadTTL = 24 * time.Hour
+
+	// UploadCleanupInterval is how often abandoned upload sessions are removed.
+	UploadCleanupInterval = time.Hour
+)
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type AttachmentSVC struct {
+	logger    *logrus.Logger
+	db        *db.DB
+	userSvc   *user.UserSVC
+	secretSvc *secret.SecretsSVC
+}
+
+func New(logger *logrus.Logger, db *db.DB, userSvc *user.UserSVC, secretSvc *secret.SecretsSVC) *AttachmentSVC {
+	a := &AttachmentSVC{logger: logger, db: db, userSvc: userSvc, secretSvc: secretSvc}
+
+	// Attachments go away together with their secret.
+	secretSvc.RegisterDeleteHook(a.DeleteForSecret)
+
+	return a
+}
+
+// StartUpload opens a resumable upload session for an attachment of the secret.
+// The declared size is reserved against the quota of the organization until the session completes or expires.
+func (a *AttachmentSVC) StartUpload(ctx context.Context, secretId model.SecretID, userId model.UserID, data model.AttachmentUpload) (upload model.AttachmentUpload, err error) {
+	data.SHA256 = strings.ToLower(data.SHA256)
+
+	if data.Name == "" || data.Size <= 0 || !sha256Pattern.MatchString(data.SHA256) {
+		err = errors.ErrInvalidAttachment
+		return
+	}
+
+	original, err := a.secretSvc.Authorize(ctx, secretId, userId, secret.ActionUpdate)
+
+	if err != nil {
+		return
+	}
+
+	err = a.reserve(ctx, original.OrganizationID, data.Size)
+
+	if err != nil {
+		return
+	}
+
+	secretObjId, _ := primitive.ObjectIDFromHex(original.ID.String())
+
+	contentType := data.ContentType
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	docUpload := &doc.AttachmentUpload{
+		SecretID:       secretObjId,
+		OrganizationID: original.OrganizationID,
+		Name:           data.Name,
+		ContentType:    contentType,
+		Size:           data.Size,
+		SHA256:         data.SHA256,
+		ExpiresAt:      time.Now().Add(UploadTTL),
+		UploadedBy:     userId.String(),
+	}
+
+	err = mgm.Coll(docUpload).CreateWithCtx(ctx, docUpload)
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while creating attachment upload")
+		a.adjustBytes(ctx, original.OrganizationID, -data.Size)
+		err = errors.ErrUnknown
+		return
+	}
+
+	upload = a.MapDocToUpload(docUpload)
+
+	return
+}
+
+// GetUpload returns the upload session so the client knows where to resume.
+func (a *AttachmentSVC) GetUpload(ctx context.Context, secretId model.SecretID, uploadId model.AttachmentUploadID, userId model.UserID) (upload model.AttachmentUpload, err error) {
+	docUpload, err := a.getUploadDoc(ctx, secretId, uploadId, userId)
+
+	if err != nil {
+		return
+	}
+
+	upload = a.MapDocToUpload(docUpload)
+
+	return
+}
+
+// AppendChunk stores the next chunk of the upload. The offset must match the bytes received so far.
+func (a *AttachmentSVC) AppendChunk(ctx context.Context, secretId model.SecretID, uploadId model.AttachmentUploadID, userId model.UserID, offset int64, data []byte) (upload model.AttachmentUpload, err error) {
+	if len(data) == 0 || len(data) > MaxChunkSize {
+		err = errors.ErrInvalidAttachment
+		return
+	}
+
+	docUpload, err := a.getUploadDoc(ctx, secretId, uploadId, userId)
+
+	if err != nil {
+		return
+	}
+
+	if offset != docUpload.ReceivedSize {
+		err = errors.ErrAttachmentChunkOffset
+		return
+	}
+
+	if offset+int64(len(data)) > docUpload.Size {
+		err = errors.ErrInvalidAttachment
+		return
+	}
+
+	chunk := &doc.AttachmentChunk{
+		UploadID: docUpload.ID,
+		Offset:   offset,
+		Data:     data,
+	}
+
+	err = mgm.Coll(chunk).CreateWithCtx(ctx, chunk)
+
+	if err != nil {
+		// The unique index on the offset rejects a chunk sent twice at the same time.
+		if strings.Contains(err.Error(), "duplicate key") {
+			err = errors.ErrAttachmentChunkOffset
+			return
+		}
+		a.logger.WithContext(ctx).WithError(err).Error("error while storing attachment chunk")
+		err = errors.ErrUnknown
+		return
+	}
+
+	filter := bson.M{
+		"_id":          docUpload.ID,
+		"receivedSize": offset,
+	}
+
+	now := time.Now()
+
+	update := bson.M{
+		"$inc": bson.M{"receivedSize": int64(len(data))},
+		"$set": bson.M{
+			"expiresAt":        now.Add(UploadTTL),
+			doc.UpdatedAtField: now,
+		},
+	}
+
+	res, err := mgm.Coll(docUpload).UpdateOne(ctx, filter, update)
+
+	if err != nil || res.MatchedCount == 0 {
+		// Drop the chunk again so the offset can be retried.
+		_, delErr := mgm.Coll(chunk).DeleteOne(ctx, bson.M{"_id": chunk.ID})
+
+		if delErr != nil {
+			a.logger.WithContext(ctx).WithError(delErr).Error("error while removing unaccounted attachment chunk")
+		}
+
+		if err != nil {
+			a.logger.WithContext(ctx).WithError(err).Error("error while updating attachment upload")
+			err = errors.ErrUnknown
+			return
+		}
+
+		err = errors.ErrAttachmentChunkOffset
+		return
+	}
+
+	docUpload.ReceivedSize += int64(len(data))
+	docUpload.ExpiresAt = now.Add(UploadTTL)
+	docUpload.UpdatedAt = now
+
+	upload = a.MapDocToUpload(docUpload)
+
+	return
+}
+
+// CompleteUpload writes the received chunks into GridFS and checks them against the declared hash.
+func (a *AttachmentSVC) CompleteUpload(ctx context.Context, secretId model.SecretID, uploadId model.AttachmentUploadID, userId model.UserID) (attachment model.Attachment, err error) {
+	logger := a.logger.WithContext(ctx).WithField("uploadId", uploadId.String())
+
+	docUpload, err := a.getUploadDoc(ctx, secretId, uploadId, userId)
+
+	if err != nil {
+		return
+	}
+
+	if docUpload.ReceivedSize != docUpload.Size {
+		err = errors.ErrAttachmentUploadIncomplete
+		return
+	}
+
+	bucket, err := a.db.AttachmentBucket()
+
+	if err != nil {
+		logger.WithError(err).Error("error while opening attachment bucket")
+		err = errors.ErrUnknown
+		return
+	}
+
+	fileId := primitive.NewObjectID()
+
+	stream, err := bucket.OpenUploadStreamWithID(fileId, docUpload.Name, options.GridFSUpload().SetMetadata(bson.M{
+		"secretId":       docUpload.SecretID,
+		"organizationId": docUpload.OrganizationID,
+		"contentType":    docUpload.ContentType,
+	}))
+
+	if err != nil {
+		logger.WithError(err).Error("error while opening attachment upload stream")
+		err = errors.ErrUnknown
+		return
+	}
+
+	written, hash, err := a.copyChunks(ctx, docUpload.ID, stream)
+
+	closeErr := stream.Close()
+
+	if err == nil && closeErr != nil {
+		logger.WithError(closeErr).Error("error while closing attachment upload stream")
+		err = errors.ErrUnknown
+	}
+
+	if err == nil && (written != docUpload.Size || hash != docUpload.SHA256) {
+		logger.WithField("expected", docUpload.SHA256).WithField("actual", hash).Info("attachment integrity check failed")
+		err = errors.ErrAttachmentIntegrity
+	}
+
+	if err != nil {
+		a.deleteFile(ctx, fileId)
+
+		// A corrupted upload cannot be resumed, the client has to start again.
+		if err == errors.ErrAttachmentIntegrity {
+			a.deleteUpload(ctx, docUpload, true)
+		}
+		return
+	}
+
+	docAttachment := &doc.Attachment{
+		SecretID:       docUpload.SecretID,
+		OrganizationID: docUpload.OrganizationID,
+		FileID:         fileId,
+		Name:           docUpload.Name,
+		ContentType:    docUpload.ContentType,
+		Size:           docUpload.Size,
+		SHA256:         docUpload.SHA256,
+		UploadedBy:     userId.String(),
+	}
+
+	err = mgm.Coll(docAttachment).CreateWithCtx(ctx, docAttachment)
+
+	if err != nil {
+		logger.WithError(err).Error("error while creating attachment")
+		a.deleteFile(ctx, fileId)
+		err = errors.ErrUnknown
+		return
+	}
+
+	// The reservation of the session now counts the stored attachment. When the session expired meanwhile
+	// its reservation was given back and the attachment takes it again.
+	if !a.deleteUpload(ctx, docUpload, false) {
+		a.adjustBytes(ctx, docUpload.OrganizationID, docUpload.Size)
+	}
+
+	attachment = a.MapDocToAttachment(docAttachment)
+
+	return
+}
+
+// copyChunks writes the chunks of the upload in order into the stream and returns the size and hash written.
+func (a *AttachmentSVC) copyChunks(ctx context.Context, uploadId primitive.ObjectID, stream *gridfs.UploadStream) (int64, string, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "offset", Value: 1}})
+
+	cursor, err := mgm.Coll(&doc.AttachmentChunk{}).Find(ctx, bson.M{"uploadId": uploadId}, findOptions)
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while fetching attachment chunks")
+		return 0, "", errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	hasher := sha256.New()
+	var written int64
+
+	for cursor.Next(ctx) {
+		var chunk doc.AttachmentChunk
+
+		err = cursor.Decode(&chunk)
+
+		if err != nil {
+			a.logger.WithContext(ctx).WithError(err).Error("error while decoding attachment chunk")
+			return 0, "", errors.ErrUnknown
+		}
+
+		if chunk.Offset != written {
+			return 0, "", errors.ErrAttachmentUploadIncomplete
+		}
+
+		_, err = stream.Write(chunk.Data)
+
+		if err != nil {
+			a.logger.WithContext(ctx).WithError(err).Error("error while writing attachment to gridfs")
+			return 0, "", errors.ErrUnknown
+		}
+
+		hasher.Write(chunk.Data)
+		written += int64(len(chunk.Data))
+	}
+
+	return written, hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// CancelUpload drops the upload session and the chunks received for it.
+func (a *AttachmentSVC) CancelUpload(ctx context.Context, secretId model.SecretID, uploadId model.AttachmentUploadID, userId model.UserID) error {
+	docUpload, err := a.getUploadDoc(ctx, secretId, uploadId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	a.deleteUpload(ctx, docUpload, true)
+
+	return nil
+}
+
+func (a *AttachmentSVC) GetForSecret(ctx context.Context, secretId model.SecretID, userId model.UserID) ([]model.Attachment, error) {
+	original, err := a.secretSvc.Authorize(ctx, secretId, userId, secret.ActionRead)
+
+	if err != nil {
+		return nil, err
+	}
+
+	secretObjId, _ := primitive.ObjectIDFromHex(original.ID.String())
+
+	findOptions := options.Find().SetSort(bson.D{{Key: doc.CreatedAtField, Value: -1}})
+
+	cursor, err := mgm.Coll(&doc.Attachment{}).Find(ctx, bson.M{"secretId": secretObjId}, findOptions)
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while fetching attachments of secret")
+		return nil, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	attachments := make([]model.Attachment, 0)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Attachment
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			a.logger.WithContext(ctx).WithError(err).Error("error while decoding attachment doc")
+			continue
+		}
+
+		attachments = append(attachments, a.MapDocToAttachment(&curDoc))
+	}
+
+	return attachments, nil
+}
+
+// Download opens a stream over the encrypted content of the attachment. The caller has to close the stream.
+func (a *AttachmentSVC) Download(ctx context.Context, secretId model.SecretID, attachmentId model.AttachmentID, userId model.UserID) (model.Attachment, *gridfs.DownloadStream, error) {
+	docAttachment, err := a.getAttachmentDoc(ctx, secretId, attachmentId, userId, secret.ActionRead)
+
+	if err != nil {
+		return model.Attachment{}, nil, err
+	}
+
+	bucket, err := a.db.AttachmentBucket()
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while opening attachment bucket")
+		return model.Attachment{}, nil, errors.ErrUnknown
+	}
+
+	stream, err := bucket.OpenDownloadStream(docAttachment.FileID)
+
+	if err != nil {
+		if err == gridfs.ErrFileNotFound {
+			return model.Attachment{}, nil, errors.ErrAttachmentNotFound
+		}
+		a.logger.WithContext(ctx).WithError(err).Error("error while opening attachment download stream")
+		return model.Attachment{}, nil, errors.ErrUnknown
+	}
+
+	return a.MapDocToAttachment(docAttachment), stream, nil
+}
+
+func (a *AttachmentSVC) Delete(ctx context.Context, secretId model.SecretID, attachmentId model.AttachmentID, userId model.UserID) (deleted int, err error) {
+	docAttachment, err := a.getAttachmentDoc(ctx, secretId, attachmentId, userId, secret.ActionUpdate)
+
+	if err != nil {
+		return
+	}
+
+	res, err := mgm.Coll(docAttachment).DeleteOne(ctx, bson.M{"_id": docAttachment.ID})
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while deleting attachment")
+		err = errors.ErrUnknown
+		return
+	}
+
+	a.deleteFile(ctx, docAttachment.FileID)
+
+	deleted = int(res.DeletedCount)
+
+	if deleted > 0 {
+		a.adjustBytes(ctx, docAttachment.OrganizationID, -docAttachment.Size)
+	}
+
+	return
+}
+
+// DeleteForSecret removes the attachments and open uploads of a deleted secret.
+func (a *AttachmentSVC) DeleteForSecret(ctx context.Context, secretId model.SecretID) {
+	logger := a.logger.WithContext(ctx).WithField("secretId", secretId.String())
+
+	secretObjId, err := primitive.ObjectIDFromHex(secretId.String())
+
+	if err != nil {
+		return
+	}
+
+	cursor, err := mgm.Coll(&doc.Attachment{}).Find(ctx, bson.M{"secretId": secretObjId})
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching attachments of deleted secret")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Attachment
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			logger.WithError(err).Error("error while decoding attachment doc")
+			continue
+		}
+
+		// Attachments are removed one by one so each gives back its size once.
+		res, delErr := mgm.Coll(&curDoc).DeleteOne(ctx, bson.M{"_id": curDoc.ID})
+
+		if delErr != nil {
+			logger.WithError(delErr).Error("error while deleting attachment of deleted secret")
+			continue
+		}
+
+		a.deleteFile(ctx, curDoc.FileID)
+
+		if res.DeletedCount > 0 {
+			a.adjustBytes(ctx, curDoc.OrganizationID, -curDoc.Size)
+		}
+	}
+
+	a.deleteUploads(ctx, bson.M{"secretId": secretObjId})
+}
+
+// RemoveExpiredUploads drops the upload sessions which did not receive a chunk within UploadTTL.
+func (a *AttachmentSVC) RemoveExpiredUploads(ctx context.Context) {
+	a.deleteUploads(ctx, bson.M{"expiresAt": bson.M{"$lte": time.Now()}})
+}
+
+// GetUsage returns the attachment storage used by the organization.
+func (a *AttachmentSVC) GetUsage(ctx context.Context, orgId model.OrganizationID, userId model.UserID) (usage model.AttachmentUsage, err error) {
+	_, err = a.userSvc.GetOrganizationMembership(ctx, userId, orgId)
+
+	if err != nil {
+		return
+	}
+
+	return a.getUsage(ctx, orgId.String())
+}
+
+// SetQuota changes the attachment quota of the organization. Only org admins can change it.
+func (a *AttachmentSVC) SetQuota(ctx context.Context, orgId model.OrganizationID, userId model.UserID, quota int64) (usage model.AttachmentUsage, err error) {
+	if quota <= 0 {
+		err = errors.ErrInvalidAttachment
+		return
+	}
+
+	if !a.userSvc.IsOrganizationAdmin(ctx, userId, orgId) {
+		err = errors.ErrAttachmentAccessDenied
+		return
+	}
+
+	objId, err := primitive.ObjectIDFromHex(orgId.String())
+
+	if err != nil {
+		err = errors.ErrInvalidOrganizationID
+		return
+	}
+
+	_, err = mgm.Coll(&doc.Organization{}).UpdateOne(ctx, bson.M{"_id": objId}, bson.M{
+		"$set": bson.M{"attachmentQuota": quota},
+	})
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while updating attachment quota")
+		err = errors.ErrUnknown
+		return
+	}
+
+	return a.getUsage(ctx, orgId.String())
+}
+
+func (a *AttachmentSVC) getUsage(ctx context.Context, orgId string) (usage model.AttachmentUsage, err error) {
+	usage = model.AttachmentUsage{
+		OrganizationID: orgId,
+		Quota:          DefaultOrganizationQuota,
+	}
+
+	objId, err := primitive.ObjectIDFromHex(orgId)
+
+	if err != nil {
+		err = errors.ErrInvalidOrganizationID
+		return
+	}
+
+	org := &doc.Organization{}
+
+	err = mgm.Coll(org).FindByID(objId, org)
+
+	if err != nil {
+		if !strings.Contains(err.Error(), "no documents") {
+			a.logger.WithContext(ctx).WithError(err).Error("error while fetching organization for attachment quota")
+			err = errors.ErrUnknown
+			return
+		}
+		err = nil
+	}
+
+	if org.AttachmentQuota > 0 {
+		usage.Quota = org.AttachmentQuota
+	}
+
+	usage.Used, err = a.sumSize(ctx, &doc.Attachment{}, bson.M{"organizationId": orgId})
+
+	if err != nil {
+		return
+	}
+
+	usage.Reserved, err = a.sumSize(ctx, &doc.AttachmentUpload{}, bson.M{
+		"organizationId": orgId,
+		"expiresAt":      bson.M{"$gt": time.Now()},
+	})
+
+	return
+}
+
+// sumSize adds up the size field of the docs matching the filter.
+func (a *AttachmentSVC) sumSize(ctx context.Context, m mgm.Model, filter bson.M) (int64, error) {
+	pipeline := bson.A{
+		bson.M{"$match": filter},
+		bson.M{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$size"}}},
+	}
+
+	cursor, err := mgm.Coll(m).Aggregate(ctx, pipeline)
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while summing attachment sizes")
+		return 0, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Total int64 `bson:"total"`
+	}
+
+	if cursor.Next(ctx) {
+		err = cursor.Decode(&result)
+
+		if err != nil {
+			a.logger.WithContext(ctx).WithError(err).Error("error while decoding attachment size sum")
+			return 0, errors.ErrUnknown
+		}
+	}
+
+	return result.Total, nil
+}
+
+// reserve adds the size to the attachment bytes of the organization unless that would exceed its quota.
+// The check and the increment are one update, so concurrent uploads cannot together overrun the quota.
+func (a *AttachmentSVC) reserve(ctx context.Context, orgId string, size int64) error {
+	logger := a.logger.WithContext(ctx).WithField("organizationId", orgId)
+
+	objId, err := primitive.ObjectIDFromHex(orgId)
+
+	if err != nil {
+		return errors.ErrInvalidOrganizationID
+	}
+
+	org := &doc.Organization{}
+
+	err = mgm.Coll(org).FindByID(objId, org)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return errors.ErrOrganizationNotFound
+		}
+		logger.WithError(err).Error("error while fetching organization for attachment quota")
+		return errors.ErrUnknown
+	}
+
+	// Organizations from before the counter start it from what they store.
+	if org.AttachmentBytes == nil {
+		usage, err := a.getUsage(ctx, orgId)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = mgm.Coll(org).UpdateOne(ctx, bson.M{"_id": objId, "attachmentBytes": bson.M{"$exists": false}}, bson.M{
+			"$set": bson.M{"attachmentBytes": usage.Used + usage.Reserved},
+		})
+
+		if err != nil {
+			logger.WithError(err).Error("error while starting attachment bytes of organization")
+			return errors.ErrUnknown
+		}
+	}
+
+	quota := bson.M{"$cond": bson.A{
+		bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$attachmentQuota", 0}}, 0}},
+		"$attachmentQuota",
+		DefaultOrganizationQuota,
+	}}
+
+	filter := bson.M{
+		"_id":   objId,
+		"$expr": bson.M{"$lte": bson.A{bson.M{"$add": bson.A{"$attachmentBytes", size}}, quota}},
+	}
+
+	res, err := mgm.Coll(org).UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"attachmentBytes": size}})
+
+	if err != nil {
+		logger.WithError(err).Error("error while reserving attachment bytes")
+		return errors.ErrUnknown
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.ErrAttachmentQuotaExceeded
+	}
+
+	return nil
+}
+
+// adjustBytes changes the attachment bytes of the organization by delta.
+func (a *AttachmentSVC) adjustBytes(ctx context.Context, orgId string, delta int64) {
+	objId, err := primitive.ObjectIDFromHex(orgId)
+
+	if err != nil {
+		return
+	}
+
+	_, err = mgm.Coll(&doc.Organization{}).UpdateOne(ctx, bson.M{"_id": objId, "attachmentBytes": bson.M{"$exists": true}}, bson.M{
+		"$inc": bson.M{"attachmentBytes": delta},
+	})
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithField("organizationId", orgId).WithError(err).Error("error while adjusting attachment bytes")
+	}
+}
+
+func (a *AttachmentSVC) getUploadDoc(ctx context.Context, secretId model.SecretID, uploadId model.AttachmentUploadID, userId model.UserID) (*doc.AttachmentUpload, error) {
+	original, err := a.secretSvc.Authorize(ctx, secretId, userId, secret.ActionUpdate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	objId, err := primitive.ObjectIDFromHex(uploadId.String())
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("invalid attachment upload id found")
+		return nil, errors.ErrInvalidID
+	}
+
+	docUpload := &doc.AttachmentUpload{}
+
+	err = mgm.Coll(docUpload).FindByID(objId, docUpload)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrAttachmentUploadNotFound
+		}
+		a.logger.WithContext(ctx).WithError(err).Error("error while fetching attachment upload")
+		return nil, errors.ErrUnknown
+	}
+
+	if docUpload.SecretID.Hex() != original.ID.String() || !docUpload.ExpiresAt.After(time.Now()) {
+		return nil, errors.ErrAttachmentUploadNotFound
+	}
+
+	return docUpload, nil
+}
+
+func (a *AttachmentSVC) getAttachmentDoc(ctx context.Context, secretId model.SecretID, attachmentId model.AttachmentID, userId model.UserID, action secret.SecretAction) (*doc.Attachment, error) {
+	original, err := a.secretSvc.Authorize(ctx, secretId, userId, action)
+
+	if err != nil {
+		return nil, err
+	}
+
+	objId, err := primitive.ObjectIDFromHex(attachmentId.String())
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("invalid attachment id found")
+		return nil, errors.ErrInvalidID
+	}
+
+	docAttachment := &doc.Attachment{}
+
+	err = mgm.Coll(docAttachment).FindByID(objId, docAttachment)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrAttachmentNotFound
+		}
+		a.logger.WithContext(ctx).WithError(err).Error("error while fetching attachment")
+		return nil, errors.ErrUnknown
+	}
+
+	if docAttachment.SecretID.Hex() != original.ID.String() {
+		return nil, errors.ErrAttachmentNotFound
+	}
+
+	return docAttachment, nil
+}
+
+func (a *AttachmentSVC) deleteFile(ctx context.Context, fileId primitive.ObjectID) {
+	bucket, err := a.db.AttachmentBucket()
+
+	if err == nil {
+		err = bucket.Delete(fileId)
+	}
+
+	if err != nil && err != gridfs.ErrFileNotFound {
+		a.logger.WithContext(ctx).WithField("fileId", fileId.Hex()).WithError(err).Error("error while deleting attachment file")
+	}
+}
+
+// deleteUpload removes the upload session and its chunks, and with release gives back the size it reserved.
+// It reports if the session was still there to remove.
+func (a *AttachmentSVC) deleteUpload(ctx context.Context, docUpload *doc.AttachmentUpload, release bool) bool {
+	logger := a.logger.WithContext(ctx).WithField("uploadId", docUpload.ID.Hex())
+
+	res, err := mgm.Coll(docUpload).DeleteOne(ctx, bson.M{"_id": docUpload.ID})
+
+	if err != nil {
+		logger.WithError(err).Error("error while deleting attachment upload")
+		return false
+	}
+
+	if res.DeletedCount == 0 {
+		return false
+	}
+
+	if release {
+		a.adjustBytes(ctx, docUpload.OrganizationID, -docUpload.Size)
+	}
+
+	_, err = mgm.Coll(&doc.AttachmentChunk{}).DeleteMany(ctx, bson.M{"uploadId": docUpload.ID})
+
+	if err != nil {
+		logger.WithError(err).Error("error while deleting attachment chunks")
+	}
+
+	return true
+}
+
+// deleteUploads removes the upload sessions matching the filter and their chunks, and gives back their sizes.
+func (a *AttachmentSVC) deleteUploads(ctx context.Context, filter bson.M) {
+	logger := a.logger.WithContext(ctx)
+
+	projection := bson.M{"_id": 1, "organizationId": 1, "size": 1}
+
+	cursor, err := mgm.Coll(&doc.AttachmentUpload{}).Find(ctx, filter, options.Find().SetProjection(projection))
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching attachment uploads to delete")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.AttachmentUpload
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			logger.WithError(err).Error("error while decoding attachment upload doc")
+			continue
+		}
+
+		a.deleteUpload(ctx, &curDoc, true)
+	}
+}
+
+func (a *AttachmentSVC) MapDocToAttachment(docAttachment *doc.Attachment) model.Attachment {
+	attachment := model.Attachment{
+		ID:             model.AttachmentID(docAttachment.ID.Hex()),
+		CreatedAt:      docAttachment.CreatedAt,
+		SecretID:       model.SecretID(docAttachment.SecretID.Hex()),
+		OrganizationID: docAttachment.OrganizationID,
+		Name:           docAttachment.Name,
+		ContentType:    docAttachment.ContentType,
+		Size:           docAttachment.Size,
+		SHA256:         docAttachment.SHA256,
+		UploadedBy:     model.UserID(docAttachment.UploadedBy),
+	}
+
+	return attachment
+}
+
+func (a *AttachmentSVC) MapDocToUpload(docUpload *doc.AttachmentUpload) model.AttachmentUpload {
+	upload := model.AttachmentUpload{
+		ID:             model.AttachmentUploadID(docUpload.ID.Hex()),
+		CreatedAt:      docUpload.CreatedAt,
+		UpdatedAt:      docUpload.UpdatedAt,
+		SecretID:       model.SecretID(docUpload.SecretID.Hex()),
+		OrganizationID: docUpload.OrganizationID,
+		Name:           docUpload.Name,
+		ContentType:    docUpload.ContentType,
+		Size:           docUpload.Size,
+		SHA256:         docUpload.SHA256,
+		ReceivedSize:   docUpload.ReceivedSize,
+		ExpiresAt:      docUpload.ExpiresAt,
+		UploadedBy:     model.UserID(docUpload.UploadedBy),
+	}
+
+	return upload
+}
@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/pagination"
+	"secaas_backend/svc/user"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type AuditSVC struct {
+	logger  *logrus.Logger
+	userSvc *user.UserSVC
+}
+
+func New(logger *logrus.Logger, userSvc *user.UserSVC) *AuditSVC {
+	a := &AuditSVC{logger: logger, userSvc: userSvc}
+	return a
+}
+
+// Record stores the event in the audit log of its organization. Failures are only logged so callers never fail because of the audit.
+func (a *AuditSVC) Record(ctx context.Context, event model.AuditEvent) {
+	docEvent := &doc.AuditEvent{
+		OrganizationID: event.OrganizationID,
+		ActorID:        event.ActorID.String(),
+		Action:         event.Action,
+		TargetType:     event.TargetType,
+		TargetID:       event.TargetID,
+		Data:           event.Data,
+	}
+
+	err := mgm.Coll(docEvent).CreateWithCtx(ctx, docEvent)
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithField("action", event.Action).WithField("targetId", event.TargetID).WithError(err).Error("error while recording audit event")
+	}
+}
+
+// GetForOrganization lists the audit log of the organization, newest first. Only org admins can read it.
+func (a *AuditSVC) GetForOrganization(ctx context.Context, orgId model.OrganizationID, userId model.UserID, action string, targetId string, params model.PaginationParams) ([]model.AuditEvent, model.PageInfo, error) {
+	if orgId == "" {
+		return nil, model.PageInfo{}, errors.ErrInvalidOrganizationID
+	}
+
+	if !a.userSvc.IsOrganizationAdmin(ctx, userId, orgId) {
+		return nil, model.PageInfo{}, errors.ErrAuditAccessDenied
+	}
+
+	filter := bson.M{"organizationId": orgId.String()}
+
+	if action != "" {
+		filter["action"] = action
+	}
+
+	if targetId != "" {
+		filter["targetId"] = targetId
+	}
+
+	cursor, err := pagination.Find(ctx, mgm.Coll(&doc.AuditEvent{}), filter, params)
+
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("error while fetching audit events")
+		return nil, model.PageInfo{}, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	events := make([]model.AuditEvent, 0)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.AuditEvent
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			a.logger.WithContext(ctx).WithError(err).Error("error while decoding audit event doc")
+			continue
+		}
+
+		events = append(events, a.MapDocToAuditEvent(&curDoc))
+	}
+
+	return events, cursor.Info(), nil
+}
+
+func (a *AuditSVC) MapDocToAuditEvent(docEvent *doc.AuditEvent) model.AuditEvent {
+	return model.AuditEvent{
+		ID:             model.AuditEventID(docEvent.ID.Hex()),
+		CreatedAt:      docEvent.CreatedAt,
+		OrganizationID: docEvent.OrganizationID,
+		ActorID:        model.UserID(docEvent.ActorID),
+		Action:         docEvent.Action,
+		TargetType:     docEvent.TargetType,
+		TargetID:       docEvent.TargetID,
+		Data:           docEvent.Data,
+	}
+}
@@ -0,0 +1,798 @@
+package breakglass
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/audit"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/notification"
+	"secaas_backend/svc/pagination"
+	"secaas_backend/svc/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// MinThreshold is the smallest quorum of a vault, a single admin can never open it alone.
+	MinThreshold = 2
+	// MaxCustodians is the most shares a Shamir split over GF(256) can produce.
+	MaxCustodians = 255
+	// UnlockTTL is how long an unlock waits for the quorum.
+	UnlockTTL = 24 * time.Hour
+	// CollectionWindow is how long the requester can collect the shares once the quorum is reached.
+	CollectionWindow = time.Hour
+	// ExpiryCheckInterval is how often the scheduler expires unlocks.
+	ExpiryCheckInterval = time.Minute
+)
+
+type BreakGlassSVC struct {
+	logger          *logrus.Logger
+	userSvc         *user.UserSVC
+	auditSvc        *audit.AuditSVC
+	notificationSvc *notification.NotificationSVC
+}
+
+func New(logger *logrus.Logger, userSvc *user.UserSVC, auditSvc *audit.AuditSVC, notificationSvc *notification.NotificationSVC) *BreakGlassSVC {
+	b := &BreakGlassSVC{logger: logger, userSvc: userSvc, auditSvc: auditSvc, notificationSvc: notificationSvc}
+	return b
+}
+
+// CreateVault seals the emergency material of the organization. Every custodian must be an org admin and gets one share.
+func (b *BreakGlassSVC) CreateVault(ctx context.Context, userId model.UserID, data model.BreakGlassVault) (vault model.BreakGlassVault, err error) {
+	orgId := model.OrganizationID(data.OrganizationID)
+
+	if orgId == "" {
+		err = errors.ErrInvalidOrganizationID
+		return
+	}
+
+	if !b.userSvc.IsOrganizationAdmin(ctx, userId, orgId) {
+		err = errors.ErrBreakGlassAccessDenied
+		return
+	}
+
+	custodianCount := len(data.Custodians)
+
+	if strings.TrimSpace(data.Name) == "" || data.EncryptedPayload == "" || custodianCount < MinThreshold || custodianCount > MaxCustodians ||
+		data.Threshold < MinThreshold || data.Threshold > custodianCount {
+		err = errors.ErrInvalidBreakGlassVault
+		return
+	}
+
+	custodians := make([]doc.BreakGlassCustodian, 0, custodianCount)
+	seen := map[model.UserID]bool{}
+
+	for _, custodian := range data.Custodians {
+		if custodian.EncryptedShare == "" || seen[custodian.UserID] {
+			err = errors.ErrInvalidBreakGlassVault
+			return
+		}
+
+		if !b.userSvc.IsOrganizationAdmin(ctx, custodian.UserID, orgId) {
+			err = errors.ErrInvalidBreakGlassVault
+			return
+		}
+
+		seen[custodian.UserID] = true
+
+		custodians = append(custodians, doc.BreakGlassCustodian{
+			UserID:         custodian.UserID.String(),
+			EncryptedShare: custodian.EncryptedShare,
+		})
+	}
+
+	docVault := &doc.BreakGlassVault{
+		OrganizationID:   orgId.String(),
+		Name:             data.Name,
+		Description:      data.Description,
+		Threshold:        data.Threshold,
+		EncryptedPayload: data.EncryptedPayload,
+		Custodians:       custodians,
+		CreatedBy:        userId.String(),
+	}
+
+	err = mgm.Coll(docVault).CreateWithCtx(ctx, docVault)
+
+	if err != nil {
+		b.logger.WithContext(ctx).WithError(err).Error("error while creating break-glass vault")
+		err = errors.ErrUnknown
+		return
+	}
+
+	b.auditSvc.Record(ctx, model.AuditEvent{
+		OrganizationID: docVault.OrganizationID,
+		ActorID:        userId,
+		Action:         model.AuditBreakGlassVaultCreated,
+		TargetType:     model.AuditTargetBreakGlassVault,
+		TargetID:       docVault.ID.Hex(),
+		Data: map[string]string{
+			"threshold":  strconv.Itoa(docVault.Threshold),
+			"custodians": strconv.Itoa(custodianCount),
+		},
+	})
+
+	vault = b.MapDocToVault(docVault, userId)
+
+	return
+}
+
+func (b *BreakGlassSVC) GetVault(ctx context.Context, vaultId model.BreakGlassVaultID, userId model.UserID) (vault model.BreakGlassVault, err error) {
+	docVault, err := b.getVault(ctx, vaultId)
+
+	if err != nil {
+		return
+	}
+
+	if !isCustodian(docVault, userId) && !b.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(docVault.OrganizationID)) {
+		err = errors.ErrBreakGlassAccessDenied
+		return
+	}
+
+	vault = b.MapDocToVault(docVault, userId)
+
+	return
+}
+
+// GetVaultsForOrganization lists the vaults of the organization. Only org admins can see them.
+func (b *BreakGlassSVC) GetVaultsForOrganization(ctx context.Context, orgId model.OrganizationID, userId model.UserID, params model.PaginationParams) ([]model.BreakGlassVault, model.PageInfo, error) {
+	if !b.userSvc.IsOrganizationAdmin(ctx, userId, orgId) {
+		return nil, model.PageInfo{}, errors.ErrBreakGlassAccessDenied
+	}
+
+	cursor, err := pagination.Find(ctx, mgm.Coll(&doc.BreakGlassVault{}), bson.M{"organizationId": orgId.String()}, params)
+
+	if err != nil {
+		b.logger.WithContext(ctx).WithError(err).Error("error while fetching break-glass vaults")
+		return nil, model.PageInfo{}, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	vaults := make([]model.BreakGlassVault, 0)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.BreakGlassVault
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			b.logger.WithContext(ctx).WithError(err).Error("error while decoding break-glass vault doc")
+			continue
+		}
+
+		vaults = append(vaults, b.MapDocToVault(&curDoc, userId))
+	}
+
+	return vaults, cursor.Info(), nil
+}
+
+// DeleteVault removes the vault and closes its open unlocks.
+func (b *BreakGlassSVC) DeleteVault(ctx context.Context, vaultId model.BreakGlassVaultID, userId model.UserID) (deleted int, err error) {
+	docVault, err := b.getVault(ctx, vaultId)
+
+	if err != nil {
+		return
+	}
+
+	if !b.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(docVault.OrganizationID)) {
+		err = errors.ErrBreakGlassAccessDenied
+		return
+	}
+
+	res, err := mgm.Coll(docVault).DeleteOne(ctx, bson.M{"_id": docVault.ID})
+
+	if err != nil {
+		b.logger.WithContext(ctx).WithField("vaultId", vaultId.String()).WithError(err).Error("error while deleting break-glass vault")
+		err = errors.ErrUnknown
+		return
+	}
+
+	deleted = int(res.DeletedCount)
+
+	b.auditSvc.Record(ctx, model.AuditEvent{
+		OrganizationID: docVault.OrganizationID,
+		ActorID:        userId,
+		Action:         model.AuditBreakGlassVaultDeleted,
+		TargetType:     model.AuditTargetBreakGlassVault,
+		TargetID:       docVault.ID.Hex(),
+	})
+
+	b.closeMatching(ctx, bson.M{"vaultId": docVault.ID}, model.BreakGlassUnlockCancelled, userId, model.AuditBreakGlassUnlockCancelled)
+
+	return
+}
+
+// StartUnlock opens an unlock of the vault and alerts every admin of the organization.
+func (b *BreakGlassSVC) StartUnlock(ctx context.Context, vaultId model.BreakGlassVaultID, userId model.UserID, start model.BreakGlassUnlockStart) (unlock model.BreakGlassUnlock, err error) {
+	if strings.TrimSpace(start.Reason) == "" || start.RecipientPublicKey == "" {
+		err = errors.ErrInvalidBreakGlassUnlock
+		return
+	}
+
+	docVault, err := b.getVault(ctx, vaultId)
+
+	if err != nil {
+		return
+	}
+
+	orgId := model.OrganizationID(docVault.OrganizationID)
+
+	if !isCustodian(docVault, userId) && !b.userSvc.IsOrganizationAdmin(ctx, userId, orgId) {
+		err = errors.ErrBreakGlassAccessDenied
+		return
+	}
+
+	count, err := mgm.Coll(&doc.BreakGlassUnlock{}).CountDocuments(ctx, bson.M{
+		"vaultId": docVault.ID,
+		"status":  bson.M{"$in": bson.A{model.BreakGlassUnlockPending, model.BreakGlassUnlockUnlocked}},
+	})
+
+	if err != nil {
+		b.logger.WithContext(ctx).WithError(err).Error("error while checking open break-glass unlocks")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if count > 0 {
+		err = errors.ErrBreakGlassUnlockOpen
+		return
+	}
+
+	custodianIds := make([]string, 0, len(docVault.Custodians))
+
+	for _, custodian := range docVault.Custodians {
+		custodianIds = append(custodianIds, custodian.UserID)
+	}
+
+	docUnlock := &doc.BreakGlassUnlock{
+		VaultID:            docVault.ID,
+		OrganizationID:     docVault.OrganizationID,
+		RequestedBy:        userId.String(),
+		Reason:             start.Reason,
+		RecipientPublicKey: start.RecipientPublicKey,
+		Threshold:          docVault.Threshold,
+		Custodians:         custodianIds,
+		Status:             model.BreakGlassUnlockPending,
+		Submissions:        []doc.BreakGlassSubmission{},
+		ExpiresAt:          time.Now().Add(UnlockTTL),
+	}
+
+	err = mgm.Coll(docUnlock).CreateWithCtx(ctx, docUnlock)
+
+	if err != nil {
+		// The unique index on pending unlocks catches a concurrent start.
+		if mongo.IsDuplicateKeyError(err) {
+			err = errors.ErrBreakGlassUnlockOpen
+			return
+		}
+		b.logger.WithContext(ctx).WithError(err).Error("error while creating break-glass unlock")
+		err = errors.ErrUnknown
+		return
+	}
+
+	b.auditSvc.Record(ctx, model.AuditEvent{
+		OrganizationID: docUnlock.OrganizationID,
+		ActorID:        userId,
+		Action:         model.AuditBreakGlassUnlockStarted,
+		TargetType:     model.AuditTargetBreakGlassUnlock,
+		TargetID:       docUnlock.ID.Hex(),
+		Data: map[string]string{
+			"vaultId": docVault.ID.Hex(),
+			"reason":  start.Reason,
+		},
+	})
+
+	b.alertAdmins(ctx, docVault, docUnlock)
+
+	unlock = b.MapDocToUnlock(docUnlock)
+
+	return
+}
+
+// alertAdmins tells every admin and custodian that an unlock of the vault started.
+func (b *BreakGlassSVC) alertAdmins(ctx context.Context, docVault *doc.BreakGlassVault, docUnlock *doc.BreakGlassUnlock) {
+	adminIds, err := b.userSvc.GetOrganizationAdminIDs(ctx, model.OrganizationID(docVault.OrganizationID))
+
+	if err != nil {
+		adminIds = nil
+	}
+
+	for _, custodian := range docVault.Custodians {
+		adminIds = append(adminIds, model.UserID(custodian.UserID))
+	}
+
+	notified := map[model.UserID]bool{}
+
+	for _, adminId := range adminIds {
+		if notified[adminId] {
+			continue
+		}
+
+		notified[adminId] = true
+
+		b.notificationSvc.Notify(ctx, adminId, model.NotificationBreakGlassUnlockStarted, "An emergency unlock of a break-glass vault was started", map[string]string{
+			"vaultId":     docVault.ID.Hex(),
+			"unlockId":    docUnlock.ID.Hex(),
+			"requestedBy": docUnlock.RequestedBy,
+			"reason":      docUnlock.Reason,
+			"custodian":   strconv.FormatBool(isCustodian(docVault, adminId)),
+		})
+	}
+}
+
+func (b *BreakGlassSVC) GetUnlock(ctx context.Context, unlockId model.BreakGlassUnlockID, userId model.UserID) (unlock model.BreakGlassUnlock, err error) {
+	docUnlock, err := b.getUnlock(ctx, unlockId)
+
+	if err != nil {
+		return
+	}
+
+	if !b.canView(ctx, docUnlock, userId) {
+		err = errors.ErrBreakGlassAccessDenied
+		return
+	}
+
+	unlock = b.MapDocToUnlock(docUnlock)
+
+	return
+}
+
+// GetUnlocksForOrganization lists the unlocks of the organization. Only org admins can see them.
+func (b *BreakGlassSVC) GetUnlocksForOrganization(ctx context.Context, orgId model.OrganizationID, userId model.UserID, status string, params model.PaginationParams) ([]model.BreakGlassUnlock, model.PageInfo, error) {
+	if !b.userSvc.IsOrganizationAdmin(ctx, userId, orgId) {
+		return nil, model.PageInfo{}, errors.ErrBreakGlassAccessDenied
+	}
+
+	filter := bson.M{"organizationId": orgId.String()}
+
+	if status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := pagination.Find(ctx, mgm.Coll(&doc.BreakGlassUnlock{}), filter, params)
+
+	if err != nil {
+		b.logger.WithContext(ctx).WithError(err).Error("error while fetching break-glass unlocks")
+		return nil, model.PageInfo{}, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	unlocks := make([]model.BreakGlassUnlock, 0)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.BreakGlassUnlock
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			b.logger.WithContext(ctx).WithError(err).Error("error while decoding break-glass unlock doc")
+			continue
+		}
+
+		unlocks = append(unlocks, b.MapDocToUnlock(&curDoc))
+	}
+
+	return unlocks, cursor.Info(), nil
+}
+
+// SubmitShare records the share of a custodian. The unlock opens once the threshold of the vault is reached.
+func (b *BreakGlassSVC) SubmitShare(ctx context.Context, unlockId model.BreakGlassUnlockID, userId model.UserID, submission model.BreakGlassShareSubmission) (unlock model.BreakGlassUnlock, err error) {
+	if submission.EncryptedShare == "" {
+		err = errors.ErrInvalidBreakGlassShare
+		return
+	}
+
+	docUnlock, err := b.getUnlock(ctx, unlockId)
+
+	if err != nil {
+		return
+	}
+
+	if !isUnlockCustodian(docUnlock, userId) {
+		err = errors.ErrBreakGlassAccessDenied
+		return
+	}
+
+	now := time.Now()
+
+	filter := bson.M{
+		"_id":                docUnlock.ID,
+		"status":             model.BreakGlassUnlockPending,
+		"expiresAt":          bson.M{"$gt": now},
+		"submissions.userId": bson.M{"$ne": userId.String()},
+	}
+
+	update := bson.M{
+		"$set": bson.M{doc.UpdatedAtField: now},
+		"$push": bson.M{"submissions": doc.BreakGlassSubmission{
+			UserID:         userId.String(),
+			SubmittedAt:    now,
+			EncryptedShare: submission.EncryptedShare,
+		}},
+	}
+
+	res, err := mgm.Coll(docUnlock).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		b.logger.WithContext(ctx).WithField("unlockId", unlockId.String()).WithError(err).Error("error while submitting break-glass share")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		err = errors.ErrBreakGlassUnlockClosed
+
+		if docUnlock.Status == model.BreakGlassUnlockPending && docUnlock.ExpiresAt.After(now) {
+			err = errors.ErrBreakGlassShareSubmitted
+		}
+		return
+	}
+
+	b.auditSvc.Record(ctx, model.AuditEvent{
+		OrganizationID: docUnlock.OrganizationID,
+		ActorID:        userId,
+		Action:         model.AuditBreakGlassShareSubmitted,
+		TargetType:     model.AuditTargetBreakGlassUnlock,
+		TargetID:       docUnlock.ID.Hex(),
+		Data: map[string]string{
+			"vaultId": docUnlock.VaultID.Hex(),
+		},
+	})
+
+	err = b.openOnQuorum(ctx, docUnlock)
+
+	if err != nil {
+		return
+	}
+
+	return b.GetUnlock(ctx, unlockId, userId)
+}
+
+// openOnQuorum moves the unlock to unlocked once enough shares were submitted. Only one submitter wins the move.
+func (b *BreakGlassSVC) openOnQuorum(ctx context.Context, docUnlock *doc.BreakGlassUnlock) error {
+	now := time.Now()
+
+	filter := bson.M{
+		"_id":    docUnlock.ID,
+		"status": model.BreakGlassUnlockPending,
+		"$expr":  bson.M{"$gte": bson.A{bson.M{"$size": "$submissions"}, "$threshold"}},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":           model.BreakGlassUnlockUnlocked,
+			"unlockedAt":       now,
+			"expiresAt":        now.Add(CollectionWindow),
+			doc.UpdatedAtField: now,
+		},
+	}
+
+	res, err := mgm.Coll(docUnlock).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		b.logger.WithContext(ctx).WithField("unlockId", docUnlock.ID.Hex()).WithError(err).Error("error while opening break-glass unlock")
+		return errors.ErrUnknown
+	}
+
+	if res.ModifiedCount == 0 {
+		return nil
+	}
+
+	b.auditSvc.Record(ctx, model.AuditEvent{
+		OrganizationID: docUnlock.OrganizationID,
+		Action:         model.AuditBreakGlassUnlocked,
+		TargetType:     model.AuditTargetBreakGlassUnlock,
+		TargetID:       docUnlock.ID.Hex(),
+		Data: map[string]string{
+			"vaultId": docUnlock.VaultID.Hex(),
+		},
+	})
+
+	b.notificationSvc.Notify(ctx, model.UserID(docUnlock.RequestedBy), model.NotificationBreakGlassUnlocked, "The break-glass vault reached its quorum and can be opened", map[string]string{
+		"vaultId":  docUnlock.VaultID.Hex(),
+		"unlockId": docUnlock.ID.Hex(),
+	})
+
+	return nil
+}
+
+// CollectShares hands the submitted shares and the sealed payload to the requester of an unlocked vault.
+func (b *BreakGlassSVC) CollectShares(ctx context.Context, unlockId model.BreakGlassUnlockID, userId model.UserID) (material model.BreakGlassMaterial, err error) {
+	docUnlock, err := b.getUnlock(ctx, unlockId)
+
+	if err != nil {
+		return
+	}
+
+	if docUnlock.RequestedBy != userId.String() {
+		err = errors.ErrBreakGlassAccessDenied
+		return
+	}
+
+	if docUnlock.Status == model.BreakGlassUnlockPending && docUnlock.ExpiresAt.After(time.Now()) {
+		err = errors.ErrBreakGlassNotUnlocked
+		return
+	}
+
+	if docUnlock.Status != model.BreakGlassUnlockUnlocked || !docUnlock.ExpiresAt.After(time.Now()) {
+		err = errors.ErrBreakGlassUnlockClosed
+		return
+	}
+
+	docVault, err := b.getVault(ctx, model.BreakGlassVaultID(docUnlock.VaultID.Hex()))
+
+	if err != nil {
+		return
+	}
+
+	material = model.BreakGlassMaterial{
+		UnlockID:         model.BreakGlassUnlockID(docUnlock.ID.Hex()),
+		VaultID:          model.BreakGlassVaultID(docVault.ID.Hex()),
+		EncryptedPayload: docVault.EncryptedPayload,
+		Shares:           []model.BreakGlassSubmission{},
+	}
+
+	for _, submission := range docUnlock.Submissions {
+		material.Shares = append(material.Shares, model.BreakGlassSubmission{
+			UserID:         model.UserID(submission.UserID),
+			SubmittedAt:    submission.SubmittedAt,
+			EncryptedShare: submission.EncryptedShare,
+		})
+	}
+
+	b.auditSvc.Record(ctx, model.AuditEvent{
+		OrganizationID: docUnlock.OrganizationID,
+		ActorID:        userId,
+		Action:         model.AuditBreakGlassSharesCollected,
+		TargetType:     model.AuditTargetBreakGlassUnlock,
+		TargetID:       docUnlock.ID.Hex(),
+		Data: map[string]string{
+			"vaultId": docVault.ID.Hex(),
+			"shares":  strconv.Itoa(len(material.Shares)),
+		},
+	})
+
+	return
+}
+
+// CancelUnlock closes an open unlock and drops the submitted shares. The requester and org admins can cancel it.
+func (b *BreakGlassSVC) CancelUnlock(ctx context.Context, unlockId model.BreakGlassUnlockID, userId model.UserID) (unlock model.BreakGlassUnlock, err error) {
+	docUnlock, err := b.getUnlock(ctx, unlockId)
+
+	if err != nil {
+		return
+	}
+
+	if docUnlock.RequestedBy != userId.String() && !b.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(docUnlock.OrganizationID)) {
+		err = errors.ErrBreakGlassAccessDenied
+		return
+	}
+
+	err = b.close(ctx, docUnlock, model.BreakGlassUnlockCancelled, userId, model.AuditBreakGlassUnlockCancelled)
+
+	if err != nil {
+		return
+	}
+
+	return b.GetUnlock(ctx, unlockId, userId)
+}
+
+// ExpireUnlocks closes the unlocks which did not reach the quorum in time and the ones whose collection window ended.
+func (b *BreakGlassSVC) ExpireUnlocks(ctx context.Context) {
+	b.closeMatching(ctx, bson.M{"expiresAt": bson.M{"$lte": time.Now()}}, model.BreakGlassUnlockExpired, "", model.AuditBreakGlassUnlockExpired)
+}
+
+func (b *BreakGlassSVC) closeMatching(ctx context.Context, filter bson.M, to string, userId model.UserID, action string) {
+	filter["status"] = bson.M{"$in": bson.A{model.BreakGlassUnlockPending, model.BreakGlassUnlockUnlocked}}
+
+	cursor, err := mgm.Coll(&doc.BreakGlassUnlock{}).Find(ctx, filter, options.Find().SetProjection(bson.M{"submissions": 0}))
+
+	if err != nil {
+		b.logger.WithContext(ctx).WithError(err).Error("error while fetching break-glass unlocks to close")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.BreakGlassUnlock
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			b.logger.WithContext(ctx).WithError(err).Error("error while decoding break-glass unlock doc")
+			continue
+		}
+
+		err = b.close(ctx, &curDoc, to, userId, action)
+
+		if err != nil && err != errors.ErrBreakGlassUnlockClosed {
+			b.logger.WithContext(ctx).WithField("unlockId", curDoc.ID.Hex()).WithError(err).Error("error while closing break-glass unlock")
+		}
+	}
+}
+
+// close ends an open unlock and wipes the submitted shares so they cannot be collected anymore.
+func (b *BreakGlassSVC) close(ctx context.Context, docUnlock *doc.BreakGlassUnlock, to string, userId model.UserID, action string) error {
+	filter := bson.M{
+		"_id":    docUnlock.ID,
+		"status": bson.M{"$in": bson.A{model.BreakGlassUnlockPending, model.BreakGlassUnlockUnlocked}},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":           to,
+			doc.UpdatedAtField: time.Now(),
+		},
+		"$unset": bson.M{"submissions.$[].encryptedShare": ""},
+	}
+
+	res, err := mgm.Coll(docUnlock).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		b.logger.WithContext(ctx).WithField("unlockId", docUnlock.ID.Hex()).WithError(err).Error("error while closing break-glass unlock")
+		return errors.ErrUnknown
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.ErrBreakGlassUnlockClosed
+	}
+
+	b.auditSvc.Record(ctx, model.AuditEvent{
+		OrganizationID: docUnlock.OrganizationID,
+		ActorID:        userId,
+		Action:         action,
+		TargetType:     model.AuditTargetBreakGlassUnlock,
+		TargetID:       docUnlock.ID.Hex(),
+		Data: map[string]string{
+			"vaultId": docUnlock.VaultID.Hex(),
+			"from":    docUnlock.Status,
+		},
+	})
+
+	return nil
+}
+
+// canView reports if the user can see the unlock, which the requester, the custodians and org admins can.
+func (b *BreakGlassSVC) canView(ctx context.Context, docUnlock *doc.BreakGlassUnlock, userId model.UserID) bool {
+	if userId == "" {
+		return false
+	}
+
+	if docUnlock.RequestedBy == userId.String() || isUnlockCustodian(docUnlock, userId) {
+		return true
+	}
+
+	return b.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(docUnlock.OrganizationID))
+}
+
+func isCustodian(docVault *doc.BreakGlassVault, userId model.UserID) bool {
+	for _, custodian := range docVault.Custodians {
+		if custodian.UserID == userId.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isUnlockCustodian(docUnlock *doc.BreakGlassUnlock, userId model.UserID) bool {
+	for _, custodianId := range docUnlock.Custodians {
+		if custodianId == userId.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *BreakGlassSVC) getVault(ctx context.Context, vaultId model.BreakGlassVaultID) (*doc.BreakGlassVault, error) {
+	objId, err := primitive.ObjectIDFromHex(vaultId.String())
+
+	if err != nil {
+		b.logger.WithContext(ctx).WithError(err).Error("invalid break-glass vault id found")
+		return nil, errors.ErrInvalidID
+	}
+
+	docVault := &doc.BreakGlassVault{}
+
+	err = mgm.Coll(docVault).FindByID(objId, docVault)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrBreakGlassVaultNotFound
+		}
+		b.logger.WithContext(ctx).WithError(err).Error("error while fetching break-glass vault")
+		return nil, errors.ErrUnknown
+	}
+
+	return docVault, nil
+}
+
+func (b *BreakGlassSVC) getUnlock(ctx context.Context, unlockId model.BreakGlassUnlockID) (*doc.BreakGlassUnlock, error) {
+	objId, err := primitive.ObjectIDFromHex(unlockId.String())
+
+	if err != nil {
+		b.logger.WithContext(ctx).WithError(err).Error("invalid break-glass unlock id found")
+		return nil, errors.ErrInvalidID
+	}
+
+	docUnlock := &doc.BreakGlassUnlock{}
+
+	err = mgm.Coll(docUnlock).FindByID(objId, docUnlock)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrBreakGlassUnlockNotFound
+		}
+		b.logger.WithContext(ctx).WithError(err).Error("error while fetching break-glass unlock")
+		return nil, errors.ErrUnknown
+	}
+
+	return docUnlock, nil
+}
+
+// MapDocToVault maps the vault for the viewer. Only the share of the viewer is included, the payload is only handed out by CollectShares.
+func (b *BreakGlassSVC) MapDocToVault(docVault *doc.BreakGlassVault, viewerId model.UserID) model.BreakGlassVault {
+	vault := model.BreakGlassVault{
+		ID:             model.BreakGlassVaultID(docVault.ID.Hex()),
+		CreatedAt:      docVault.CreatedAt,
+		UpdatedAt:      docVault.UpdatedAt,
+		OrganizationID: docVault.OrganizationID,
+		Name:           docVault.Name,
+		Description:    docVault.Description,
+		Threshold:      docVault.Threshold,
+		Custodians:     []model.BreakGlassCustodian{},
+		CreatedBy:      model.UserID(docVault.CreatedBy),
+	}
+
+	for _, custodian := range docVault.Custodians {
+		entry := model.BreakGlassCustodian{UserID: model.UserID(custodian.UserID)}
+
+		if custodian.UserID == viewerId.String() {
+			entry.EncryptedShare = custodian.EncryptedShare
+		}
+
+		vault.Custodians = append(vault.Custodians, entry)
+	}
+
+	return vault
+}
+
+// MapDocToUnlock maps the unlock without the submitted shares.
+func (b *BreakGlassSVC) MapDocToUnlock(docUnlock *doc.BreakGlassUnlock) model.BreakGlassUnlock {
+	unlock := model.BreakGlassUnlock{
+		ID:                 model.BreakGlassUnlockID(docUnlock.ID.Hex()),
+		CreatedAt:          docUnlock.CreatedAt,
+		UpdatedAt:          docUnlock.UpdatedAt,
+		VaultID:            model.BreakGlassVaultID(docUnlock.VaultID.Hex()),
+		OrganizationID:     docUnlock.OrganizationID,
+		RequestedBy:        model.UserID(docUnlock.RequestedBy),
+		Reason:             docUnlock.Reason,
+		RecipientPublicKey: docUnlock.RecipientPublicKey,
+		Threshold:          docUnlock.Threshold,
+		Status:             docUnlock.Status,
+		Submissions:        []model.BreakGlassSubmission{},
+		ExpiresAt:          docUnlock.ExpiresAt,
+		UnlockedAt:         docUnlock.UnlockedAt,
+	}
+
+	for _, submission := range docUnlock.Submissions {
+		unlock.Submissions = append(unlock.Submissions, model.BreakGlassSubmission{
+			UserID:      model.UserID(submission.UserID),
+			SubmittedAt: submission.SubmittedAt,
+		})
+	}
+
+	return unlock
+}
@@ -0,0 +1,113 @@
+package certificate
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"strings"
+)
+
+const (
+	// MaxChainLength bounds the PEM chain, it is stored in plain text next to every copy of the secret.
+	MaxChainLength = 64 * 1024
+	// MaxChainCertificates bounds the certificates of a chain.
+	MaxChainCertificates = 10
+)
+
+// ParseChain reads a PEM chain of certificates, leaf first, and returns it re-encoded with what the
+// leaf tells about itself. Anything but certificates is rejected so no private key ends up stored in
+// plain text by mistake.
+func ParseChain(raw string) (chain string, info model.CertificateInfo, err error) {
+	if len(raw) > MaxChainLength {
+		err = errors.ErrInvalidCertificateChain
+		return
+	}
+
+	rest := []byte(raw)
+	certs := []*x509.Certificate{}
+	encoded := strings.Builder{}
+
+	for {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" || len(certs) == MaxChainCertificates {
+			err = errors.ErrInvalidCertificateChain
+			return
+		}
+
+		cert, parseErr := x509.ParseCertificate(block.Bytes)
+
+		if parseErr != nil {
+			err = errors.ErrInvalidCertificateChain
+			return
+		}
+
+		certs = append(certs, cert)
+		encoded.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: block.Bytes}))
+	}
+
+	if len(certs) == 0 || strings.TrimSpace(string(rest)) != "" {
+		err = errors.ErrInvalidCertificateChain
+		return
+	}
+
+	leaf := certs[0]
+
+	sans := []string{}
+	sans = append(sans, leaf.DNSNames...)
+
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	sans = append(sans, leaf.EmailAddresses...)
+
+	for _, uri := range leaf.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	info = model.CertificateInfo{
+		Subject:      leaf.Subject.String(),
+		Issuer:       leaf.Issuer.String(),
+		SerialNumber: colonHex(leaf.SerialNumber.Bytes()),
+		SANs:         sans,
+		NotBefore:    leaf.NotBefore.UTC(),
+		NotAfter:     leaf.NotAfter.UTC(),
+		Fingerprint:  Fingerprint(leaf.Raw),
+		ChainLength:  len(certs),
+	}
+
+	chain = encoded.String()
+
+	return
+}
+
+// Fingerprint is the SHA-256 digest of the DER encoded certificate as colon separated hex.
+func Fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+
+	return colonHex(sum[:])
+}
+
+func colonHex(data []byte) string {
+	if len(data) == 0 {
+		return "00"
+	}
+
+	parts := make([]string, len(data))
+
+	for i, b := range data {
+		parts[i] = strings.ToUpper(hex.EncodeToString([]byte{b}))
+	}
+
+	return strings.Join(parts, ":")
+}
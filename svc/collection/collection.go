@@ -0,0 +1,965 @@
+package collection
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/team"
+	"secaas_backend/svc/user"
+	"strings"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PathSeparator separates the collection names in a path like prod/payments.
+const (
+	PathSeparator = "/"
+	// MaxRotationIntervalDays is the longest rotation interval a policy can set.
+	MaxRotationIntervalDays = 3650
+)
+
+type CollectionSVC struct {
+	logger      *logrus.Logger
+	userSvc     *user.UserSVC
+	teamSvc     *team.TeamSVC
+	policyHooks []PolicyHook
+	grantHooks  []GrantHook
+}
+
+// PolicyHook is called with the root of a collection subtree whose inherited policies may have changed.
+type PolicyHook func(ctx context.Context, collectionId model.CollectionID)
+
+// GrantHook is called with the root of a collection subtree whose grants may have changed and
+// the users who could read it through a grant before the change.
+type GrantHook func(ctx context.Context, collectionId model.CollectionID, previous []model.UserID)
+
+func New(logger *logrus.Logger, userSvc *user.UserSVC, teamSvc *team.TeamSVC) *CollectionSVC {
+	c := &CollectionSVC{logger: logger, userSvc: userSvc, teamSvc: teamSvc}
+	return c
+}
+
+// RegisterPolicyHook adds a hook which refreshes the data other services derive from collection policies.
+func (c *CollectionSVC) RegisterPolicyHook(hook PolicyHook) {
+	c.policyHooks = append(c.policyHooks, hook)
+}
+
+// RegisterGrantHook adds a hook which follows the users who gain or lose access through collection grants.
+func (c *CollectionSVC) RegisterGrantHook(hook GrantHook) {
+	c.grantHooks = append(c.grantHooks, hook)
+}
+
+// Create adds a collection at the root of the organization or under a parent collection.
+// Root collections can only be created by org admins, nested ones need the editor role on the parent.
+func (c *CollectionSVC) Create(ctx context.Context, data model.Collection, userId model.UserID) (collection model.Collection, err error) {
+	if data.Name == "" || strings.Contains(data.Name, PathSeparator) {
+		err = errors.ErrInvalidCollectionName
+		return
+	}
+
+	err = c.validateGrants(ctx, data.OrganizationID, data.Grants)
+
+	if err != nil {
+		return
+	}
+
+	docCollection := &doc.Collection{
+		OrganizationID: data.OrganizationID,
+		Name:           data.Name,
+		Ancestors:      []primitive.ObjectID{},
+		Path:           data.Name,
+		Grants:         mapGrantsToDoc(data.Grants),
+	}
+
+	if data.ParentID != nil {
+		parent, parentErr := c.getDoc(ctx, *data.ParentID)
+
+		if parentErr != nil {
+			err = parentErr
+			return
+		}
+
+		role, roleErr := c.effectiveRole(ctx, parent, userId)
+
+		if roleErr != nil {
+			err = roleErr
+			return
+		}
+
+		if model.SecretRoleRank(role) < model.SecretRoleRank(model.SecretRoleEditor) {
+			err = errors.ErrCollectionAccessDenied
+			return
+		}
+
+		parentId := parent.ID
+		docCollection.OrganizationID = parent.OrganizationID
+		docCollection.ParentID = &parentId
+		docCollection.Ancestors = append(append(docCollection.Ancestors, parent.Ancestors...), parent.ID)
+		docCollection.Path = parent.Path + PathSeparator + data.Name
+	} else if !c.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(data.OrganizationID)) {
+		err = errors.ErrCollectionAccessDenied
+		return
+	}
+
+	err = c.ensurePathFree(ctx, docCollection.OrganizationID, docCollection.Path)
+
+	if err != nil {
+		return
+	}
+
+	err = mgm.Coll(docCollection).CreateWithCtx(ctx, docCollection)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while creating collection")
+		err = errors.ErrUnknown
+		return
+	}
+
+	collection = c.MapDocToCollection(docCollection)
+
+	return
+}
+
+func (c *CollectionSVC) GetByID(ctx context.Context, collectionId model.CollectionID) (collection model.Collection, err error) {
+	docCollection, err := c.getDoc(ctx, collectionId)
+
+	if err != nil {
+		return
+	}
+
+	collection = c.MapDocToCollection(docCollection)
+
+	return
+}
+
+func (c *CollectionSVC) GetByPath(ctx context.Context, orgId model.OrganizationID, path string) (collection model.Collection, err error) {
+	docCollection := &doc.Collection{}
+
+	filter := bson.M{
+		"organizationId": orgId.String(),
+		"path":           strings.Trim(path, PathSeparator),
+	}
+
+	err = mgm.Coll(docCollection).First(filter, docCollection)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			err = errors.ErrCollectionNotFound
+			return
+		}
+		c.logger.WithContext(ctx).WithError(err).Error("error while fetching collection by path")
+		err = errors.ErrUnknown
+		return
+	}
+
+	collection = c.MapDocToCollection(docCollection)
+
+	return
+}
+
+// GetForOrganization lists all the collections of the organization ordered by path.
+func (c *CollectionSVC) GetForOrganization(ctx context.Context, orgId model.OrganizationID, userId model.UserID) ([]model.Collection, error) {
+	_, err := c.userSvc.GetOrganizationMembership(ctx, userId, orgId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"organizationId": orgId.String(),
+	}
+
+	findOptions := options.Find().SetSort(bson.D{
+		{Key: "path", Value: 1},
+	})
+
+	cursor, err := mgm.Coll(&doc.Collection{}).Find(ctx, filter, findOptions)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while fetching collections for organization")
+		return nil, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	collections := make([]model.Collection, 0)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Collection
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			c.logger.WithContext(ctx).WithError(err).Error("error while decoding collection doc")
+			continue
+		}
+
+		collections = append(collections, c.MapDocToCollection(&curDoc))
+	}
+
+	return collections, nil
+}
+
+// GetDescendantIDs lists the ids of every collection nested under the collection.
+func (c *CollectionSVC) GetDescendantIDs(ctx context.Context, collectionId model.CollectionID) ([]primitive.ObjectID, error) {
+	objId, err := primitive.ObjectIDFromHex(collectionId.String())
+
+	if err != nil {
+		return nil, errors.ErrInvalidID
+	}
+
+	ids, err := mgm.Coll(&doc.Collection{}).Distinct(ctx, "_id", bson.M{"ancestors": objId})
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while fetching descendant collections")
+		return nil, errors.ErrUnknown
+	}
+
+	descendants := []primitive.ObjectID{}
+
+	for _, id := range ids {
+		if descendantId, ok := id.(primitive.ObjectID); ok {
+			descendants = append(descendants, descendantId)
+		}
+	}
+
+	return descendants, nil
+}
+
+func (c *CollectionSVC) SetGrants(ctx context.Context, collectionId model.CollectionID, userId model.UserID, grants []model.CollectionGrant) (collection model.Collection, err error) {
+	docCollection, err := c.getManagedDoc(ctx, collectionId, userId)
+
+	if err != nil {
+		return
+	}
+
+	err = c.validateGrants(ctx, docCollection.OrganizationID, grants)
+
+	if err != nil {
+		return
+	}
+
+	previous := c.grantees(ctx, docCollection)
+
+	docCollection.Grants = mapGrantsToDoc(grants)
+
+	err = mgm.Coll(docCollection).UpdateWithCtx(ctx, docCollection)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while updating collection grants")
+		err = errors.ErrUnknown
+		return
+	}
+
+	for _, hook := range c.grantHooks {
+		hook(ctx, collectionId, previous)
+	}
+
+	collection = c.MapDocToCollection(docCollection)
+
+	return
+}
+
+// Move places the collection under a new parent, or at the root when no parent is given.
+// Paths and ancestors of all nested collections are rewritten.
+func (c *CollectionSVC) Move(ctx context.Context, collectionId model.CollectionID, userId model.UserID, parentId *model.CollectionID) (collection model.Collection, err error) {
+	docCollection, err := c.getManagedDoc(ctx, collectionId, userId)
+
+	if err != nil {
+		return
+	}
+
+	oldPath := docCollection.Path
+	oldAncestorCount := len(docCollection.Ancestors)
+	// The collection inherits the grants of its new ancestors instead of the old ones.
+	previous := c.grantees(ctx, docCollection)
+
+	if parentId != nil {
+		parent, parentErr := c.getDoc(ctx, *parentId)
+
+		if parentErr != nil {
+			err = parentErr
+			return
+		}
+
+		if parent.OrganizationID != docCollection.OrganizationID {
+			err = errors.ErrOrganizationMismatch
+			return
+		}
+
+		if parent.ID == docCollection.ID {
+			err = errors.ErrInvalidCollectionMove
+			return
+		}
+
+		for _, ancestor := range parent.Ancestors {
+			if ancestor == docCollection.ID {
+				err = errors.ErrInvalidCollectionMove
+				return
+			}
+		}
+
+		role, roleErr := c.effectiveRole(ctx, parent, userId)
+
+		if roleErr != nil {
+			err = roleErr
+			return
+		}
+
+		if model.SecretRoleRank(role) < model.SecretRoleRank(model.SecretRoleEditor) {
+			err = errors.ErrCollectionAccessDenied
+			return
+		}
+
+		newParentId := parent.ID
+		docCollection.ParentID = &newParentId
+		docCollection.Ancestors = append(append([]primitive.ObjectID{}, parent.Ancestors...), parent.ID)
+		docCollection.Path = parent.Path + PathSeparator + docCollection.Name
+	} else {
+		if !c.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(docCollection.OrganizationID)) {
+			err = errors.ErrCollectionAccessDenied
+			return
+		}
+
+		docCollection.ParentID = nil
+		docCollection.Ancestors = []primitive.ObjectID{}
+		docCollection.Path = docCollection.Name
+	}
+
+	if docCollection.Path != oldPath {
+		err = c.ensurePathFree(ctx, docCollection.OrganizationID, docCollection.Path)
+
+		if err != nil {
+			return
+		}
+	}
+
+	err = mgm.Coll(docCollection).UpdateWithCtx(ctx, docCollection)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while moving collection")
+		err = errors.ErrUnknown
+		return
+	}
+
+	cursor, err := mgm.Coll(docCollection).Find(ctx, bson.M{"ancestors": docCollection.ID})
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while fetching nested collections to move")
+		err = errors.ErrUnknown
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Collection
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			c.logger.WithContext(ctx).WithError(err).Error("error while decoding nested collection doc")
+			err = nil
+			continue
+		}
+
+		curDoc.Ancestors = append(append([]primitive.ObjectID{}, docCollection.Ancestors...), curDoc.Ancestors[oldAncestorCount:]...)
+		curDoc.Path = docCollection.Path + strings.TrimPrefix(curDoc.Path, oldPath)
+
+		err = mgm.Coll(&curDoc).UpdateWithCtx(ctx, &curDoc)
+
+		if err != nil {
+			c.logger.WithContext(ctx).WithField("collectionId", curDoc.ID.Hex()).WithError(err).Error("error while moving nested collection")
+			err = nil
+		}
+	}
+
+	for _, hook := range c.policyHooks {
+		hook(ctx, collectionId)
+	}
+
+	for _, hook := range c.grantHooks {
+		hook(ctx, collectionId, previous)
+	}
+
+	collection = c.MapDocToCollection(docCollection)
+
+	return
+}
+
+// SetRotationPolicy sets the rotation interval inherited by the secrets of the collection and its nested collections
+// which do not set their own.
+func (c *CollectionSVC) SetRotationPolicy(ctx context.Context, collectionId model.CollectionID, userId model.UserID, policy model.RotationPolicy) (collection model.Collection, err error) {
+	if policy.IntervalDays < 0 || policy.IntervalDays > MaxRotationIntervalDays {
+		err = errors.ErrInvalidRotationPolicy
+		return
+	}
+
+	docCollection, err := c.getManagedDoc(ctx, collectionId, userId)
+
+	if err != nil {
+		return
+	}
+
+	docCollection.RotationIntervalDays = policy.IntervalDays
+
+	err = mgm.Coll(docCollection).UpdateWithCtx(ctx, docCollection)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while updating collection rotation policy")
+		err = errors.ErrUnknown
+		return
+	}
+
+	for _, hook := range c.policyHooks {
+		hook(ctx, collectionId)
+	}
+
+	collection = c.MapDocToCollection(docCollection)
+
+	return
+}
+
+// RotationIntervalDays returns the rotation interval of the collection, or of its nearest ancestor which sets one.
+func (c *CollectionSVC) RotationIntervalDays(ctx context.Context, collectionId model.CollectionID) (int, error) {
+	docCollection, err := c.getDoc(ctx, collectionId)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if docCollection.RotationIntervalDays > 0 || len(docCollection.Ancestors) == 0 {
+		return docCollection.RotationIntervalDays, nil
+	}
+
+	cursor, err := mgm.Coll(docCollection).Find(ctx, bson.M{
+		"_id":                  bson.M{"$in": docCollection.Ancestors},
+		"rotationIntervalDays": bson.M{"$gt": 0},
+	})
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while fetching collection ancestors")
+		return 0, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	intervals := map[primitive.ObjectID]int{}
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Collection
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			c.logger.WithContext(ctx).WithError(err).Error("error while decoding collection doc")
+			continue
+		}
+
+		intervals[curDoc.ID] = curDoc.RotationIntervalDays
+	}
+
+	// Ancestors are stored from the root down, the nearest one wins.
+	for i := len(docCollection.Ancestors) - 1; i >= 0; i-- {
+		if days, ok := intervals[docCollection.Ancestors[i]]; ok {
+			return days, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// Delete removes an empty collection.
+func (c *CollectionSVC) Delete(ctx context.Context, collectionId model.CollectionID, userId model.UserID) (deleted int, err error) {
+	docCollection, err := c.getManagedDoc(ctx, collectionId, userId)
+
+	if err != nil {
+		return
+	}
+
+	children, err := mgm.Coll(docCollection).CountDocuments(ctx, bson.M{"parentId": docCollection.ID})
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while counting nested collections")
+		err = errors.ErrUnknown
+		return
+	}
+
+	secrets, err := mgm.Coll(&doc.Secret{}).CountDocuments(ctx, bson.M{"collectionId": docCollection.ID})
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while counting secrets in collection")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if children > 0 || secrets > 0 {
+		err = errors.ErrCollectionNotEmpty
+		return
+	}
+
+	res, err := mgm.Coll(docCollection).DeleteOne(ctx, bson.M{"_id": docCollection.ID})
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while deleting collection")
+		err = errors.ErrUnknown
+		return
+	}
+
+	deleted = int(res.DeletedCount)
+
+	return
+}
+
+// GetGrantedIDs lists the collections of the organization the user can read through a grant,
+// including all the collections nested in them.
+func (c *CollectionSVC) GetGrantedIDs(ctx context.Context, orgId model.OrganizationID, userId model.UserID) ([]primitive.ObjectID, error) {
+	teamIds, err := c.teamSvc.GetActiveTeamIDs(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"organizationId": orgId.String(),
+		"$or": bson.A{
+			bson.M{"grants.userId": userId.String()},
+			bson.M{"grants.teamId": bson.M{"$in": teamIds}},
+		},
+	}
+
+	ids, err := mgm.Coll(&doc.Collection{}).Distinct(ctx, "_id", filter)
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while fetching granted collections")
+		return nil, errors.ErrUnknown
+	}
+
+	granted := []primitive.ObjectID{}
+
+	for _, id := range ids {
+		if grantedId, ok := id.(primitive.ObjectID); ok {
+			granted = append(granted, grantedId)
+		}
+	}
+
+	if len(granted) == 0 {
+		return granted, nil
+	}
+
+	nested, err := mgm.Coll(&doc.Collection{}).Distinct(ctx, "_id", bson.M{"ancestors": bson.M{"$in": granted}})
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while fetching nested granted collections")
+		return nil, errors.ErrUnknown
+	}
+
+	for _, id := range nested {
+		if nestedId, ok := id.(primitive.ObjectID); ok {
+			granted = append(granted, nestedId)
+		}
+	}
+
+	return granted, nil
+}
+
+// GrantedRole returns the strongest role granted to the user, directly or through a team,
+// on the collection or any of its ancestors.
+func (c *CollectionSVC) GrantedRole(ctx context.Context, collectionId model.CollectionID, userId model.UserID) (string, error) {
+	docCollection, err := c.getDoc(ctx, collectionId)
+
+	if err != nil {
+		return "", err
+	}
+
+	return c.grantedRole(ctx, docCollection, userId)
+}
+
+// EffectiveRole is the granted role of the user, org admins always act as owners.
+func (c *CollectionSVC) EffectiveRole(ctx context.Context, collectionId model.CollectionID, userId model.UserID) (string, error) {
+	docCollection, err := c.getDoc(ctx, collectionId)
+
+	if err != nil {
+		return "", err
+	}
+
+	return c.effectiveRole(ctx, docCollection, userId)
+}
+
+func (c *CollectionSVC) effectiveRole(ctx context.Context, docCollection *doc.Collection, userId model.UserID) (string, error) {
+	if c.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(docCollection.OrganizationID)) {
+		return model.SecretRoleOwner, nil
+	}
+
+	return c.grantedRole(ctx, docCollection, userId)
+}
+
+func (c *CollectionSVC) grantedRole(ctx context.Context, docCollection *doc.Collection, userId model.UserID) (string, error) {
+	teamIds, err := c.teamSvc.GetActiveTeamIDs(ctx, userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	inTeam := map[string]bool{}
+
+	for _, teamId := range teamIds {
+		inTeam[teamId] = true
+	}
+
+	collections := []doc.Collection{*docCollection}
+
+	if len(docCollection.Ancestors) > 0 {
+		cursor, err := mgm.Coll(docCollection).Find(ctx, bson.M{"_id": bson.M{"$in": docCollection.Ancestors}})
+
+		if err != nil {
+			c.logger.WithContext(ctx).WithError(err).Error("error while fetching ancestor collections")
+			return "", errors.ErrUnknown
+		}
+
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			var curDoc doc.Collection
+
+			err = cursor.Decode(&curDoc)
+
+			if err != nil {
+				c.logger.WithContext(ctx).WithError(err).Error("error while decoding ancestor collection doc")
+				continue
+			}
+
+			collections = append(collections, curDoc)
+		}
+	}
+
+	return roleFromGrants(collections, userId, inTeam), nil
+}
+
+// GrantedRoles returns the role granted to the user on every one of the collections, like GrantedRole, with
+// the teams of the user and the ancestors of the collections looked up once. Unknown collections are left out.
+func (c *CollectionSVC) GrantedRoles(ctx context.Context, collectionIds []primitive.ObjectID, userId model.UserID) (map[primitive.ObjectID]string, error) {
+	roles := map[primitive.ObjectID]string{}
+
+	if len(collectionIds) == 0 {
+		return roles, nil
+	}
+
+	teamIds, err := c.teamSvc.GetActiveTeamIDs(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	inTeam := map[string]bool{}
+
+	for _, teamId := range teamIds {
+		inTeam[teamId] = true
+	}
+
+	requested, err := c.findByIDs(ctx, collectionIds)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ancestorIds := []primitive.ObjectID{}
+
+	for _, docCollection := range requested {
+		ancestorIds = append(ancestorIds, docCollection.Ancestors...)
+	}
+
+	ancestors, err := c.findByIDs(ctx, ancestorIds)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for id, docCollection := range requested {
+		collections := []doc.Collection{docCollection}
+
+		for _, ancestorId := range docCollection.Ancestors {
+			if ancestor, ok := ancestors[ancestorId]; ok {
+				collections = append(collections, ancestor)
+			}
+		}
+
+		roles[id] = roleFromGrants(collections, userId, inTeam)
+	}
+
+	return roles, nil
+}
+
+// findByIDs loads the collections with the ids, keyed by id.
+func (c *CollectionSVC) findByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]doc.Collection, error) {
+	found := map[primitive.ObjectID]doc.Collection{}
+
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	cursor, err := mgm.Coll(&doc.Collection{}).Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while fetching collections")
+		return nil, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Collection
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			c.logger.WithContext(ctx).WithError(err).Error("error while decoding collection doc")
+			continue
+		}
+
+		found[curDoc.ID] = curDoc
+	}
+
+	return found, nil
+}
+
+// roleFromGrants returns the strongest role the grants of the collections give to the user or their teams.
+func roleFromGrants(collections []doc.Collection, userId model.UserID, inTeam map[string]bool) string {
+	role := ""
+
+	for _, curCollection := range collections {
+		for _, grant := range curCollection.Grants {
+			matches := (grant.UserID != "" && grant.UserID == userId.String()) || (grant.TeamID != "" && inTeam[grant.TeamID])
+
+			if matches && model.SecretRoleRank(grant.Role) > model.SecretRoleRank(role) {
+				role = grant.Role
+			}
+		}
+	}
+
+	return role
+}
+
+// GranteeIDs lists the users granted access to the collection or any of its ancestors,
+// directly or as active members of a granted team.
+func (c *CollectionSVC) GranteeIDs(ctx context.Context, collectionId model.CollectionID) ([]model.UserID, error) {
+	docCollection, err := c.getDoc(ctx, collectionId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.grantees(ctx, docCollection), nil
+}
+
+func (c *CollectionSVC) grantees(ctx context.Context, docCollection *doc.Collection) []model.UserID {
+	grants := append([]doc.CollectionGrant{}, docCollection.Grants...)
+
+	if len(docCollection.Ancestors) > 0 {
+		cursor, err := mgm.Coll(docCollection).Find(ctx, bson.M{"_id": bson.M{"$in": docCollection.Ancestors}})
+
+		if err != nil {
+			c.logger.WithContext(ctx).WithError(err).Error("error while fetching ancestor collections")
+		} else {
+			defer cursor.Close(ctx)
+
+			for cursor.Next(ctx) {
+				var curDoc doc.Collection
+
+				err := cursor.Decode(&curDoc)
+
+				if err != nil {
+					c.logger.WithContext(ctx).WithError(err).Error("error while decoding ancestor collection doc")
+					continue
+				}
+
+				grants = append(grants, curDoc.Grants...)
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	userIds := []model.UserID{}
+
+	add := func(userId string) {
+		if userId != "" && !seen[userId] {
+			seen[userId] = true
+			userIds = append(userIds, model.UserID(userId))
+		}
+	}
+
+	for _, grant := range grants {
+		if grant.TeamID == "" {
+			add(grant.UserID)
+			continue
+		}
+
+		team, err := c.teamSvc.Lookup(ctx, model.TeamID(grant.TeamID))
+
+		if err != nil {
+			continue
+		}
+
+		for _, member := range team.Members {
+			if !member.Pending {
+				add(member.UserID.String())
+			}
+		}
+	}
+
+	return userIds
+}
+
+// getManagedDoc fetches the collection and checks that the user holds the owner role on it.
+func (c *CollectionSVC) getManagedDoc(ctx context.Context, collectionId model.CollectionID, userId model.UserID) (*doc.Collection, error) {
+	docCollection, err := c.getDoc(ctx, collectionId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := c.effectiveRole(ctx, docCollection, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if role != model.SecretRoleOwner {
+		return nil, errors.ErrCollectionAccessDenied
+	}
+
+	return docCollection, nil
+}
+
+func (c *CollectionSVC) ensurePathFree(ctx context.Context, orgId string, path string) error {
+	count, err := mgm.Coll(&doc.Collection{}).CountDocuments(ctx, bson.M{
+		"organizationId": orgId,
+		"path":           path,
+	})
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("error while checking collection path")
+		return errors.ErrUnknown
+	}
+
+	if count > 0 {
+		return errors.ErrCollectionExists
+	}
+
+	return nil
+}
+
+// validateGrants checks that every grant names one member or team of the organization with a role below owner.
+// Owners can delete and transfer secrets, so a grant never makes anyone an owner of what it covers.
+func (c *CollectionSVC) validateGrants(ctx context.Context, orgId string, grants []model.CollectionGrant) error {
+	for _, grant := range grants {
+		if (grant.UserID == "") == (grant.TeamID == "") {
+			return errors.ErrInvalidID
+		}
+
+		rank := model.SecretRoleRank(grant.Role)
+
+		if rank == 0 || rank >= model.SecretRoleRank(model.SecretRoleOwner) {
+			return errors.ErrInvalidSecretRole
+		}
+
+		if grant.UserID != "" {
+			_, err := c.userSvc.GetOrganizationMembership(ctx, model.UserID(grant.UserID), model.OrganizationID(orgId))
+
+			if err == errors.ErrNotOrganizationMember || err == errors.ErrInvalidID {
+				return errors.ErrInvalidCollectionGrant
+			}
+
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		team, err := c.teamSvc.Lookup(ctx, model.TeamID(grant.TeamID))
+
+		if err == errors.ErrTeamNotFound || err == errors.ErrInvalidID {
+			return errors.ErrInvalidCollectionGrant
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if team.OrganizationID != orgId {
+			return errors.ErrInvalidCollectionGrant
+		}
+	}
+
+	return nil
+}
+
+func (c *CollectionSVC) getDoc(ctx context.Context, collectionId model.CollectionID) (*doc.Collection, error) {
+	objId, err := primitive.ObjectIDFromHex(collectionId.String())
+
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Error("invalid collection id found")
+		return nil, errors.ErrInvalidID
+	}
+
+	docCollection := &doc.Collection{}
+
+	err = mgm.Coll(docCollection).FindByID(objId, docCollection)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrCollectionNotFound
+		}
+		c.logger.WithContext(ctx).WithError(err).Error("error while fetching collection")
+		return nil, errors.ErrUnknown
+	}
+
+	return docCollection, nil
+}
+
+func mapGrantsToDoc(grants []model.CollectionGrant) []doc.CollectionGrant {
+	docGrants := []doc.CollectionGrant{}
+
+	for _, grant := range grants {
+		docGrants = append(docGrants, doc.CollectionGrant{
+			UserID: grant.UserID.String(),
+			TeamID: grant.TeamID.String(),
+			Role:   grant.Role,
+		})
+	}
+
+	return docGrants
+}
+
+func (c *CollectionSVC) MapDocToCollection(docCollection *doc.Collection) model.Collection {
+	collection := model.Collection{
+		ID:                   model.CollectionID(docCollection.ID.Hex()),
+		CreatedAt:            docCollection.CreatedAt,
+		UpdatedAt:            docCollection.UpdatedAt,
+		OrganizationID:       docCollection.OrganizationID,
+		Name:                 docCollection.Name,
+		Path:                 docCollection.Path,
+		Grants:               []model.CollectionGrant{},
+		RotationIntervalDays: docCollection.RotationIntervalDays,
+	}
+
+	if docCollection.ParentID != nil {
+		parentId := model.CollectionID(docCollection.ParentID.Hex())
+		collection.ParentID = &parentId
+	}
+
+	for _, grant := range docCollection.Grants {
+		collection.Grants = append(collection.Grants, model.CollectionGrant{
+			UserID: model.UserID(grant.UserID),
+			TeamID: model.TeamID(grant.TeamID),
+			Role:   grant.Role,
+		})
+	}
+
+	return collection
+}
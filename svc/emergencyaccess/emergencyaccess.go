@@ -0,0 +1,566 @@
+package emergencyaccess
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/notification"
+	"secaas_backend/svc/pagination"
+	"secaas_backend/svc/secret"
+	"secaas_backend/svc/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// DefaultWaitDays is the waiting period when the grant does not set one.
+	DefaultWaitDays = 7
+	// MaxWaitDays is the longest waiting period a grant can set.
+	MaxWaitDays = 90
+	// ReminderBefore is how long before the automatic approval the grantor is reminded.
+	ReminderBefore = 24 * time.Hour
+	// ApprovalCheckInterval is how often the scheduler approves the requests whose wait ended.
+	ApprovalCheckInterval = time.Minute
+)
+
+type EmergencyAccessSVC struct {
+	logger          *logrus.Logger
+	userSvc         *user.UserSVC
+	secretSvc       *secret.SecretsSVC
+	notificationSvc *notification.NotificationSVC
+}
+
+func New(logger *logrus.Logger, userSvc *user.UserSVC, secretSvc *secret.SecretsSVC, notificationSvc *notification.NotificationSVC) *EmergencyAccessSVC {
+	e := &EmergencyAccessSVC{logger: logger, userSvc: userSvc, secretSvc: secretSvc, notificationSvc: notificationSvc}
+	return e
+}
+
+// Create names the grantee as trusted contact of the user. The key of the user must already be wrapped for the public key of the grantee.
+func (e *EmergencyAccessSVC) Create(ctx context.Context, userId model.UserID, data model.EmergencyAccess) (grant model.EmergencyAccess, err error) {
+	waitDays := data.WaitDays
+
+	if waitDays == 0 {
+		waitDays = DefaultWaitDays
+	}
+
+	if userId == "" || data.GranteeID == "" || data.GranteeID == userId || data.WrappedKey == "" || waitDays < 0 || waitDays > MaxWaitDays {
+		err = errors.ErrInvalidEmergencyAccess
+		return
+	}
+
+	grantee, err := e.userSvc.GetByID(ctx, data.GranteeID)
+
+	if err != nil {
+		return
+	}
+
+	if grantee.AsymmKey.Public == "" {
+		err = errors.ErrInvalidEmergencyAccess
+		return
+	}
+
+	count, err := mgm.Coll(&doc.EmergencyAccess{}).CountDocuments(ctx, bson.M{
+		"grantorId": userId.String(),
+		"granteeId": data.GranteeID.String(),
+		"status":    bson.M{"$ne": model.EmergencyAccessRevoked},
+	})
+
+	if err != nil {
+		e.logger.WithContext(ctx).WithError(err).Error("error while checking existing emergency access grants")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if count > 0 {
+		err = errors.ErrEmergencyAccessExists
+		return
+	}
+
+	docGrant := &doc.EmergencyAccess{
+		GrantorID:  userId.String(),
+		GranteeID:  data.GranteeID.String(),
+		WaitDays:   waitDays,
+		WrappedKey: data.WrappedKey,
+		Status:     model.EmergencyAccessGranted,
+		History: []doc.EmergencyAccessEvent{
+			{Status: model.EmergencyAccessGranted, At: time.Now(), By: userId.String()},
+		},
+	}
+
+	err = mgm.Coll(docGrant).CreateWithCtx(ctx, docGrant)
+
+	if err != nil {
+		e.logger.WithContext(ctx).WithError(err).Error("error while creating emergency access grant")
+		err = errors.ErrUnknown
+		return
+	}
+
+	e.notificationSvc.Notify(ctx, data.GranteeID, model.NotificationEmergencyAccessGranted, "You were named as an emergency contact", map[string]string{
+		"grantId":   docGrant.ID.Hex(),
+		"grantorId": userId.String(),
+		"waitDays":  strconv.Itoa(waitDays),
+	})
+
+	grant = e.MapDocToEmergencyAccess(docGrant)
+
+	return
+}
+
+func (e *EmergencyAccessSVC) GetByID(ctx context.Context, grantId model.EmergencyAccessID, userId model.UserID) (grant model.EmergencyAccess, err error) {
+	docGrant, err := e.getDoc(ctx, grantId)
+
+	if err != nil {
+		return
+	}
+
+	if docGrant.GrantorID != userId.String() && docGrant.GranteeID != userId.String() {
+		err = errors.ErrEmergencyAccessDenied
+		return
+	}
+
+	grant = e.MapDocToEmergencyAccess(docGrant)
+
+	return
+}
+
+// GetForGrantor lists the contacts the user trusts with emergency access.
+func (e *EmergencyAccessSVC) GetForGrantor(ctx context.Context, userId model.UserID, status string, params model.PaginationParams) ([]model.EmergencyAccess, model.PageInfo, error) {
+	if userId == "" {
+		return nil, model.PageInfo{}, errors.ErrInvalidID
+	}
+
+	filter := bson.M{"grantorId": userId.String()}
+
+	if status != "" {
+		filter["status"] = status
+	}
+
+	return e.find(ctx, filter, params)
+}
+
+// GetForGrantee lists the users who named the user as emergency contact.
+func (e *EmergencyAccessSVC) GetForGrantee(ctx context.Context, userId model.UserID, status string, params model.PaginationParams) ([]model.EmergencyAccess, model.PageInfo, error) {
+	if userId == "" {
+		return nil, model.PageInfo{}, errors.ErrInvalidID
+	}
+
+	filter := bson.M{"granteeId": userId.String()}
+
+	if status != "" {
+		filter["status"] = status
+	}
+
+	return e.find(ctx, filter, params)
+}
+
+// UpdateKey replaces the wrapped key after the grantor rotated their key. It cannot change while access is requested or approved.
+func (e *EmergencyAccessSVC) UpdateKey(ctx context.Context, grantId model.EmergencyAccessID, userId model.UserID, key model.EmergencyAccessKey) (grant model.EmergencyAccess, err error) {
+	if key.WrappedKey == "" {
+		err = errors.ErrInvalidEmergencyAccess
+		return
+	}
+
+	docGrant, err := e.getDoc(ctx, grantId)
+
+	if err != nil {
+		return
+	}
+
+	if docGrant.GrantorID != userId.String() {
+		err = errors.ErrEmergencyAccessDenied
+		return
+	}
+
+	filter := bson.M{
+		"_id":    docGrant.ID,
+		"status": bson.M{"$in": bson.A{model.EmergencyAccessGranted, model.EmergencyAccessRejected}},
+	}
+
+	res, err := mgm.Coll(docGrant).UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"wrappedKey":       key.WrappedKey,
+		doc.UpdatedAtField: time.Now(),
+	}})
+
+	if err != nil {
+		e.logger.WithContext(ctx).WithField("grantId", grantId.String()).WithError(err).Error("error while updating emergency access key")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		err = errors.ErrEmergencyAccessState
+		return
+	}
+
+	return e.GetByID(ctx, grantId, userId)
+}
+
+// Request starts the waiting period for the grantee. Access is approved when it ends unless the grantor rejects it first.
+func (e *EmergencyAccessSVC) Request(ctx context.Context, grantId model.EmergencyAccessID, userId model.UserID) (grant model.EmergencyAccess, err error) {
+	docGrant, err := e.getDoc(ctx, grantId)
+
+	if err != nil {
+		return
+	}
+
+	if docGrant.GranteeID != userId.String() {
+		err = errors.ErrEmergencyAccessDenied
+		return
+	}
+
+	now := time.Now()
+	autoApproveAt := now.AddDate(0, 0, docGrant.WaitDays)
+
+	err = e.transition(ctx, docGrant.ID, []string{model.EmergencyAccessGranted, model.EmergencyAccessRejected}, model.EmergencyAccessRequested, userId, bson.M{
+		"requestedAt":   now,
+		"autoApproveAt": autoApproveAt,
+		"reminderSent":  false,
+	})
+
+	if err != nil {
+		return
+	}
+
+	e.notificationSvc.Notify(ctx, model.UserID(docGrant.GrantorID), model.NotificationEmergencyAccessRequested, "Your emergency contact requested access to your vault", map[string]string{
+		"grantId":       docGrant.ID.Hex(),
+		"granteeId":     docGrant.GranteeID,
+		"autoApproveAt": autoApproveAt.Format(time.RFC3339),
+	})
+
+	return e.GetByID(ctx, grantId, userId)
+}
+
+// Approve grants the requested access before the waiting period ends.
+func (e *EmergencyAccessSVC) Approve(ctx context.Context, grantId model.EmergencyAccessID, userId model.UserID) (model.EmergencyAccess, error) {
+	return e.decide(ctx, grantId, userId, model.EmergencyAccessApproved, "Your emergency access request was approved")
+}
+
+// Reject turns down the request. The grant stays in place so the grantee can ask again later.
+func (e *EmergencyAccessSVC) Reject(ctx context.Context, grantId model.EmergencyAccessID, userId model.UserID) (model.EmergencyAccess, error) {
+	return e.decide(ctx, grantId, userId, model.EmergencyAccessRejected, "Your emergency access request was rejected")
+}
+
+func (e *EmergencyAccessSVC) decide(ctx context.Context, grantId model.EmergencyAccessID, userId model.UserID, to string, message string) (grant model.EmergencyAccess, err error) {
+	docGrant, err := e.getDoc(ctx, grantId)
+
+	if err != nil {
+		return
+	}
+
+	if docGrant.GrantorID != userId.String() {
+		err = errors.ErrEmergencyAccessDenied
+		return
+	}
+
+	err = e.transition(ctx, docGrant.ID, []string{model.EmergencyAccessRequested}, to, userId, nil)
+
+	if err != nil {
+		return
+	}
+
+	e.notificationSvc.Notify(ctx, model.UserID(docGrant.GranteeID), model.NotificationEmergencyAccessDecided, message, map[string]string{
+		"grantId":   docGrant.ID.Hex(),
+		"grantorId": docGrant.GrantorID,
+		"status":    to,
+	})
+
+	return e.GetByID(ctx, grantId, userId)
+}
+
+// Revoke ends the grant for good and drops the wrapped key. Both the grantor and the grantee can revoke it.
+func (e *EmergencyAccessSVC) Revoke(ctx context.Context, grantId model.EmergencyAccessID, userId model.UserID) (grant model.EmergencyAccess, err error) {
+	docGrant, err := e.getDoc(ctx, grantId)
+
+	if err != nil {
+		return
+	}
+
+	if docGrant.GrantorID != userId.String() && docGrant.GranteeID != userId.String() {
+		err = errors.ErrEmergencyAccessDenied
+		return
+	}
+
+	from := []string{model.EmergencyAccessGranted, model.EmergencyAccessRequested, model.EmergencyAccessApproved, model.EmergencyAccessRejected}
+
+	err = e.transition(ctx, docGrant.ID, from, model.EmergencyAccessRevoked, userId, bson.M{"wrappedKey": ""})
+
+	if err != nil {
+		return
+	}
+
+	// Tell the other side of the grant.
+	notifyId := docGrant.GranteeID
+
+	if userId.String() == docGrant.GranteeID {
+		notifyId = docGrant.GrantorID
+	}
+
+	e.notificationSvc.Notify(ctx, model.UserID(notifyId), model.NotificationEmergencyAccessRevoked, "An emergency access grant was revoked", map[string]string{
+		"grantId": docGrant.ID.Hex(),
+		"by":      userId.String(),
+	})
+
+	return e.GetByID(ctx, grantId, userId)
+}
+
+// GetVault hands the wrapped key and the secrets of the grantor to the grantee of an approved grant.
+func (e *EmergencyAccessSVC) GetVault(ctx context.Context, grantId model.EmergencyAccessID, userId model.UserID, params model.PaginationParams) (vault model.EmergencyAccessVault, err error) {
+	docGrant, err := e.getDoc(ctx, grantId)
+
+	if err != nil {
+		return
+	}
+
+	if docGrant.GranteeID != userId.String() {
+		err = errors.ErrEmergencyAccessDenied
+		return
+	}
+
+	if docGrant.Status != model.EmergencyAccessApproved {
+		err = errors.ErrEmergencyAccessState
+		return
+	}
+
+	secrets, info, err := e.secretSvc.GetAllSecretsforUser(ctx, model.UserID(docGrant.GrantorID), params)
+
+	if err != nil {
+		return
+	}
+
+	if secrets == nil {
+		secrets = []model.Secret{}
+	}
+
+	// The grantee reads the secrets of the grantor.
+	e.secretSvc.RecordAccesses(ctx, secrets, userId)
+
+	now := time.Now()
+
+	_, err = mgm.Coll(docGrant).UpdateOne(ctx, bson.M{"_id": docGrant.ID}, bson.M{"$set": bson.M{"lastAccessedAt": now}})
+
+	if err != nil {
+		e.logger.WithContext(ctx).WithField("grantId", grantId.String()).WithError(err).Error("error while recording emergency access")
+		err = nil
+	}
+
+	docGrant.LastAccessedAt = now
+
+	vault = model.EmergencyAccessVault{
+		Grant:      e.MapDocToEmergencyAccess(docGrant),
+		WrappedKey: docGrant.WrappedKey,
+		Secrets:    secrets,
+		HasMore:    info.HasMore,
+		NextCursor: info.NextCursor,
+	}
+
+	return
+}
+
+// ApproveWaitedRequests approves the requests whose waiting period ended and reminds grantors of the ones about to end.
+func (e *EmergencyAccessSVC) ApproveWaitedRequests(ctx context.Context) {
+	now := time.Now()
+
+	e.remindGrantors(ctx, now)
+
+	cursor, err := mgm.Coll(&doc.EmergencyAccess{}).Find(ctx, bson.M{
+		"status":        model.EmergencyAccessRequested,
+		"autoApproveAt": bson.M{"$lte": now},
+	})
+
+	if err != nil {
+		e.logger.WithContext(ctx).WithError(err).Error("error while fetching emergency access requests to approve")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.EmergencyAccess
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			e.logger.WithContext(ctx).WithError(err).Error("error while decoding emergency access doc")
+			continue
+		}
+
+		err = e.transition(ctx, curDoc.ID, []string{model.EmergencyAccessRequested}, model.EmergencyAccessApproved, "", nil)
+
+		if err != nil {
+			if err != errors.ErrEmergencyAccessState {
+				e.logger.WithContext(ctx).WithField("grantId", curDoc.ID.Hex()).WithError(err).Error("error while approving emergency access request")
+			}
+			continue
+		}
+
+		data := map[string]string{
+			"grantId":   curDoc.ID.Hex(),
+			"grantorId": curDoc.GrantorID,
+			"granteeId": curDoc.GranteeID,
+			"status":    model.EmergencyAccessApproved,
+		}
+
+		e.notificationSvc.Notify(ctx, model.UserID(curDoc.GranteeID), model.NotificationEmergencyAccessDecided, "Your emergency access request was approved after the waiting period", data)
+		e.notificationSvc.Notify(ctx, model.UserID(curDoc.GrantorID), model.NotificationEmergencyAccessDecided, "Your emergency contact was given access to your vault after the waiting period", data)
+	}
+}
+
+func (e *EmergencyAccessSVC) remindGrantors(ctx context.Context, now time.Time) {
+	cursor, err := mgm.Coll(&doc.EmergencyAccess{}).Find(ctx, bson.M{
+		"status":        model.EmergencyAccessRequested,
+		"reminderSent":  bson.M{"$ne": true},
+		"autoApproveAt": bson.M{"$gt": now, "$lte": now.Add(ReminderBefore)},
+	})
+
+	if err != nil {
+		e.logger.WithContext(ctx).WithError(err).Error("error while fetching emergency access requests to remind")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.EmergencyAccess
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			e.logger.WithContext(ctx).WithError(err).Error("error while decoding emergency access doc")
+			continue
+		}
+
+		res, err := mgm.Coll(&curDoc).UpdateOne(ctx, bson.M{"_id": curDoc.ID, "reminderSent": bson.M{"$ne": true}}, bson.M{"$set": bson.M{"reminderSent": true}})
+
+		if err != nil || res.ModifiedCount == 0 {
+			continue
+		}
+
+		e.notificationSvc.Notify(ctx, model.UserID(curDoc.GrantorID), model.NotificationEmergencyAccessReminder, "Your emergency contact will get access to your vault soon unless you reject the request", map[string]string{
+			"grantId":       curDoc.ID.Hex(),
+			"granteeId":     curDoc.GranteeID,
+			"autoApproveAt": curDoc.AutoApproveAt.Format(time.RFC3339),
+		})
+	}
+}
+
+// transition moves the grant to a new status and records it in the history.
+// It fails with ErrEmergencyAccessState when the grant is not in one of the expected statuses anymore.
+func (e *EmergencyAccessSVC) transition(ctx context.Context, grantId primitive.ObjectID, from []string, to string, userId model.UserID, set bson.M) error {
+	now := time.Now()
+
+	fields := bson.M{
+		"status":           to,
+		doc.UpdatedAtField: now,
+	}
+
+	for key, value := range set {
+		fields[key] = value
+	}
+
+	update := bson.M{
+		"$set": fields,
+		"$push": bson.M{"history": doc.EmergencyAccessEvent{
+			Status: to,
+			At:     now,
+			By:     userId.String(),
+		}},
+	}
+
+	res, err := mgm.Coll(&doc.EmergencyAccess{}).UpdateOne(ctx, bson.M{"_id": grantId, "status": bson.M{"$in": from}}, update)
+
+	if err != nil {
+		e.logger.WithContext(ctx).WithError(err).Error("error while updating emergency access status")
+		return errors.ErrUnknown
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.ErrEmergencyAccessState
+	}
+
+	return nil
+}
+
+func (e *EmergencyAccessSVC) find(ctx context.Context, filter bson.M, params model.PaginationParams) ([]model.EmergencyAccess, model.PageInfo, error) {
+	cursor, err := pagination.Find(ctx, mgm.Coll(&doc.EmergencyAccess{}), filter, params)
+
+	if err != nil {
+		e.logger.WithContext(ctx).WithError(err).Error("error while fetching emergency access grants")
+		return nil, model.PageInfo{}, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	grants := make([]model.EmergencyAccess, 0)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.EmergencyAccess
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			e.logger.WithContext(ctx).WithError(err).Error("error while decoding emergency access doc")
+			continue
+		}
+
+		grants = append(grants, e.MapDocToEmergencyAccess(&curDoc))
+	}
+
+	return grants, cursor.Info(), nil
+}
+
+func (e *EmergencyAccessSVC) getDoc(ctx context.Context, grantId model.EmergencyAccessID) (*doc.EmergencyAccess, error) {
+	objId, err := primitive.ObjectIDFromHex(grantId.String())
+
+	if err != nil {
+		e.logger.WithContext(ctx).WithError(err).Error("invalid emergency access id found")
+		return nil, errors.ErrInvalidID
+	}
+
+	docGrant := &doc.EmergencyAccess{}
+
+	err = mgm.Coll(docGrant).FindByID(objId, docGrant)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrEmergencyAccessNotFound
+		}
+		e.logger.WithContext(ctx).WithError(err).Error("error while fetching emergency access grant")
+		return nil, errors.ErrUnknown
+	}
+
+	return docGrant, nil
+}
+
+// MapDocToEmergencyAccess maps the grant without the wrapped key, which is only handed out by GetVault.
+func (e *EmergencyAccessSVC) MapDocToEmergencyAccess(docGrant *doc.EmergencyAccess) model.EmergencyAccess {
+	grant := model.EmergencyAccess{
+		ID:             model.EmergencyAccessID(docGrant.ID.Hex()),
+		CreatedAt:      docGrant.CreatedAt,
+		UpdatedAt:      docGrant.UpdatedAt,
+		GrantorID:      model.UserID(docGrant.GrantorID),
+		GranteeID:      model.UserID(docGrant.GranteeID),
+		WaitDays:       docGrant.WaitDays,
+		Status:         docGrant.Status,
+		RequestedAt:    docGrant.RequestedAt,
+		AutoApproveAt:  docGrant.AutoApproveAt,
+		LastAccessedAt: docGrant.LastAccessedAt,
+		History:        []model.EmergencyAccessEvent{},
+	}
+
+	for _, event := range docGrant.History {
+		grant.History = append(grant.History, model.EmergencyAccessEvent{
+			Status: event.Status,
+			At:     event.At,
+			By:     model.UserID(event.By),
+		})
+	}
+
+	return grant
+}
@@ -9,14 +9,131 @@ var (
 
 	ErrUnknown = errors.New("an unknown error has occurred")
 
+	ErrVersionConflict   = errors.New("resource was changed since the expected version")
+	ErrInvalidPageCursor = errors.New("page cursor is not valid")
+
 	ErrInvalidPassHash      = errors.New("password hash is not valid")
 	ErrInvalidAsymmetricKey = errors.New("Asymmetric Key is not valid")
 	ErrInvalidSymmetricKey  = errors.New("Symmetric Key is not valid")
+	ErrInvalidSigningKey    = errors.New("signing key is not valid")
 
 	ErrInvalidID      = errors.New("ID is not valid")
 	ErrSecretNotFound = errors.New("Secret not found")
 
 	ErrSecretShareFailed = errors.New("secret not shared")
 
-	ErrInvalidOrganizationID = errors.New("Orgnization ID is not valid")
+	ErrSecretAccessDenied = errors.New("secret access denied")
+	ErrInvalidSecretRole  = errors.New("secret role is not valid")
+	ErrSecretUserNotFound = errors.New("secret is not shared with user")
+	ErrInvalidShareExpiry = errors.New("share expiry is not valid")
+
+	ErrNotificationNotFound = errors.New("notification not found")
+
+	ErrTeamNotFound         = errors.New("team not found")
+	ErrTeamAccessDenied     = errors.New("team access denied")
+	ErrTeamMemberExists     = errors.New("user is already a team member")
+	ErrTeamMemberNotFound   = errors.New("user is not a team member")
+	ErrOrganizationMismatch = errors.New("resources belong to different organizations")
+	ErrSecretAlreadyShared  = errors.New("secret is already shared")
+
+	ErrCollectionNotFound     = errors.New("collection not found")
+	ErrCollectionAccessDenied = errors.New("collection access denied")
+	ErrCollectionExists       = errors.New("collection already exists at path")
+	ErrCollectionNotEmpty     = errors.New("collection is not empty")
+	ErrInvalidCollectionGrant = errors.New("collection grant names a user or team outside the organization")
+	ErrInvalidCollectionMove  = errors.New("collection cannot be moved into itself")
+	ErrInvalidCollectionName  = errors.New("collection name is not valid")
+
+	ErrProjectNotFound     = errors.New("project not found")
+	ErrProjectAccessDenied = errors.New("project access denied")
+	ErrProjectExists       = errors.New("project slug already exists")
+	ErrProjectNotEmpty     = errors.New("project still has secrets")
+	ErrInvalidProject      = errors.New("project is not valid")
+	ErrEnvironmentNotFound = errors.New("environment not found in project")
+	ErrSecretNameExists    = errors.New("secret name already exists")
+	ErrNoNextEnvironment   = errors.New("environment has no next environment to promote to")
+
+	ErrInvalidSearch = errors.New("search query is not valid")
+
+	ErrInvalidSecretKind     = errors.New("secret kind is not valid")
+	ErrInvalidSecretMetadata = errors.New("secret metadata does not match the kind")
+
+	ErrAttachmentNotFound         = errors.New("attachment not found")
+	ErrAttachmentAccessDenied     = errors.New("attachment access denied")
+	ErrAttachmentUploadNotFound   = errors.New("attachment upload not found")
+	ErrInvalidAttachment          = errors.New("attachment details are not valid")
+	ErrAttachmentQuotaExceeded    = errors.New("organization attachment quota exceeded")
+	ErrAttachmentChunkOffset      = errors.New("attachment chunk offset does not match the received size")
+	ErrAttachmentUploadIncomplete = errors.New("attachment upload is not complete")
+	ErrAttachmentIntegrity        = errors.New("attachment hash does not match")
+
+	ErrShareLinkNotFound     = errors.New("share link not found or no longer available")
+	ErrInvalidShareLink      = errors.New("share link details are not valid")
+	ErrShareLinkPassphrase   = errors.New("share link passphrase is not valid")
+	ErrShareLinkAccessDenied = errors.New("share link access denied")
+
+	ErrAccessRequestNotFound    = errors.New("access request not found")
+	ErrInvalidAccessRequest     = errors.New("access request is not valid")
+	ErrAccessRequestDecided     = errors.New("access request is no longer pending")
+	ErrAccessRequestDenied      = errors.New("user cannot act on the access request")
+	ErrAccessRequestAlreadyOpen = errors.New("user already has a pending access request for the secret")
+
+	ErrCheckoutNotEnabled   = errors.New("secret does not use checkout")
+	ErrSecretCheckedOut     = errors.New("secret is checked out by another user")
+	ErrCheckoutRequired     = errors.New("secret has to be checked out before it can be read")
+	ErrNotCheckoutHolder    = errors.New("user does not hold the checkout of the secret")
+	ErrInvalidCheckoutLease = errors.New("checkout lease is not valid")
+
+	ErrInvalidRotationPolicy = errors.New("rotation policy is not valid")
+
+	ErrInvalidValueFingerprint = errors.New("value fingerprint is not valid")
+	ErrInvalidSecretConsumer   = errors.New("secret consumer is not valid")
+	ErrInvalidSecretSignature  = errors.New("secret signature is not valid")
+	ErrSigningKeyNotFound      = errors.New("author has no such signing key")
+	ErrSecretSignatureRequired = errors.New("secret signature is required")
+	ErrInvalidUnusedDays       = errors.New("unused days are not valid")
+	ErrInvalidCertificateChain = errors.New("certificate chain is not valid")
+	ErrInvalidExpiryDays       = errors.New("expiry days are not valid")
+	ErrInvalidHealthPolicy     = errors.New("health policy is not valid")
+
+	ErrInvalidOwnershipTransfer = errors.New("ownership transfer is not valid")
+
+	ErrInvalidSecretImport     = errors.New("secret import is not valid")
+	ErrInvalidSecretImportItem = errors.New("secret import item is not valid")
+	ErrSecretImportPlaintext   = errors.New("secret import item carries a secret field in plaintext")
+	ErrSecretImportAborted     = errors.New("secret import was aborted because an item is not valid")
+
+	ErrInvalidArchive      = errors.New("organization archive is not valid")
+	ErrArchiveIntegrity    = errors.New("organization archive does not match its manifest")
+	ErrArchiveVersion      = errors.New("organization archive format version is not supported")
+	ErrArchiveAccessDenied = errors.New("user cannot export or import the organization")
+
+	ErrAuditAccessDenied = errors.New("audit log access denied")
+
+	ErrInvalidSyncRevision     = errors.New("sync revision is not valid")
+	ErrSyncResetRequired       = errors.New("sync revision is older than the retained changes")
+	ErrChangeStreamUnavailable = errors.New("change stream is not available")
+
+	ErrBreakGlassVaultNotFound  = errors.New("break-glass vault not found")
+	ErrInvalidBreakGlassVault   = errors.New("break-glass vault is not valid")
+	ErrBreakGlassAccessDenied   = errors.New("user cannot act on the break-glass vault")
+	ErrBreakGlassUnlockNotFound = errors.New("break-glass unlock not found")
+	ErrBreakGlassUnlockOpen     = errors.New("break-glass vault already has an open unlock")
+	ErrBreakGlassUnlockClosed   = errors.New("break-glass unlock is no longer pending")
+	ErrBreakGlassShareSubmitted = errors.New("custodian already submitted a share")
+	ErrInvalidBreakGlassUnlock  = errors.New("break-glass unlock is not valid")
+	ErrInvalidBreakGlassShare   = errors.New("break-glass share is not valid")
+	ErrBreakGlassNotUnlocked    = errors.New("break-glass unlock has not reached its quorum")
+
+	ErrEmergencyAccessNotFound = errors.New("emergency access grant not found")
+	ErrInvalidEmergencyAccess  = errors.New("emergency access grant is not valid")
+	ErrEmergencyAccessDenied   = errors.New("user cannot act on the emergency access grant")
+	ErrEmergencyAccessExists   = errors.New("an emergency access grant already exists for the contact")
+	ErrEmergencyAccessState    = errors.New("emergency access grant is not in a state for this action")
+
+	ErrInvalidOrganizationID    = errors.New("Orgnization ID is not valid")
+	ErrOrganizationNotFound     = errors.New("organization not found")
+	ErrNotOrganizationMember    = errors.New("user is not a member of the organization")
+	ErrOrganizationAccessDenied = errors.New("organization access denied")
+	ErrInvalidOrganizationName  = errors.New("organization name is not valid")
 )
@@ -5,6 +5,7 @@ import (
 	"secaas_backend/db/doc"
 	"secaas_backend/model"
 	"secaas_backend/svc/errors"
+	"secaas_backend/svc/pagination"
 	"strings"
 	"time"
 
@@ -12,18 +13,27 @@ import (
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type InviteSVC struct {
-	logger *logrus.Logger
+	logger      *logrus.Logger
+	changeHooks []ChangeHook
 }
 
+// ChangeHook is called after an invite was created, deleted or accepted. Accepting adds the
+// recipient to the organization of the invite.
+type ChangeHook func(ctx context.Context, invite model.Invite, accepted bool)
+
 func New(logger *logrus.Logger) *InviteSVC {
 	u := &InviteSVC{logger: logger}
 	return u
 }
 
+// RegisterChangeHook adds a hook which follows the invites and the memberships they lead to.
+func (s *InviteSVC) RegisterChangeHook(hook ChangeHook) {
+	s.changeHooks = append(s.changeHooks, hook)
+}
+
 func (s *InviteSVC) CreateInvite(ctx context.Context, data model.Invite) (model.Invite, error) {
 
 	docInvite := &doc.Invite{
@@ -47,10 +57,37 @@ func (s *InviteSVC) CreateInvite(ctx context.Context, data model.Invite) (model.
 
 	newInvite := s.MapDocToInvite(docInvite)
 
+	for _, hook := range s.changeHooks {
+		hook(ctx, newInvite, false)
+	}
+
 	return newInvite, nil
 }
 
-func (s *InviteSVC) GetInvitesByOrganization(ctx context.Context, orgId string, params model.PaginationParams, onlyActive bool) ([]model.Invite, error) {
+// GetByID returns the invite, expired or not.
+func (s *InviteSVC) GetByID(ctx context.Context, inviteId model.InviteID) (model.Invite, error) {
+	objId, err := primitive.ObjectIDFromHex(inviteId.String())
+
+	if err != nil {
+		return model.Invite{}, errors.ErrInvalidID
+	}
+
+	docInvite := &doc.Invite{}
+
+	err = mgm.Coll(docInvite).FindByID(objId, docInvite)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return model.Invite{}, errors.ErrInviteNotFound
+		}
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching invite")
+		return model.Invite{}, errors.ErrUnknown
+	}
+
+	return s.MapDocToInvite(docInvite), nil
+}
+
+func (s *InviteSVC) GetInvitesByOrganization(ctx context.Context, orgId string, params model.PaginationParams, onlyActive bool) ([]model.Invite, model.PageInfo, error) {
 	filter := bson.M{
 		"organizationId": orgId,
 	}
@@ -64,16 +101,12 @@ func (s *InviteSVC) GetInvitesByOrganization(ctx context.Context, orgId string,
 
 	coll := mgm.Coll(inviteDoc)
 
-	findOptions := options.Find().SetSkip(int64(params.Skip)).SetLimit(int64(params.Limit)).SetSort(bson.D{
-		{"createdAt", -1},
-	})
-
-	rawDocs, err := coll.Find(ctx, filter, findOptions)
+	rawDocs, err := pagination.Find(ctx, coll, filter, params)
 
 	if err != nil {
 		s.logger.WithContext(ctx).WithError(err).Error("error while fetching list of invites")
 		err = errors.ErrUnknown
-		return []model.Invite{}, err
+		return []model.Invite{}, model.PageInfo{}, err
 	}
 
 	defer rawDocs.Close(ctx)
@@ -97,10 +130,10 @@ func (s *InviteSVC) GetInvitesByOrganization(ctx context.Context, orgId string,
 
 	s.DeleteInvite(ctx, inviteDoc.ID.Hex())
 
-	return docs, nil
+	return docs, rawDocs.Info(), nil
 }
 
-func (s *InviteSVC) GetInvitesForUser(ctx context.Context, receiverEmail string, params model.PaginationParams, onlyActive bool) ([]model.Invite, error) {
+func (s *InviteSVC) GetInvitesForUser(ctx context.Context, receiverEmail string, params model.PaginationParams, onlyActive bool) ([]model.Invite, model.PageInfo, error) {
 	filter := bson.M{
 		"toUserEmail": receiverEmail,
 	}
@@ -114,16 +147,12 @@ func (s *InviteSVC) GetInvitesForUser(ctx context.Context, receiverEmail string,
 
 	coll := mgm.Coll(inviteDoc)
 
-	findOptions := options.Find().SetSkip(int64(params.Skip)).SetLimit(int64(params.Limit)).SetSort(bson.D{
-		{"updatedAt", -1},
-	})
-
-	rawDocs, err := coll.Find(ctx, filter, findOptions)
+	rawDocs, err := pagination.Find(ctx, coll, filter, params)
 
 	if err != nil {
 		s.logger.WithContext(ctx).WithError(err).Error("error while fetching list of invites for user.")
 		err = errors.ErrUnknown
-		return []model.Invite{}, err
+		return []model.Invite{}, model.PageInfo{}, err
 	}
 
 	defer rawDocs.Close(ctx)
@@ -146,7 +175,7 @@ func (s *InviteSVC) GetInvitesForUser(ctx context.Context, receiverEmail string,
 
 	}
 
-	return docs, nil
+	return docs, rawDocs.Info(), nil
 }
 
 func (s *InviteSVC) DeleteInvite(ctx context.Context, inviteId string) (int, error) {
@@ -164,6 +193,18 @@ func (s *InviteSVC) DeleteInvite(ctx context.Context, inviteId string) (int, err
 		"_id": objId,
 	}
 
+	// Load the invite first so the hooks know whom it was addressed to.
+	err = mgm.Coll(&inviteDoc).First(filter, &inviteDoc)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return 0, nil
+		}
+		s.logger.WithContext(ctx).WithError(err).Error("Error while fetching invitation to delete")
+		err = errors.ErrUnknown
+		return 0, err
+	}
+
 	res, err := mgm.Coll(&inviteDoc).DeleteOne(ctx, filter)
 
 	if err != nil {
@@ -172,6 +213,12 @@ func (s *InviteSVC) DeleteInvite(ctx context.Context, inviteId string) (int, err
 		return 0, err
 	}
 
+	if res.DeletedCount > 0 {
+		for _, hook := range s.changeHooks {
+			hook(ctx, s.MapDocToInvite(&inviteDoc), false)
+		}
+	}
+
 	return int(res.DeletedCount), nil
 
 }
@@ -233,6 +280,10 @@ func (i *InviteSVC) AcceptInvite(ctx context.Context, inviteId string) (err erro
 		i.logger.WithContext(ctx).WithField("To Email", docInvite.ToUserEmail).Print("Could not update the email with organization when accepting invite.")
 	} else {
 		i.logger.WithContext(ctx).WithField("To Email", docInvite.ToUserEmail).Print("Added organization to the email when accepting invite.")
+
+		for _, hook := range i.changeHooks {
+			hook(ctx, i.MapDocToInvite(docInvite), true)
+		}
 	}
 
 	return
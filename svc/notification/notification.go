@@ -0,0 +1,127 @@
+package notification
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/pagination"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type NotificationSVC struct {
+	logger *logrus.Logger
+}
+
+func New(logger *logrus.Logger) *NotificationSVC {
+	n := &NotificationSVC{logger: logger}
+	return n
+}
+
+// Notify stores a new notification for the user. Failures are only logged so callers never fail because of a notification.
+func (n *NotificationSVC) Notify(ctx context.Context, userId model.UserID, notificationType string, message string, data map[string]string) {
+	docNotification := &doc.Notification{
+		UserID:  userId.String(),
+		Type:    notificationType,
+		Message: message,
+		Data:    data,
+	}
+
+	err := mgm.Coll(docNotification).CreateWithCtx(ctx, docNotification)
+
+	if err != nil {
+		n.logger.WithContext(ctx).WithField("userId", userId).WithField("type", notificationType).WithError(err).Error("error while creating notification")
+	}
+}
+
+func (n *NotificationSVC) GetForUser(ctx context.Context, userId model.UserID, params model.PaginationParams, onlyUnread bool) ([]model.Notification, model.PageInfo, error) {
+	if userId == "" {
+		n.logger.WithContext(ctx).Error("invalid user id to get notifications")
+		return nil, model.PageInfo{}, errors.ErrInvalidID
+	}
+
+	filter := bson.M{
+		"userId": userId.String(),
+	}
+
+	if onlyUnread {
+		filter["read"] = false
+	}
+
+	cursor, err := pagination.Find(ctx, mgm.Coll(&doc.Notification{}), filter, params)
+
+	if err != nil {
+		n.logger.WithContext(ctx).WithError(err).Error("error while fetching notifications for user")
+		return nil, model.PageInfo{}, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	notifications := make([]model.Notification, 0)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Notification
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			n.logger.WithContext(ctx).WithError(err).Error("error while decoding notification doc")
+			continue
+		}
+
+		notifications = append(notifications, n.MapDocToNotification(&curDoc))
+	}
+
+	return notifications, cursor.Info(), nil
+}
+
+func (n *NotificationSVC) MarkRead(ctx context.Context, notificationId model.NotificationID, userId model.UserID) error {
+	objId, err := primitive.ObjectIDFromHex(notificationId.String())
+
+	if err != nil {
+		n.logger.WithContext(ctx).WithError(err).Error("invalid notification id found")
+		return errors.ErrInvalidID
+	}
+
+	filter := bson.M{
+		"_id":    objId,
+		"userId": userId.String(),
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"read": true,
+		},
+	}
+
+	res, err := mgm.Coll(&doc.Notification{}).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		n.logger.WithContext(ctx).WithError(err).Error("error while marking notification as read")
+		return errors.ErrUnknown
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+func (n *NotificationSVC) MapDocToNotification(docNotification *doc.Notification) model.Notification {
+	notification := model.Notification{
+		ID:        model.NotificationID(docNotification.ID.Hex()),
+		CreatedAt: docNotification.CreatedAt,
+		UserID:    model.UserID(docNotification.UserID),
+		Type:      docNotification.Type,
+		Message:   docNotification.Message,
+		Data:      docNotification.Data,
+		Read:      docNotification.Read,
+	}
+
+	return notification
+}
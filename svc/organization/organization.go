@@ -5,6 +5,10 @@ import (
 	"secaas_backend/db/doc"
 	"secaas_backend/model"
 	"secaas_backend/svc/errors"
+	"secaas_backend/svc/pagination"
+	"secaas_backend/svc/user"
+	"strings"
+	"time"
 
 	"github.com/kamva/mgm/v3"
 	"github.com/sirupsen/logrus"
@@ -12,15 +16,61 @@ import (
 	"gopkg.in/mgo.v2/bson"
 )
 
+// MaxHealthPolicyAgeDays bounds the max age of the health policy, like the rotation intervals.
+const MaxHealthPolicyAgeDays = 3650
+
 type OrganizationSVC struct {
-	logger *logrus.Logger
+	logger      *logrus.Logger
+	userSvc     *user.UserSVC
+	memberHooks []MemberHook
 }
 
-func New(logger *logrus.Logger) *OrganizationSVC {
-	u := &OrganizationSVC{logger: logger}
+// MemberHook is called after users were added to or removed from the organization.
+type MemberHook func(ctx context.Context, orgId model.OrganizationID, memberIds []model.UserID)
+
+func New(logger *logrus.Logger, userSvc *user.UserSVC) *OrganizationSVC {
+	u := &OrganizationSVC{logger: logger, userSvc: userSvc}
 	return u
 }
 
+// RegisterMemberHook adds a hook which follows the memberships of the organizations.
+func (o *OrganizationSVC) RegisterMemberHook(hook MemberHook) {
+	o.memberHooks = append(o.memberHooks, hook)
+}
+
+// memberIDs lists the ids of the users matching the filter, when a member hook needs them.
+func (o *OrganizationSVC) memberIDs(ctx context.Context, filter bson.M) []model.UserID {
+	memberIds := []model.UserID{}
+
+	if len(o.memberHooks) == 0 {
+		return memberIds
+	}
+
+	cursor, err := mgm.Coll(&doc.User{}).Find(ctx, filter)
+
+	if err != nil {
+		o.logger.WithContext(ctx).WithError(err).Error("error while fetching organization members")
+		return memberIds
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.User
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			o.logger.WithContext(ctx).WithError(err).Error("error while decoding user document")
+			continue
+		}
+
+		memberIds = append(memberIds, model.UserID(curDoc.ID.Hex()))
+	}
+
+	return memberIds
+}
+
 func (o *OrganizationSVC) CreateNew(ctx context.Context, organization model.Organization) (data model.Organization, err error) {
 
 	if organization.SymmKey.Alg == "" || organization.SymmKey.EncryptedData == "" {
@@ -40,6 +90,7 @@ func (o *OrganizationSVC) CreateNew(ctx context.Context, organization model.Orga
 			EncryptedData: organization.SymmKey.EncryptedData,
 			Alg:           organization.SymmKey.Alg,
 		},
+		Version: 1,
 	}
 
 	err = mgm.Coll(docOrganization).CreateWithCtx(ctx, docOrganization)
@@ -80,12 +131,188 @@ func (o *OrganizationSVC) CreateNew(ctx context.Context, organization model.Orga
 		o.logger.WithContext(ctx).WithField("Admin Email", data.AdminEmail).Print("Could not update the email with organization.")
 	} else {
 		o.logger.WithContext(ctx).WithField("Admin Email", data.AdminEmail).Print("Added organization to the email.")
+
+		memberIds := o.memberIDs(ctx, userFilter)
+
+		for _, hook := range o.memberHooks {
+			hook(ctx, data.ID, memberIds)
+		}
 	}
 
 	return
 }
 
-func (o *OrganizationSVC) DeleteOrganization(ctx context.Context, organizationId model.OrganizationID) (deleted int, err error) {
+// versionFilter matches the organization while it still has the version. Organizations from before
+// versioning have no version field and count as version zero.
+func versionFilter(objId primitive.ObjectID, version int64) bson.M {
+	if version == 0 {
+		return bson.M{"_id": objId, "version": bson.M{"$in": []interface{}{nil, 0}}}
+	}
+
+	return bson.M{"_id": objId, "version": version}
+}
+
+// getDoc loads the organization document.
+func (o *OrganizationSVC) getDoc(ctx context.Context, organizationId model.OrganizationID) (*doc.Organization, error) {
+	objId, err := primitive.ObjectIDFromHex(organizationId.String())
+
+	if err != nil {
+		return nil, errors.ErrInvalidOrganizationID
+	}
+
+	docOrg := &doc.Organization{}
+
+	err = mgm.Coll(docOrg).FindByIDWithCtx(ctx, objId, docOrg)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrOrganizationNotFound
+		}
+		o.logger.WithContext(ctx).WithField("organizationId", organizationId.String()).WithError(err).Error("error while fetching organization")
+		return nil, errors.ErrUnknown
+	}
+
+	return docOrg, nil
+}
+
+// GetByID returns the organization to one of its members.
+func (o *OrganizationSVC) GetByID(ctx context.Context, organizationId model.OrganizationID, userId model.UserID) (org model.Organization, err error) {
+	_, err = o.userSvc.GetOrganizationMembership(ctx, userId, organizationId)
+
+	if err != nil {
+		return
+	}
+
+	docOrg, err := o.getDoc(ctx, organizationId)
+
+	if err != nil {
+		return
+	}
+
+	org = o.MapDocToOrganization(docOrg)
+
+	return
+}
+
+// GetVersion returns the current version of the organization.
+func (o *OrganizationSVC) GetVersion(ctx context.Context, organizationId model.OrganizationID) (version int64, err error) {
+	docOrg, err := o.getDoc(ctx, organizationId)
+
+	if err != nil {
+		return
+	}
+
+	version = docOrg.Version
+
+	return
+}
+
+// Update changes the name and billing email of the organization and moves it to the next version.
+// Only admins can update it. With an expected version the update fails with ErrVersionConflict
+// unless the organization still has that version.
+func (o *OrganizationSVC) Update(ctx context.Context, organizationId model.OrganizationID, userId model.UserID, data model.Organization, expectedVersion *int64) (org model.Organization, err error) {
+	if strings.TrimSpace(data.Name) == "" {
+		err = errors.ErrInvalidOrganizationName
+		return
+	}
+
+	membership, err := o.userSvc.GetOrganizationMembership(ctx, userId, organizationId)
+
+	if err != nil {
+		return
+	}
+
+	if !membership.IsAdmin {
+		err = errors.ErrOrganizationAccessDenied
+		return
+	}
+
+	docOrg, err := o.getDoc(ctx, organizationId)
+
+	if err != nil {
+		return
+	}
+
+	if expectedVersion != nil && *expectedVersion != docOrg.Version {
+		err = errors.ErrVersionConflict
+		return
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":             data.Name,
+			"billingEmail":     data.BillingEmail,
+			doc.UpdatedAtField: time.Now().UTC(),
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	res, err := mgm.Coll(docOrg).UpdateOne(ctx, versionFilter(docOrg.ID, docOrg.Version), update)
+
+	if err != nil {
+		o.logger.WithContext(ctx).WithField("organizationId", organizationId.String()).WithError(err).Error("error while updating organization")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		err = errors.ErrVersionConflict
+		return
+	}
+
+	return o.GetByID(ctx, organizationId, userId)
+}
+
+// SetHealthPolicy sets the limits the password health report of the organization checks against.
+// Only admins can set it.
+func (o *OrganizationSVC) SetHealthPolicy(ctx context.Context, organizationId model.OrganizationID, userId model.UserID, policy model.HealthPolicy) (org model.Organization, err error) {
+	if policy.MaxAgeDays < 0 || policy.MaxAgeDays > MaxHealthPolicyAgeDays || policy.MaxShares < 0 {
+		err = errors.ErrInvalidHealthPolicy
+		return
+	}
+
+	membership, err := o.userSvc.GetOrganizationMembership(ctx, userId, organizationId)
+
+	if err != nil {
+		return
+	}
+
+	if !membership.IsAdmin {
+		err = errors.ErrOrganizationAccessDenied
+		return
+	}
+
+	docOrg, err := o.getDoc(ctx, organizationId)
+
+	if err != nil {
+		return
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"healthPolicy": doc.HealthPolicy{
+				MaxAgeDays: policy.MaxAgeDays,
+				MaxShares:  policy.MaxShares,
+			},
+			doc.UpdatedAtField: time.Now().UTC(),
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	_, err = mgm.Coll(docOrg).UpdateOne(ctx, bson.M{"_id": docOrg.ID}, update)
+
+	if err != nil {
+		o.logger.WithContext(ctx).WithField("organizationId", organizationId.String()).WithError(err).Error("error while setting health policy")
+		err = errors.ErrUnknown
+		return
+	}
+
+	return o.GetByID(ctx, organizationId, userId)
+}
+
+// DeleteOrganization removes the organization and its memberships. With an expected version nothing is
+// deleted and ErrVersionConflict is returned unless the organization still has that version.
+func (o *OrganizationSVC) DeleteOrganization(ctx context.Context, organizationId model.OrganizationID, expectedVersion *int64) (deleted int, err error) {
 
 	objId, err := primitive.ObjectIDFromHex(organizationId.String())
 
@@ -99,6 +326,10 @@ func (o *OrganizationSVC) DeleteOrganization(ctx context.Context, organizationId
 		"_id": objId,
 	}
 
+	if expectedVersion != nil {
+		filter = versionFilter(objId, *expectedVersion)
+	}
+
 	docOrg := &doc.Organization{}
 
 	res, err := mgm.Coll(docOrg).DeleteOne(ctx, filter)
@@ -111,6 +342,18 @@ func (o *OrganizationSVC) DeleteOrganization(ctx context.Context, organizationId
 
 	deleted = int(res.DeletedCount)
 
+	if deleted == 0 && expectedVersion != nil {
+		_, err = o.getDoc(ctx, organizationId)
+
+		// Only an organization which is still there has a version to conflict with.
+		if err == nil {
+			err = errors.ErrVersionConflict
+		} else if err == errors.ErrOrganizationNotFound {
+			err = nil
+		}
+		return
+	}
+
 	// Delete all the users related to that organization.
 	docUser := &doc.User{}
 
@@ -126,18 +369,24 @@ func (o *OrganizationSVC) DeleteOrganization(ctx context.Context, organizationId
 		},
 	}
 
+	memberIds := o.memberIDs(ctx, userFilter)
+
 	userRes, userErr := mgm.Coll(docUser).UpdateMany(ctx, userFilter, userUpdate)
 
 	if userErr != nil {
 		o.logger.WithContext(ctx).WithError(err).Error("Failed to delete the users associated with organization.")
 	}
 
+	for _, hook := range o.memberHooks {
+		hook(ctx, organizationId, memberIds)
+	}
+
 	o.logger.WithContext(ctx).WithField("userMatched", userRes.MatchedCount).WithField("userUpdated", userRes.ModifiedCount).Debug("removed organization from users after its deletion.")
 
 	return
 }
 
-func (o *OrganizationSVC) GetOrganizationList(ctx context.Context, organizationIds []model.OrganizationID) (orgs []model.Organization, err error) {
+func (o *OrganizationSVC) GetOrganizationList(ctx context.Context, organizationIds []model.OrganizationID, params model.PaginationParams) (orgs []model.Organization, info model.PageInfo, err error) {
 
 	organizationObjIds := []primitive.ObjectID{}
 
@@ -163,7 +412,7 @@ func (o *OrganizationSVC) GetOrganizationList(ctx context.Context, organizationI
 
 	docOrg := &doc.Organization{}
 
-	res, err := mgm.Coll(docOrg).Find(ctx, filter)
+	res, err := pagination.Find(ctx, mgm.Coll(docOrg), filter, params)
 
 	if err != nil {
 		o.logger.WithContext(ctx).WithError(err).Error("error while fetching list of organizations")
@@ -190,6 +439,7 @@ func (o *OrganizationSVC) GetOrganizationList(ctx context.Context, organizationI
 	}
 
 	orgs = mappedOrgs
+	info = res.Info()
 
 	return
 }
@@ -206,6 +456,14 @@ func (o *OrganizationSVC) MapDocToOrganization(docOrg *doc.Organization) model.O
 			EncryptedData: docOrg.SymmKey.EncryptedData,
 			Alg:           docOrg.SymmKey.Alg,
 		},
+		Version: docOrg.Version,
+	}
+
+	if docOrg.HealthPolicy != nil {
+		org.HealthPolicy = model.HealthPolicy{
+			MaxAgeDays: docOrg.HealthPolicy.MaxAgeDays,
+			MaxShares:  docOrg.HealthPolicy.MaxShares,
+		}
 	}
 
 	return org
@@ -0,0 +1,152 @@
+package pagination
+
+import (
+	"context"
+	"encoding/base64"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Lists are sorted by the last update, newest first. The id breaks ties so the order is stable across pages.
+var sort = bson.D{{Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}}
+
+// EncodeCursor turns the sort key of an item into the opaque cursor clients pass back for the next page.
+func EncodeCursor(cursor model.PageCursor) string {
+	raw := strconv.FormatInt(cursor.UpdatedAt.UnixMilli(), 10) + "." + cursor.ID
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor reads a cursor made by EncodeCursor.
+func DecodeCursor(raw string) (*model.PageCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+
+	if err != nil {
+		return nil, errors.ErrInvalidPageCursor
+	}
+
+	millis, id, found := strings.Cut(string(decoded), ".")
+
+	if !found || !primitive.IsValidObjectID(id) {
+		return nil, errors.ErrInvalidPageCursor
+	}
+
+	updatedAt, err := strconv.ParseInt(millis, 10, 64)
+
+	if err != nil {
+		return nil, errors.ErrInvalidPageCursor
+	}
+
+	return &model.PageCursor{UpdatedAt: time.UnixMilli(updatedAt).UTC(), ID: id}, nil
+}
+
+// Page iterates over the items of one page of a list.
+type Page struct {
+	cursor *mongo.Cursor
+	limit  int
+	read   int
+	last   pageKey
+	info   model.PageInfo
+}
+
+type pageKey struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+// Find queries the page of the list matching the filter. It reads one item more than the limit to
+// know if there is a next page, and counts all the matching items when the total was asked for.
+func Find(ctx context.Context, coll *mgm.Collection, filter interface{}, params model.PaginationParams) (*Page, error) {
+	page := &Page{limit: params.Limit}
+
+	if params.WithTotal {
+		total, err := coll.CountDocuments(ctx, filter)
+
+		if err != nil {
+			return nil, err
+		}
+
+		page.info.Total = &total
+	}
+
+	findOptions := options.Find().SetLimit(int64(params.Limit + 1)).SetSort(sort)
+
+	if params.Cursor != nil {
+		objId, err := primitive.ObjectIDFromHex(params.Cursor.ID)
+
+		if err != nil {
+			return nil, errors.ErrInvalidPageCursor
+		}
+
+		after := bson.M{
+			"$or": bson.A{
+				bson.M{doc.UpdatedAtField: bson.M{"$lt": params.Cursor.UpdatedAt}},
+				bson.M{doc.UpdatedAtField: params.Cursor.UpdatedAt, "_id": bson.M{"$lt": objId}},
+			},
+		}
+
+		filter = bson.M{"$and": bson.A{filter, after}}
+	} else {
+		findOptions.SetSkip(int64(params.Skip))
+	}
+
+	cursor, err := coll.Find(ctx, filter, findOptions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	page.cursor = cursor
+
+	return page, nil
+}
+
+// Next moves to the next item of the page. It returns false after the last item of the page.
+func (p *Page) Next(ctx context.Context) bool {
+	if !p.cursor.Next(ctx) {
+		return false
+	}
+
+	if p.read == p.limit {
+		p.info.HasMore = true
+		p.info.NextCursor = EncodeCursor(model.PageCursor{UpdatedAt: p.last.UpdatedAt, ID: p.last.ID.Hex()})
+		return false
+	}
+
+	p.read++
+
+	// Keep the key even when the item itself fails to decode, the next page starts after it.
+	_ = bson.Unmarshal(p.cursor.Current, &p.last)
+
+	return true
+}
+
+// Decode decodes the current item into v.
+func (p *Page) Decode(v interface{}) error {
+	return p.cursor.Decode(v)
+}
+
+// Err returns the last error of the underlying cursor.
+func (p *Page) Err() error {
+	return p.cursor.Err()
+}
+
+// Close closes the underlying cursor.
+func (p *Page) Close(ctx context.Context) error {
+	return p.cursor.Close(ctx)
+}
+
+// Info tells where the page ends, once all its items were read.
+func (p *Page) Info() model.PageInfo {
+	return p.info
+}
@@ -0,0 +1,145 @@
+package pagination
+
+import (
+	"context"
+	"os"
+	"secaas_backend/model"
+	"testing"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type pageItem struct {
+	mgm.DefaultModel `bson:",inline"`
+	N                int `bson:"n"`
+}
+
+// testCollection connects to the MongoDB named by MONGO_TEST_URI and returns an empty collection.
+func testCollection(t *testing.T) *mgm.Collection {
+	uri := os.Getenv("MONGO_TEST_URI")
+
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	err := mgm.SetDefaultConfig(&mgm.Config{CtxTimeout: 10 * time.Second}, "secaas_pagination_test", options.Client().ApplyURI(uri))
+
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+
+	coll := mgm.CollectionByName("page_items")
+
+	t.Cleanup(func() {
+		coll.Drop(context.Background())
+	})
+
+	_, err = coll.DeleteMany(context.Background(), bson.M{})
+
+	if err != nil {
+		t.Fatalf("clearing collection: %v", err)
+	}
+
+	return coll
+}
+
+func readPage(t *testing.T, coll *mgm.Collection, params model.PaginationParams) ([]int, model.PageInfo) {
+	ctx := context.Background()
+
+	page, err := Find(ctx, coll, bson.M{}, params)
+
+	if err != nil {
+		t.Fatalf("finding page: %v", err)
+	}
+
+	defer page.Close(ctx)
+
+	items := []int{}
+
+	for page.Next(ctx) {
+		var item pageItem
+
+		if err := page.Decode(&item); err != nil {
+			t.Fatalf("decoding item: %v", err)
+		}
+
+		items = append(items, item.N)
+	}
+
+	return items, page.Info()
+}
+
+func TestFindWalksPages(t *testing.T) {
+	coll := testCollection(t)
+
+	for n := 0; n < 5; n++ {
+		if err := coll.Create(&pageItem{N: n}); err != nil {
+			t.Fatalf("creating item: %v", err)
+		}
+
+		// Distinct timestamps keep the expected order independent of the ids.
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	want := []int{4, 3, 2, 1, 0}
+
+	t.Run("cursor", func(t *testing.T) {
+		got := []int{}
+		params := model.PaginationParams{Page: 1, Limit: 2}
+
+		for pages := 0; pages < 5; pages++ {
+			items, info := readPage(t, coll, params)
+			got = append(got, items...)
+
+			if !info.HasMore {
+				break
+			}
+
+			if len(items) == 0 {
+				t.Fatalf("empty page %d announced more", pages+1)
+			}
+
+			cursor, err := DecodeCursor(info.NextCursor)
+
+			if err != nil {
+				t.Fatalf("decoding cursor: %v", err)
+			}
+
+			params.Cursor = cursor
+		}
+
+		assertItems(t, got, want)
+	})
+
+	t.Run("page number", func(t *testing.T) {
+		got := []int{}
+
+		for page := 1; page <= 5; page++ {
+			items, info := readPage(t, coll, model.PaginationParams{Page: page, Limit: 2, Skip: (page - 1) * 2})
+			got = append(got, items...)
+
+			if !info.HasMore {
+				break
+			}
+		}
+
+		assertItems(t, got, want)
+	})
+}
+
+func assertItems(t *testing.T, got []int, want []int) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got items %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got items %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package project
+
+import (
+	"context"
+	"regexp"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/user"
+	"sort"
+	"strings"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// defaultEnvironments are used when a project is created without environments.
+var defaultEnvironments = []model.ProjectEnvironment{
+	{Slug: "dev", Name: "Development", Position: 0},
+	{Slug: "staging", Name: "Staging", Position: 1},
+	{Slug: "prod", Name: "Production", Position: 2},
+}
+
+type ProjectSVC struct {
+	logger  *logrus.Logger
+	userSvc *user.UserSVC
+}
+
+func New(logger *logrus.Logger, userSvc *user.UserSVC) *ProjectSVC {
+	p := &ProjectSVC{logger: logger, userSvc: userSvc}
+	return p
+}
+
+func (p *ProjectSVC) Create(ctx context.Context, data model.Project, userId model.UserID) (project model.Project, err error) {
+	if data.Name == "" || !slugPattern.MatchString(data.Slug) {
+		err = errors.ErrInvalidProject
+		return
+	}
+
+	if !p.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(data.OrganizationID)) {
+		err = errors.ErrProjectAccessDenied
+		return
+	}
+
+	environments := data.Environments
+
+	if len(environments) == 0 {
+		environments = defaultEnvironments
+	}
+
+	docEnvironments, err := mapEnvironmentsToDoc(environments)
+
+	if err != nil {
+		return
+	}
+
+	count, err := mgm.Coll(&doc.Project{}).CountDocuments(ctx, bson.M{
+		"organizationId": data.OrganizationID,
+		"slug":           data.Slug,
+	})
+
+	if err != nil {
+		p.logger.WithContext(ctx).WithError(err).Error("error while checking project slug")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if count > 0 {
+		err = errors.ErrProjectExists
+		return
+	}
+
+	docProject := &doc.Project{
+		OrganizationID: data.OrganizationID,
+		Name:           data.Name,
+		Slug:           data.Slug,
+		Description:    data.Description,
+		Environments:   docEnvironments,
+	}
+
+	err = mgm.Coll(docProject).CreateWithCtx(ctx, docProject)
+
+	if err != nil {
+		p.logger.WithContext(ctx).WithError(err).Error("error while creating project")
+		err = errors.ErrUnknown
+		return
+	}
+
+	project = p.MapDocToProject(docProject)
+
+	return
+}
+
+func (p *ProjectSVC) GetByID(ctx context.Context, projectId model.ProjectID) (project model.Project, err error) {
+	docProject, err := p.getDoc(ctx, projectId)
+
+	if err != nil {
+		return
+	}
+
+	project = p.MapDocToProject(docProject)
+
+	return
+}
+
+func (p *ProjectSVC) GetForOrganization(ctx context.Context, orgId model.OrganizationID, userId model.UserID) ([]model.Project, error) {
+	_, err := p.userSvc.GetOrganizationMembership(ctx, userId, orgId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().SetSort(bson.D{
+		{Key: "slug", Value: 1},
+	})
+
+	cursor, err := mgm.Coll(&doc.Project{}).Find(ctx, bson.M{"organizationId": orgId.String()}, findOptions)
+
+	if err != nil {
+		p.logger.WithContext(ctx).WithError(err).Error("error while fetching projects for organization")
+		return nil, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	projects := make([]model.Project, 0)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Project
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			p.logger.WithContext(ctx).WithError(err).Error("error while decoding project doc")
+			continue
+		}
+
+		projects = append(projects, p.MapDocToProject(&curDoc))
+	}
+
+	return projects, nil
+}
+
+// SetEnvironments replaces the environments of the project. Environments which still hold secrets cannot be removed.
+func (p *ProjectSVC) SetEnvironments(ctx context.Context, projectId model.ProjectID, userId model.UserID, environments []model.ProjectEnvironment) (project model.Project, err error) {
+	docProject, err := p.getDoc(ctx, projectId)
+
+	if err != nil {
+		return
+	}
+
+	if !p.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(docProject.OrganizationID)) {
+		err = errors.ErrProjectAccessDenied
+		return
+	}
+
+	docEnvironments, err := mapEnvironmentsToDoc(environments)
+
+	if err != nil {
+		return
+	}
+
+	kept := map[string]bool{}
+
+	for _, env := range docEnvironments {
+		kept[env.Slug] = true
+	}
+
+	for _, env := range docProject.Environments {
+		if kept[env.Slug] {
+			continue
+		}
+
+		count, countErr := mgm.Coll(&doc.Secret{}).CountDocuments(ctx, bson.M{
+			"projectId":   docProject.ID,
+			"environment": env.Slug,
+		})
+
+		if countErr != nil {
+			p.logger.WithContext(ctx).WithError(countErr).Error("error while counting secrets of environment")
+			err = errors.ErrUnknown
+			return
+		}
+
+		if count > 0 {
+			err = errors.ErrProjectNotEmpty
+			return
+		}
+	}
+
+	docProject.Environments = docEnvironments
+
+	err = mgm.Coll(docProject).UpdateWithCtx(ctx, docProject)
+
+	if err != nil {
+		p.logger.WithContext(ctx).WithError(err).Error("error while updating project environments")
+		err = errors.ErrUnknown
+		return
+	}
+
+	project = p.MapDocToProject(docProject)
+
+	return
+}
+
+func (p *ProjectSVC) Delete(ctx context.Context, projectId model.ProjectID, userId model.UserID) (deleted int, err error) {
+	docProject, err := p.getDoc(ctx, projectId)
+
+	if err != nil {
+		return
+	}
+
+	if !p.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(docProject.OrganizationID)) {
+		err = errors.ErrProjectAccessDenied
+		return
+	}
+
+	count, err := mgm.Coll(&doc.Secret{}).CountDocuments(ctx, bson.M{"projectId": docProject.ID})
+
+	if err != nil {
+		p.logger.WithContext(ctx).WithError(err).Error("error while counting secrets of project")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if count > 0 {
+		err = errors.ErrProjectNotEmpty
+		return
+	}
+
+	res, err := mgm.Coll(docProject).DeleteOne(ctx, bson.M{"_id": docProject.ID})
+
+	if err != nil {
+		p.logger.WithContext(ctx).WithError(err).Error("error while deleting project")
+		err = errors.ErrUnknown
+		return
+	}
+
+	deleted = int(res.DeletedCount)
+
+	return
+}
+
+// HasEnvironment reports if the project defines the environment.
+func HasEnvironment(project model.Project, environment string) bool {
+	for _, env := range project.Environments {
+		if env.Slug == environment {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextEnvironment returns the environment which follows the given one in the promotion order.
+func NextEnvironment(project model.Project, environment string) (model.ProjectEnvironment, error) {
+	for i, env := range project.Environments {
+		if env.Slug != environment {
+			continue
+		}
+
+		if i+1 == len(project.Environments) {
+			return model.ProjectEnvironment{}, errors.ErrNoNextEnvironment
+		}
+
+		return project.Environments[i+1], nil
+	}
+
+	return model.ProjectEnvironment{}, errors.ErrEnvironmentNotFound
+}
+
+func (p *ProjectSVC) getDoc(ctx context.Context, projectId model.ProjectID) (*doc.Project, error) {
+	objId, err := primitive.ObjectIDFromHex(projectId.String())
+
+	if err != nil {
+		p.logger.WithContext(ctx).WithError(err).Error("invalid project id found")
+		return nil, errors.ErrInvalidID
+	}
+
+	docProject := &doc.Project{}
+
+	err = mgm.Coll(docProject).FindByID(objId, docProject)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrProjectNotFound
+		}
+		p.logger.WithContext(ctx).WithError(err).Error("error while fetching project")
+		return nil, errors.ErrUnknown
+	}
+
+	return docProject, nil
+}
+
+// mapEnvironmentsToDoc validates the environments and orders them by position.
+func mapEnvironmentsToDoc(environments []model.ProjectEnvironment) ([]doc.ProjectEnvironment, error) {
+	seen := map[string]bool{}
+	docEnvironments := []doc.ProjectEnvironment{}
+
+	for _, env := range environments {
+		if !slugPattern.MatchString(env.Slug) || seen[env.Slug] {
+			return nil, errors.ErrInvalidProject
+		}
+
+		seen[env.Slug] = true
+
+		docEnvironments = append(docEnvironments, doc.ProjectEnvironment{
+			Slug:     env.Slug,
+			Name:     env.Name,
+			Position: env.Position,
+		})
+	}
+
+	if len(docEnvironments) == 0 {
+		return nil, errors.ErrInvalidProject
+	}
+
+	sort.SliceStable(docEnvironments, func(i, j int) bool {
+		return docEnvironments[i].Position < docEnvironments[j].Position
+	})
+
+	return docEnvironments, nil
+}
+
+func (p *ProjectSVC) MapDocToProject(docProject *doc.Project) model.Project {
+	project := model.Project{
+		ID:             model.ProjectID(docProject.ID.Hex()),
+		CreatedAt:      docProject.CreatedAt,
+		UpdatedAt:      docProject.UpdatedAt,
+		OrganizationID: docProject.OrganizationID,
+		Name:           docProject.Name,
+		Slug:           docProject.Slug,
+		Description:    docProject.Description,
+		Environments:   []model.ProjectEnvironment{},
+	}
+
+	for _, env := range docProject.Environments {
+		project.Environments = append(project.Environments, model.ProjectEnvironment{
+			Slug:     env.Slug,
+			Name:     env.Name,
+			Position: env.Position,
+		})
+	}
+
+	return project
+}
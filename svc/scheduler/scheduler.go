@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context)
+}
+
+// Scheduler runs the registered background jobs on a fixed interval.
+type Scheduler struct {
+	logger *logrus.Logger
+	jobs   []job
+}
+
+func New(logger *logrus.Logger) *Scheduler {
+	s := &Scheduler{logger: logger}
+	return s
+}
+
+// Add registers a job. Jobs must be added before the scheduler is started.
+func (s *Scheduler) Add(name string, interval time.Duration, run func(ctx context.Context)) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start runs every job in its own goroutine until the context is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		go s.loop(ctx, j)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.WithField("job", j.name).Debug("stopping scheduled job")
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, j)
+		}
+	}
+}
+
+// runOnce runs the job and keeps the scheduler alive if the job panics.
+func (s *Scheduler) runOnce(ctx context.Context, j job) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.WithField("job", j.name).Errorf("scheduled job panicked: %v", r)
+		}
+	}()
+
+	s.logger.WithField("job", j.name).Debug("running scheduled job")
+	j.run(ctx)
+}
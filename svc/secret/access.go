@@ -0,0 +1,406 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"strings"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SecretAction string
+
+const (
+	ActionRead       SecretAction = "read"
+	ActionUpdate     SecretAction = "update"
+	ActionShare      SecretAction = "share"
+	ActionDelete     SecretAction = "delete"
+	ActionListUsers  SecretAction = "list-users"
+	ActionChangeRole SecretAction = "change-role"
+)
+
+// rolePermissions lists the actions allowed for every secret role.
+// The use-only role is meant for machine identities which only need the value.
+var rolePermissions = map[string]map[SecretAction]bool{
+	model.SecretRoleOwner: {
+		ActionRead:       true,
+		ActionUpdate:     true,
+		ActionShare:      true,
+		ActionDelete:     true,
+		ActionListUsers:  true,
+		ActionChangeRole: true,
+	},
+	model.SecretRoleEditor: {
+		ActionRead:      true,
+		ActionUpdate:    true,
+		ActionListUsers: true,
+	},
+	model.SecretRoleViewer: {
+		ActionRead:      true,
+		ActionListUsers: true,
+	},
+	model.SecretRoleUseOnly: {
+		ActionRead: true,
+	},
+}
+
+// CanPerform reports if the role is allowed to perform the action.
+func CanPerform(role string, action SecretAction) bool {
+	return rolePermissions[role][action]
+}
+
+// IsShareableRole reports if the role can be handed out through a share.
+func IsShareableRole(role string) bool {
+	return role == model.SecretRoleEditor || role == model.SecretRoleViewer || role == model.SecretRoleUseOnly
+}
+
+// isOriginalSecret reports if the doc is the owner copy of a secret.
+// Older documents store a zero object id instead of no reference key.
+func isOriginalSecret(secretDoc *doc.Secret) bool {
+	return secretDoc.ReferenceKey == nil || secretDoc.ReferenceKey.IsZero()
+}
+
+// originalSecretFilter matches the owner copies of the secrets.
+func originalSecretFilter() bson.A {
+	return bson.A{
+		bson.M{"referenceKey": nil},
+		bson.M{"referenceKey": primitive.NilObjectID},
+	}
+}
+
+// getOriginal fetches the owner copy of the secret. The id can point to the original or to any shared copy.
+func (s *SecretsSVC) getOriginal(ctx context.Context, secretId model.SecretID) (*doc.Secret, error) {
+	objId, err := primitive.ObjectIDFromHex(secretId.String())
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("invalid secret id to get original secret")
+		return nil, errors.ErrInvalidID
+	}
+
+	secretDoc := &doc.Secret{}
+
+	err = mgm.Coll(secretDoc).FindByID(objId, secretDoc)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrSecretNotFound
+		}
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching secret by id")
+		return nil, errors.ErrUnknown
+	}
+
+	if isOriginalSecret(secretDoc) {
+		return secretDoc, nil
+	}
+
+	original := &doc.Secret{}
+
+	err = mgm.Coll(original).FindByID(*secretDoc.ReferenceKey, original)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrSecretNotFound
+		}
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching original secret of copy")
+		return nil, errors.ErrUnknown
+	}
+
+	return original, nil
+}
+
+// getUserCopy fetches the shared copy of the original secret which belongs to the user.
+func (s *SecretsSVC) getUserCopy(ctx context.Context, original *doc.Secret, userId model.UserID) (*doc.Secret, error) {
+	copyDoc := &doc.Secret{}
+
+	filter := bson.M{
+		"referenceKey": original.ID,
+		"user.id":      userId.String(),
+	}
+
+	err := mgm.Coll(copyDoc).First(filter, copyDoc)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrSecretUserNotFound
+		}
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching shared copy of secret")
+		return nil, errors.ErrUnknown
+	}
+
+	return copyDoc, nil
+}
+
+// resolveGrant finds the role of the user on the original secret and the doc which grants it.
+// Access comes from owning the original, a direct share, a share made to one of the user's teams
+// or a grant on the collection holding the secret.
+func (s *SecretsSVC) resolveGrant(ctx context.Context, original *doc.Secret, userId model.UserID) (string, *doc.Secret, error) {
+	if userId == "" {
+		return "", nil, errors.ErrSecretAccessDenied
+	}
+
+	if original.User.ID == userId.String() {
+		return model.SecretRoleOwner, original, nil
+	}
+
+	teamIds, err := s.teamSvc.GetActiveTeamIDs(ctx, userId)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	filter := bson.M{
+		"referenceKey": original.ID,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"user.id": userId.String()},
+				bson.M{"team.id": bson.M{"$in": teamIds}},
+			}},
+			bson.M{"$or": activeShareFilter()},
+		},
+	}
+
+	cursor, err := mgm.Coll(original).Find(ctx, filter)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching secret grants of user")
+		return "", nil, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	role := ""
+	var grant *doc.Secret
+
+	// Secrets inside a collection inherit the grants of the collection and its ancestors.
+	if original.CollectionID != nil {
+		collectionRole, err := s.collectionSvc.GrantedRole(ctx, model.CollectionID(original.CollectionID.Hex()), userId)
+
+		if err != nil && err != errors.ErrCollectionNotFound {
+			return "", nil, err
+		}
+
+		if collectionRole != "" {
+			role = collectionRole
+			grant = original
+		}
+	}
+
+	for cursor.Next(ctx) {
+		curDoc := &doc.Secret{}
+
+		err := cursor.Decode(curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret grant")
+			continue
+		}
+
+		curRole := shareRole(curDoc)
+
+		if model.SecretRoleRank(curRole) > model.SecretRoleRank(role) {
+			role = curRole
+			grant = curDoc
+		}
+	}
+
+	if grant == nil {
+		return "", nil, errors.ErrSecretAccessDenied
+	}
+
+	return role, grant, nil
+}
+
+// shareRole is the role a shared copy grants, to its user or to the members of its team.
+func shareRole(copyDoc *doc.Secret) string {
+	role := copyDoc.User.Role
+
+	if copyDoc.Team != nil {
+		role = copyDoc.Team.Role
+	}
+
+	if role == "" {
+		role = model.SecretRoleViewer
+	}
+
+	return role
+}
+
+// resolveRoles finds the roles of the user on many original secrets the way resolveGrant does for one, with
+// the shares and the collection grants of the user looked up once for all of them. Secrets the user has no
+// access to are left out.
+func (s *SecretsSVC) resolveRoles(ctx context.Context, originals []*doc.Secret, userId model.UserID) (map[primitive.ObjectID]string, error) {
+	roles := map[primitive.ObjectID]string{}
+
+	if userId == "" || len(originals) == 0 {
+		return roles, nil
+	}
+
+	originalIds := []primitive.ObjectID{}
+	collectionIds := []primitive.ObjectID{}
+	seenCollections := map[primitive.ObjectID]bool{}
+
+	for _, original := range originals {
+		originalIds = append(originalIds, original.ID)
+
+		if original.CollectionID != nil && !seenCollections[*original.CollectionID] {
+			seenCollections[*original.CollectionID] = true
+			collectionIds = append(collectionIds, *original.CollectionID)
+		}
+	}
+
+	filter, err := s.userSecretsFilter(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter["referenceKey"] = bson.M{"$in": originalIds}
+
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, filter)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching secret grants of user")
+		return nil, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	shared := map[primitive.ObjectID]string{}
+
+	for cursor.Next(ctx) {
+		curDoc := &doc.Secret{}
+
+		err := cursor.Decode(curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret grant")
+			continue
+		}
+
+		curRole := shareRole(curDoc)
+
+		if model.SecretRoleRank(curRole) > model.SecretRoleRank(shared[*curDoc.ReferenceKey]) {
+			shared[*curDoc.ReferenceKey] = curRole
+		}
+	}
+
+	collectionRoles, err := s.collectionSvc.GrantedRoles(ctx, collectionIds, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, original := range originals {
+		if original.User.ID == userId.String() {
+			roles[original.ID] = model.SecretRoleOwner
+			continue
+		}
+
+		role := shared[original.ID]
+
+		if original.CollectionID != nil && model.SecretRoleRank(collectionRoles[*original.CollectionID]) > model.SecretRoleRank(role) {
+			role = collectionRoles[*original.CollectionID]
+		}
+
+		if role != "" {
+			roles[original.ID] = role
+		}
+	}
+
+	return roles, nil
+}
+
+// resolveRole finds the role of the user on the original secret.
+func (s *SecretsSVC) resolveRole(ctx context.Context, original *doc.Secret, userId model.UserID) (string, error) {
+	role, _, err := s.resolveGrant(ctx, original, userId)
+
+	return role, err
+}
+
+// userSecretsFilter matches the secret docs the user holds directly or through a team.
+func (s *SecretsSVC) userSecretsFilter(ctx context.Context, userId model.UserID) (bson.M, error) {
+	teamIds, err := s.teamSvc.GetActiveTeamIDs(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"user.id": userId.String()},
+				bson.M{"team.id": bson.M{"$in": teamIds}},
+			}},
+			bson.M{"$or": activeShareFilter()},
+		},
+	}
+
+	return filter, nil
+}
+
+// authorize resolves the original secret and checks that the user is allowed to perform the action on it.
+func (s *SecretsSVC) authorize(ctx context.Context, secretId model.SecretID, userId model.UserID, action SecretAction) (*doc.Secret, string, error) {
+	original, err := s.getOriginal(ctx, secretId)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	role, err := s.resolveRole(ctx, original, userId)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !CanPerform(role, action) {
+		s.logger.WithContext(ctx).WithField("secretId", original.ID.Hex()).WithField("userId", userId).WithField("action", action).Info("secret action denied for role")
+		return nil, "", errors.ErrSecretAccessDenied
+	}
+
+	return original, role, nil
+}
+
+// Authorize checks that the user is allowed to perform the action on the secret and returns the original secret.
+// It lets other services protect the data they keep for a secret with the same rules, the checkout included:
+// while someone else holds the checkout the secret can neither be read nor changed.
+func (s *SecretsSVC) Authorize(ctx context.Context, secretId model.SecretID, userId model.UserID, action SecretAction) (model.Secret, error) {
+	original, _, err := s.authorize(ctx, secretId, userId, action)
+
+	if err != nil {
+		return model.Secret{}, err
+	}
+
+	if isCheckoutBlocked(original.Checkout, userId) {
+		switch {
+		case action == ActionRead:
+			return model.Secret{}, errors.ErrCheckoutRequired
+		case action == ActionUpdate && original.Checkout.UserID != "":
+			return model.Secret{}, errors.ErrSecretCheckedOut
+		case action == ActionUpdate:
+			return model.Secret{}, errors.ErrNotCheckoutHolder
+		}
+	}
+
+	return s.MapDocToModelSecret(*original), nil
+}
+
+// ResolveAccess returns the original secret and the role the user holds on it. The role is empty when the user has no access.
+func (s *SecretsSVC) ResolveAccess(ctx context.Context, secretId model.SecretID, userId model.UserID) (model.Secret, string, error) {
+	original, err := s.getOriginal(ctx, secretId)
+
+	if err != nil {
+		return model.Secret{}, "", err
+	}
+
+	role, err := s.resolveRole(ctx, original, userId)
+
+	if err != nil && err != errors.ErrSecretAccessDenied {
+		return model.Secret{}, "", err
+	}
+
+	return s.MapDocToModelSecret(*original), role, nil
+}
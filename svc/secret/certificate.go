@@ -0,0 +1,140 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/certificate"
+	"secaas_backend/svc/errors"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultCertificateExpiryDays is how far ahead the certificate inventory looks by default.
+	DefaultCertificateExpiryDays = 30
+	// MaxCertificateExpiryDays bounds the days of the certificate inventory.
+	MaxCertificateExpiryDays = 3650
+
+	// Metadata fields the server derives from the certificate chain.
+	certificateExpiresAtField = "certificateExpiresAt"
+	fingerprintField          = "fingerprint"
+)
+
+// certificateFor parses the certificate chain written with a secret. Only TLS certificates carry one.
+func certificateFor(kind string, raw string) (string, *doc.SecretCertificate, error) {
+	if raw == "" {
+		return "", nil, nil
+	}
+
+	if kind != model.SecretKindTLSCertificate {
+		return "", nil, errors.ErrInvalidCertificateChain
+	}
+
+	chain, info, err := certificate.ParseChain(raw)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	cert := doc.SecretCertificate(info)
+
+	return chain, &cert, nil
+}
+
+// withCertificateMetadata fills the certificate expiry and fingerprint metadata in from the certificate,
+// so they always tell what the chain does.
+func withCertificateMetadata(metadata map[string]string, cert *doc.SecretCertificate) map[string]string {
+	if cert == nil {
+		return metadata
+	}
+
+	filled := map[string]string{}
+
+	for name, value := range metadata {
+		filled[name] = value
+	}
+
+	filled[certificateExpiresAtField] = cert.NotAfter.UTC().Format(time.RFC3339)
+	filled[fingerprintField] = cert.Fingerprint
+
+	return filled
+}
+
+func mapCertificate(cert *doc.SecretCertificate) *model.CertificateInfo {
+	if cert == nil {
+		return nil
+	}
+
+	info := model.CertificateInfo(*cert)
+
+	return &info
+}
+
+// GetExpiringCertificates lists the certificates of the organization the user can see which expire within
+// the days, expired ones included, soonest first.
+func (s *SecretsSVC) GetExpiringCertificates(ctx context.Context, orgId model.OrganizationID, userId model.UserID, days int, params model.PaginationParams) (data []model.ExpiringCertificate, info model.PageInfo, err error) {
+	if days <= 0 || days > MaxCertificateExpiryDays {
+		err = errors.ErrInvalidExpiryDays
+		return
+	}
+
+	accessible, err := s.accessibleSecretsFilter(ctx, orgId, userId)
+
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+
+	filter := bson.M{
+		"$and": bson.A{
+			accessible,
+			bson.M{"certificate.notAfter": bson.M{"$lte": now.AddDate(0, 0, days)}},
+		},
+	}
+
+	findOptions := options.Find().SetLimit(int64(params.Limit + 1)).SetSkip(int64(params.Skip)).SetSort(bson.D{
+		{Key: "certificate.notAfter", Value: 1},
+		{Key: "_id", Value: 1},
+	})
+
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, filter, findOptions)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching expiring certificates")
+		err = errors.ErrUnknown
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	data = []model.ExpiringCertificate{}
+
+	for cursor.Next(ctx) {
+		if len(data) == params.Limit {
+			info.HasMore = true
+			break
+		}
+
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		data = append(data, model.ExpiringCertificate{
+			HealthSecret:  mapHealthSecret(&curDoc),
+			Certificate:   *mapCertificate(curDoc.Certificate),
+			ExpiresInDays: int(curDoc.Certificate.NotAfter.Sub(now).Hours() / 24),
+		})
+	}
+
+	return
+}
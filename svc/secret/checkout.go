@@ -0,0 +1,520 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// DefaultCheckoutLease is the lease in minutes when the policy does not set one.
+	DefaultCheckoutLease = 60
+	// MaxCheckoutLease is the longest lease in minutes a policy can set.
+	MaxCheckoutLease = 24 * 60
+	// CheckoutExpiryCheckInterval is how often the scheduler checks in lapsed leases.
+	CheckoutExpiryCheckInterval = time.Minute
+)
+
+// noHolderFilter matches the checkout secrets nobody holds right now.
+func noHolderFilter() bson.A {
+	return bson.A{
+		bson.M{"checkout.userId": bson.M{"$exists": false}},
+		bson.M{"checkout.userId": ""},
+	}
+}
+
+// isCheckoutBlocked reports if the checkout of the secret keeps the user away from its encrypted data.
+func isCheckoutBlocked(checkout *doc.SecretCheckout, userId model.UserID) bool {
+	return checkout != nil && checkout.Enabled && checkout.UserID != userId.String()
+}
+
+// redactCheckout removes the encrypted data of a listed secret when the user does not hold its checkout.
+func redactCheckout(sec *model.Secret, userId model.UserID) {
+	if sec.Checkout == nil || !sec.Checkout.Enabled {
+		return
+	}
+
+	if sec.Checkout.Holder == nil || sec.Checkout.Holder.UserID != userId {
+		sec.EncryptedData = ""
+	}
+}
+
+// SetCheckoutPolicy turns the checkout model on or off for the secret. Turning it off drops the holder and the queue.
+func (s *SecretsSVC) SetCheckoutPolicy(ctx context.Context, secretId model.SecretID, userId model.UserID, policy model.SecretCheckoutPolicy) (state model.SecretCheckoutState, err error) {
+	leaseMinutes := policy.LeaseMinutes
+
+	if leaseMinutes == 0 {
+		leaseMinutes = DefaultCheckoutLease
+	}
+
+	if leaseMinutes < 0 || leaseMinutes > MaxCheckoutLease {
+		err = errors.ErrInvalidCheckoutLease
+		return
+	}
+
+	original, _, err := s.authorize(ctx, secretId, userId, ActionChangeRole)
+
+	if err != nil {
+		return
+	}
+
+	var update bson.M
+
+	if policy.Enabled {
+		fields := bson.M{
+			"checkout.enabled":      true,
+			"checkout.leaseMinutes": leaseMinutes,
+		}
+
+		if original.Checkout == nil {
+			fields["checkout.queue"] = []doc.SecretCheckoutQueueEntry{}
+		}
+
+		update = bson.M{"$set": fields}
+	} else {
+		update = bson.M{"$unset": bson.M{"checkout": ""}}
+	}
+
+	_, err = mgm.Coll(original).UpdateOne(ctx, bson.M{"_id": original.ID}, update)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("error while setting checkout policy")
+		err = errors.ErrUnknown
+		return
+	}
+
+	return s.syncCheckout(ctx, original.ID)
+}
+
+func (s *SecretsSVC) GetCheckout(ctx context.Context, secretId model.SecretID, userId model.UserID) (state model.SecretCheckoutState, err error) {
+	original, _, err := s.authorize(ctx, secretId, userId, ActionRead)
+
+	if err != nil {
+		return
+	}
+
+	if original.Checkout == nil || !original.Checkout.Enabled {
+		err = errors.ErrCheckoutNotEnabled
+		return
+	}
+
+	state = *mapCheckout(original.Checkout)
+
+	return
+}
+
+// Checkout gives the user the exclusive lease on the secret and returns it with its encrypted data, which
+// counts as a read by the consumer. When someone else holds it the user is queued and queued is true,
+// the lease is handed over on check-in.
+func (s *SecretsSVC) Checkout(ctx context.Context, secretId model.SecretID, userId model.UserID, consumer model.SecretConsumer) (sec model.Secret, queued bool, err error) {
+	consumer, err = checkConsumer(userId, consumer)
+
+	if err != nil {
+		return
+	}
+
+	original, _, err := s.authorize(ctx, secretId, userId, ActionRead)
+
+	if err != nil {
+		return
+	}
+
+	if original.Checkout == nil || !original.Checkout.Enabled {
+		err = errors.ErrCheckoutNotEnabled
+		return
+	}
+
+	if original.Checkout.UserID == userId.String() {
+		sec, err = s.Read(ctx, secretId, userId, consumer)
+		return
+	}
+
+	acquired, err := s.acquireCheckout(ctx, original, userId, false)
+
+	if err != nil {
+		return
+	}
+
+	if acquired {
+		_, err = s.syncCheckout(ctx, original.ID)
+
+		if err != nil {
+			return
+		}
+
+		sec, err = s.Read(ctx, secretId, userId, consumer)
+		return
+	}
+
+	// Join the queue once, the position is kept if the user asks again.
+	filter := bson.M{
+		"_id":                   original.ID,
+		"checkout.enabled":      true,
+		"checkout.queue.userId": bson.M{"$ne": userId.String()},
+	}
+
+	update := bson.M{
+		"$push": bson.M{"checkout.queue": doc.SecretCheckoutQueueEntry{
+			UserID:      userId.String(),
+			RequestedAt: time.Now(),
+		}},
+	}
+
+	_, err = mgm.Coll(original).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("error while queueing secret checkout")
+		err = errors.ErrUnknown
+		return
+	}
+
+	state, err := s.syncCheckout(ctx, original.ID)
+
+	if err != nil {
+		return
+	}
+
+	sec = s.MapDocToModelSecret(*original)
+	sec.EncryptedData = ""
+	sec.Checkout = &state
+	queued = true
+
+	return
+}
+
+// acquireCheckout gives the lease to the user if nobody holds it and the user is first in line.
+func (s *SecretsSVC) acquireCheckout(ctx context.Context, original *doc.Secret, userId model.UserID, fromQueue bool) (bool, error) {
+	leaseMinutes := original.Checkout.LeaseMinutes
+
+	if leaseMinutes <= 0 {
+		leaseMinutes = DefaultCheckoutLease
+	}
+
+	now := time.Now()
+
+	filter := bson.M{
+		"_id":              original.ID,
+		"checkout.enabled": true,
+		"$and": bson.A{
+			bson.M{"$or": noHolderFilter()},
+			bson.M{"$or": bson.A{
+				bson.M{"checkout.queue": bson.M{"$size": 0}},
+				bson.M{"checkout.queue.0.userId": userId.String()},
+			}},
+		},
+	}
+
+	if fromQueue {
+		filter["checkout.queue.0.userId"] = userId.String()
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"checkout.userId":         userId.String(),
+			"checkout.checkedOutAt":   now,
+			"checkout.leaseExpiresAt": now.Add(time.Duration(leaseMinutes) * time.Minute),
+		},
+		"$pull": bson.M{"checkout.queue": bson.M{"userId": userId.String()}},
+	}
+
+	res, err := mgm.Coll(original).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", original.ID.Hex()).WithError(err).Error("error while checking out secret")
+		return false, errors.ErrUnknown
+	}
+
+	return res.ModifiedCount > 0, nil
+}
+
+// Checkin ends the lease of the user. The secret is flagged for rotation and handed to the next user in the queue.
+func (s *SecretsSVC) Checkin(ctx context.Context, secretId model.SecretID, userId model.UserID) (state model.SecretCheckoutState, err error) {
+	original, err := s.getOriginal(ctx, secretId)
+
+	if err != nil {
+		return
+	}
+
+	if original.Checkout == nil || !original.Checkout.Enabled {
+		err = errors.ErrCheckoutNotEnabled
+		return
+	}
+
+	if original.Checkout.UserID != userId.String() {
+		err = errors.ErrNotCheckoutHolder
+		return
+	}
+
+	return s.release(ctx, original, original.Checkout.UserID)
+}
+
+// ForceCheckin ends the lease of the current holder. Only the owner and org admins can force it.
+func (s *SecretsSVC) ForceCheckin(ctx context.Context, secretId model.SecretID, userId model.UserID) (state model.SecretCheckoutState, err error) {
+	original, err := s.getOriginal(ctx, secretId)
+
+	if err != nil {
+		return
+	}
+
+	role, err := s.resolveRole(ctx, original, userId)
+
+	if err != nil && err != errors.ErrSecretAccessDenied {
+		return
+	}
+
+	if role != model.SecretRoleOwner && !s.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(original.OrganizationID)) {
+		err = errors.ErrSecretAccessDenied
+		return
+	}
+
+	if original.Checkout == nil || !original.Checkout.Enabled {
+		err = errors.ErrCheckoutNotEnabled
+		return
+	}
+
+	holderId := original.Checkout.UserID
+
+	if holderId == "" {
+		err = errors.ErrNotCheckoutHolder
+		return
+	}
+
+	state, err = s.release(ctx, original, holderId)
+
+	if err != nil {
+		return
+	}
+
+	s.notificationSvc.Notify(ctx, model.UserID(holderId), model.NotificationCheckoutForced, "Your checkout of a secret was ended", map[string]string{
+		"secretId": original.ID.Hex(),
+		"by":       userId.String(),
+	})
+
+	return
+}
+
+// LeaveCheckoutQueue removes the user from the queue of the secret.
+func (s *SecretsSVC) LeaveCheckoutQueue(ctx context.Context, secretId model.SecretID, userId model.UserID) (state model.SecretCheckoutState, err error) {
+	original, err := s.getOriginal(ctx, secretId)
+
+	if err != nil {
+		return
+	}
+
+	if original.Checkout == nil || !original.Checkout.Enabled {
+		err = errors.ErrCheckoutNotEnabled
+		return
+	}
+
+	_, err = mgm.Coll(original).UpdateOne(ctx, bson.M{"_id": original.ID}, bson.M{
+		"$pull": bson.M{"checkout.queue": bson.M{"userId": userId.String()}},
+	})
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("error while leaving checkout queue")
+		err = errors.ErrUnknown
+		return
+	}
+
+	// The user may have been first in line of a free secret.
+	s.handOff(ctx, original.ID)
+
+	return s.syncCheckout(ctx, original.ID)
+}
+
+// ExpireCheckouts checks in the leases which ran out.
+func (s *SecretsSVC) ExpireCheckouts(ctx context.Context) {
+	filter := bson.M{
+		"checkout.enabled":        true,
+		"checkout.leaseExpiresAt": bson.M{"$lte": time.Now()},
+		"checkout.userId":         bson.M{"$nin": bson.A{nil, ""}},
+	}
+
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, bson.M{"$and": bson.A{filter, bson.M{"$or": originalSecretFilter()}}})
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching lapsed secret checkouts")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		curDoc := &doc.Secret{}
+
+		err = cursor.Decode(curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		_, err = s.release(ctx, curDoc, curDoc.Checkout.UserID)
+
+		if err != nil {
+			continue
+		}
+
+		s.notificationSvc.Notify(ctx, model.UserID(curDoc.Checkout.UserID), model.NotificationCheckoutForced, "Your checkout of a secret ran out", map[string]string{
+			"secretId": curDoc.ID.Hex(),
+		})
+	}
+}
+
+// release ends the lease of the holder, flags the secret for rotation and hands it to the next user in the queue.
+func (s *SecretsSVC) release(ctx context.Context, original *doc.Secret, holderId string) (model.SecretCheckoutState, error) {
+	filter := bson.M{
+		"_id":             original.ID,
+		"checkout.userId": holderId,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"rotationRequired": true,
+			doc.UpdatedAtField: time.Now(),
+		},
+		"$unset": bson.M{
+			"checkout.userId":         "",
+			"checkout.checkedOutAt":   "",
+			"checkout.leaseExpiresAt": "",
+		},
+	}
+
+	res, err := mgm.Coll(original).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", original.ID.Hex()).WithError(err).Error("error while checking in secret")
+		return model.SecretCheckoutState{}, errors.ErrUnknown
+	}
+
+	if res.MatchedCount == 0 {
+		return model.SecretCheckoutState{}, errors.ErrNotCheckoutHolder
+	}
+
+	s.handOff(ctx, original.ID)
+
+	return s.syncCheckout(ctx, original.ID)
+}
+
+// handOff gives a free secret to the first queued user who can still read it.
+func (s *SecretsSVC) handOff(ctx context.Context, originalId primitive.ObjectID) {
+	for {
+		original := &doc.Secret{}
+
+		err := mgm.Coll(original).FindByID(originalId, original)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithField("secretId", originalId.Hex()).WithError(err).Error("error while fetching secret for checkout hand off")
+			return
+		}
+
+		if original.Checkout == nil || !original.Checkout.Enabled || original.Checkout.UserID != "" || len(original.Checkout.Queue) == 0 {
+			return
+		}
+
+		next := model.UserID(original.Checkout.Queue[0].UserID)
+
+		role, err := s.resolveRole(ctx, original, next)
+
+		if err != nil || !CanPerform(role, ActionRead) {
+			// Users who lost access since they queued are skipped.
+			_, err = mgm.Coll(original).UpdateOne(ctx, bson.M{"_id": originalId}, bson.M{
+				"$pull": bson.M{"checkout.queue": bson.M{"userId": next.String()}},
+			})
+
+			if err != nil {
+				s.logger.WithContext(ctx).WithField("secretId", originalId.Hex()).WithError(err).Error("error while skipping queued checkout")
+				return
+			}
+			continue
+		}
+
+		acquired, err := s.acquireCheckout(ctx, original, next, true)
+
+		if err != nil || !acquired {
+			return
+		}
+
+		s.notificationSvc.Notify(ctx, next, model.NotificationCheckoutGranted, "A secret you queued for is now checked out to you", map[string]string{
+			"secretId": originalId.Hex(),
+		})
+
+		return
+	}
+}
+
+// syncCheckout copies the checkout state of the original to its shared copies and returns it.
+func (s *SecretsSVC) syncCheckout(ctx context.Context, originalId primitive.ObjectID) (model.SecretCheckoutState, error) {
+	original := &doc.Secret{}
+
+	err := mgm.Coll(original).FindByID(originalId, original)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", originalId.Hex()).WithError(err).Error("error while fetching secret checkout")
+		return model.SecretCheckoutState{}, errors.ErrUnknown
+	}
+
+	var update bson.M
+
+	if original.Checkout == nil {
+		update = bson.M{
+			"$unset": bson.M{"checkout": ""},
+			"$set":   bson.M{"rotationRequired": original.RotationRequired},
+		}
+	} else {
+		update = bson.M{"$set": bson.M{
+			"checkout":         original.Checkout,
+			"rotationRequired": original.RotationRequired,
+		}}
+	}
+
+	_, err = mgm.Coll(original).UpdateMany(ctx, bson.M{"referenceKey": original.ID}, update)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", originalId.Hex()).WithError(err).Error("error while syncing checkout to shared copies")
+		return model.SecretCheckoutState{}, errors.ErrUnknown
+	}
+
+	s.changed(ctx, original, nil)
+
+	if original.Checkout == nil {
+		return model.SecretCheckoutState{Queue: []model.SecretCheckoutQueueEntry{}}, nil
+	}
+
+	return *mapCheckout(original.Checkout), nil
+}
+
+func mapCheckout(checkout *doc.SecretCheckout) *model.SecretCheckoutState {
+	if checkout == nil {
+		return nil
+	}
+
+	state := &model.SecretCheckoutState{
+		Enabled:      checkout.Enabled,
+		LeaseMinutes: checkout.LeaseMinutes,
+		Queue:        []model.SecretCheckoutQueueEntry{},
+	}
+
+	if checkout.UserID != "" {
+		state.Holder = &model.SecretCheckout{
+			UserID:         model.UserID(checkout.UserID),
+			CheckedOutAt:   checkout.CheckedOutAt,
+			LeaseExpiresAt: checkout.LeaseExpiresAt,
+		}
+	}
+
+	for _, entry := range checkout.Queue {
+		state.Queue = append(state.Queue, model.SecretCheckoutQueueEntry{
+			UserID:      model.UserID(entry.UserID),
+			RequestedAt: entry.RequestedAt,
+		})
+	}
+
+	return state
+}
@@ -0,0 +1,163 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/pagination"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MoveSecret places the secret in a collection, or takes it out of any collection when no id is given.
+// Moving hands the collection grants to the secret, so only owners can do it.
+func (s *SecretsSVC) MoveSecret(ctx context.Context, secretId model.SecretID, userId model.UserID, collectionId *model.CollectionID) (sec model.Secret, err error) {
+	original, _, err := s.authorize(ctx, secretId, userId, ActionShare)
+
+	if err != nil {
+		return
+	}
+
+	target, err := s.collectionPlacement(ctx, collectionId, original.OrganizationID, userId)
+
+	if err != nil {
+		return
+	}
+
+	// The grantees of the old collection lose the secret.
+	previous := s.holders(ctx, original)
+
+	original.CollectionID = target
+
+	err = mgm.Coll(original).UpdateWithCtx(ctx, original)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("error while moving secret to collection")
+		err = errors.ErrUnknown
+		return
+	}
+
+	// The secret may inherit another rotation interval now.
+	err = s.refreshRotationDue(ctx, original)
+
+	if err != nil {
+		return
+	}
+
+	sec = s.MapDocToModelSecret(*original)
+
+	s.changed(ctx, original, previous)
+
+	return
+}
+
+// collectionPlacement checks that the user can place secrets of the organization in the collection.
+// No collection id means no collection.
+func (s *SecretsSVC) collectionPlacement(ctx context.Context, collectionId *model.CollectionID, orgId string, userId model.UserID) (*primitive.ObjectID, error) {
+	if collectionId == nil {
+		return nil, nil
+	}
+
+	targetCollection, err := s.collectionSvc.GetByID(ctx, *collectionId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if targetCollection.OrganizationID != orgId {
+		return nil, errors.ErrOrganizationMismatch
+	}
+
+	role, err := s.collectionSvc.EffectiveRole(ctx, *collectionId, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if model.SecretRoleRank(role) < model.SecretRoleRank(model.SecretRoleEditor) {
+		return nil, errors.ErrCollectionAccessDenied
+	}
+
+	objId, _ := primitive.ObjectIDFromHex(collectionId.String())
+
+	return &objId, nil
+}
+
+// GetSecretsByPath lists the secrets stored in the collection at the path.
+// Recursive listing also includes the secrets of all the nested collections.
+func (s *SecretsSVC) GetSecretsByPath(ctx context.Context, orgId model.OrganizationID, path string, recursive bool, userId model.UserID, params model.PaginationParams) (data []model.Secret, info model.PageInfo, err error) {
+	target, err := s.collectionSvc.GetByPath(ctx, orgId, path)
+
+	if err != nil {
+		return
+	}
+
+	role, err := s.collectionSvc.EffectiveRole(ctx, target.ID, userId)
+
+	if err != nil {
+		return
+	}
+
+	if !CanPerform(role, ActionRead) {
+		err = errors.ErrCollectionAccessDenied
+		return
+	}
+
+	targetId, _ := primitive.ObjectIDFromHex(target.ID.String())
+	collectionIds := []primitive.ObjectID{targetId}
+
+	if recursive {
+		descendants, descErr := s.collectionSvc.GetDescendantIDs(ctx, target.ID)
+
+		if descErr != nil {
+			err = descErr
+			return
+		}
+
+		collectionIds = append(collectionIds, descendants...)
+	}
+
+	secretDoc := &doc.Secret{}
+
+	filter := bson.M{
+		"organizationId": orgId,
+		"collectionId":   bson.M{"$in": collectionIds},
+	}
+
+	cursor, err := pagination.Find(ctx, mgm.Coll(secretDoc), filter, params)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("Error while fetching secrets for collection")
+		err = errors.ErrUnknown
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	data = []model.Secret{}
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		modelSecret := s.MapDocToModelSecret(curDoc)
+		redactCheckout(&modelSecret, userId)
+
+		data = append(data, modelSecret)
+	}
+
+	info = cursor.Info()
+
+	s.RecordAccesses(ctx, data, userId)
+
+	return
+}
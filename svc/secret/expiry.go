@@ -0,0 +1,316 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// ShareExpiryCheckInterval is how often the scheduler looks for lapsed shares.
+	ShareExpiryCheckInterval = time.Minute
+	// ShareExpiryReminderWindow is how long before the lapse the owner and recipient are reminded.
+	ShareExpiryReminderWindow = 24 * time.Hour
+)
+
+// activeShareFilter matches the secret docs whose access has not lapsed yet.
+func activeShareFilter() bson.A {
+	return bson.A{
+		bson.M{"user.accessExpiresAt": bson.M{"$exists": false}},
+		bson.M{"user.accessExpiresAt": bson.M{"$gt": time.Now()}},
+	}
+}
+
+// RenewShare moves the access expiry of the direct share of the member. A temporarily upgraded share keeps its
+// current role until the new expiry and is then revoked, unless keepUpgrade is set: then it still falls back
+// to the role and expiry it had before the upgrade.
+func (s *SecretsSVC) RenewShare(ctx context.Context, secretId model.SecretID, userId model.UserID, memberId model.UserID, accessExpiresAt time.Time, keepUpgrade bool) (sec model.Secret, err error) {
+	if !accessExpiresAt.After(time.Now()) {
+		err = errors.ErrInvalidShareExpiry
+		return
+	}
+
+	original, _, err := s.authorize(ctx, secretId, userId, ActionShare)
+
+	if err != nil {
+		return
+	}
+
+	copyDoc, err := s.getUserCopy(ctx, original, memberId)
+
+	if err != nil {
+		return
+	}
+
+	copyDoc.User.AccessExpiresAt = accessExpiresAt
+	copyDoc.User.ExpiryReminderSent = false
+
+	if !keepUpgrade {
+		copyDoc.User.RestoreRole = ""
+		copyDoc.User.RestoreAccessExpiresAt = time.Time{}
+	}
+
+	err = mgm.Coll(copyDoc).UpdateWithCtx(ctx, copyDoc)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("error while renewing secret share")
+		err = errors.ErrUnknown
+		return
+	}
+
+	sec = s.MapDocToModelSecret(*copyDoc)
+
+	s.changed(ctx, original, nil)
+
+	return
+}
+
+// GetExpiringShares lists the shares of the secrets owned by the user which lapse within the given window.
+func (s *SecretsSVC) GetExpiringShares(ctx context.Context, orgId model.OrganizationID, userId model.UserID, within time.Duration) (data []model.Secret, err error) {
+	if userId == "" {
+		err = errors.ErrInvalidID
+		return
+	}
+
+	secretDoc := &doc.Secret{}
+
+	ownedFilter := bson.M{
+		"organizationId": orgId,
+		"user.id":        userId.String(),
+		"$or":            originalSecretFilter(),
+	}
+
+	ownedIds, err := mgm.Coll(secretDoc).Distinct(ctx, "_id", ownedFilter)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching owned secrets for expiring shares")
+		err = errors.ErrUnknown
+		return
+	}
+
+	data = []model.Secret{}
+
+	if len(ownedIds) == 0 {
+		return
+	}
+
+	now := time.Now()
+
+	filter := bson.M{
+		"referenceKey": bson.M{"$in": ownedIds},
+		"user.accessExpiresAt": bson.M{
+			"$gt":  now,
+			"$lte": now.Add(within),
+		},
+	}
+
+	cursor, err := mgm.Coll(secretDoc).Find(ctx, filter)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching expiring shares")
+		err = errors.ErrUnknown
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding expiring share document")
+			continue
+		}
+
+		data = append(data, s.MapDocToModelSecret(curDoc))
+	}
+
+	return
+}
+
+// RevokeExpiredShares deletes the shared copies whose access has lapsed and notifies the owners.
+// Temporarily upgraded shares fall back to their previous role instead.
+func (s *SecretsSVC) RevokeExpiredShares(ctx context.Context) {
+	logger := s.logger.WithContext(ctx)
+	secretDoc := &doc.Secret{}
+
+	filter := bson.M{
+		"referenceKey":         bson.M{"$ne": nil},
+		"user.accessExpiresAt": bson.M{"$lte": time.Now()},
+	}
+
+	cursor, err := mgm.Coll(secretDoc).Find(ctx, filter)
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching expired shares")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			logger.WithError(err).Error("error while decoding expired share document")
+			continue
+		}
+
+		// Match the expiry again so a renewal made in between is not revoked.
+		deleteFilter := bson.M{
+			"_id":                  curDoc.ID,
+			"user.accessExpiresAt": bson.M{"$lte": time.Now()},
+		}
+
+		if curDoc.User.RestoreRole != "" {
+			s.restoreShare(ctx, &curDoc, deleteFilter)
+			continue
+		}
+
+		res, err := mgm.Coll(secretDoc).DeleteOne(ctx, deleteFilter)
+
+		if err != nil {
+			logger.WithField("secretId", curDoc.ID.Hex()).WithError(err).Error("error while revoking expired share")
+			continue
+		}
+
+		if res.DeletedCount == 0 {
+			continue
+		}
+
+		original := &doc.Secret{}
+
+		err = mgm.Coll(original).FindByID(*curDoc.ReferenceKey, original)
+
+		if err != nil {
+			logger.WithField("secretId", curDoc.ID.Hex()).WithError(err).Error("error while fetching original of revoked share")
+			continue
+		}
+
+		data := map[string]string{
+			"secretId": original.ID.Hex(),
+			"userId":   curDoc.User.ID,
+		}
+
+		s.notificationSvc.Notify(ctx, model.UserID(original.User.ID), model.NotificationShareRevoked, "Access to secret "+original.Name+" has expired and was revoked.", data)
+		s.notificationSvc.Notify(ctx, model.UserID(curDoc.User.ID), model.NotificationShareRevoked, "Your access to secret "+original.Name+" has expired.", data)
+
+		s.changed(ctx, original, []model.UserID{model.UserID(curDoc.User.ID)})
+	}
+}
+
+// restoreShare moves a temporarily upgraded share back to the role and expiry it had before the upgrade.
+func (s *SecretsSVC) restoreShare(ctx context.Context, curDoc *doc.Secret, filter bson.M) {
+	logger := s.logger.WithContext(ctx).WithField("secretId", curDoc.ID.Hex())
+
+	set := bson.M{"user.role": curDoc.User.RestoreRole, "user.expiryReminderSent": false}
+	unset := bson.M{"user.restoreRole": "", "user.restoreAccessExpiresAt": ""}
+
+	if curDoc.User.RestoreAccessExpiresAt.IsZero() {
+		unset["user.accessExpiresAt"] = ""
+	} else {
+		set["user.accessExpiresAt"] = curDoc.User.RestoreAccessExpiresAt
+	}
+
+	res, err := mgm.Coll(curDoc).UpdateOne(ctx, filter, bson.M{"$set": set, "$unset": unset})
+
+	if err != nil {
+		logger.WithError(err).Error("error while restoring upgraded share")
+		return
+	}
+
+	if res.ModifiedCount == 0 {
+		return
+	}
+
+	original := &doc.Secret{}
+
+	err = mgm.Coll(original).FindByID(*curDoc.ReferenceKey, original)
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching original of restored share")
+		return
+	}
+
+	data := map[string]string{
+		"secretId": original.ID.Hex(),
+		"userId":   curDoc.User.ID,
+		"role":     curDoc.User.RestoreRole,
+	}
+
+	s.notificationSvc.Notify(ctx, model.UserID(curDoc.User.ID), model.NotificationShareRevoked, "Your temporary access to secret "+original.Name+" has expired.", data)
+
+	s.changed(ctx, original, nil)
+}
+
+// RemindExpiringShares notifies the owner and the recipient once when a share is about to lapse.
+func (s *SecretsSVC) RemindExpiringShares(ctx context.Context) {
+	logger := s.logger.WithContext(ctx)
+	secretDoc := &doc.Secret{}
+	now := time.Now()
+
+	filter := bson.M{
+		"referenceKey":            bson.M{"$ne": nil},
+		"user.expiryReminderSent": bson.M{"$ne": true},
+		"user.accessExpiresAt": bson.M{
+			"$gt":  now,
+			"$lte": now.Add(ShareExpiryReminderWindow),
+		},
+	}
+
+	cursor, err := mgm.Coll(secretDoc).Find(ctx, filter)
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching expiring shares for reminders")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			logger.WithError(err).Error("error while decoding expiring share document")
+			continue
+		}
+
+		_, err = mgm.Coll(secretDoc).UpdateByID(ctx, curDoc.ID, bson.M{
+			"$set": bson.M{"user.expiryReminderSent": true},
+		})
+
+		if err != nil {
+			logger.WithField("secretId", curDoc.ID.Hex()).WithError(err).Error("error while flagging share expiry reminder")
+			continue
+		}
+
+		original := &doc.Secret{}
+
+		err = mgm.Coll(original).FindByID(*curDoc.ReferenceKey, original)
+
+		if err != nil {
+			logger.WithField("secretId", curDoc.ID.Hex()).WithError(err).Error("error while fetching original of expiring share")
+			continue
+		}
+
+		data := map[string]string{
+			"secretId":        original.ID.Hex(),
+			"userId":          curDoc.User.ID,
+			"accessExpiresAt": curDoc.User.AccessExpiresAt.Format(time.RFC3339),
+		}
+
+		s.notificationSvc.Notify(ctx, model.UserID(original.User.ID), model.NotificationShareExpiring, "A share of secret "+original.Name+" is about to expire.", data)
+		s.notificationSvc.Notify(ctx, model.UserID(curDoc.User.ID), model.NotificationShareExpiring, "Your access to secret "+original.Name+" is about to expire.", data)
+	}
+}
@@ -0,0 +1,265 @@
+package secret
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Bounds of the decoded value fingerprint, from a truncated to a full HMAC-SHA512.
+const (
+	minValueFingerprintBytes = 16
+	maxValueFingerprintBytes = 64
+)
+
+// normalizeValueFingerprint accepts an empty fingerprint or a hex or base64 encoded HMAC, and returns it
+// as lower case hex so equal values match however the clients encode them.
+func normalizeValueFingerprint(fingerprint string) (string, error) {
+	if fingerprint == "" {
+		return "", nil
+	}
+
+	decoded, err := hex.DecodeString(fingerprint)
+
+	if err != nil {
+		decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(fingerprint, "="))
+	}
+
+	if err != nil {
+		decoded, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(fingerprint, "="))
+	}
+
+	if err != nil || len(decoded) < minValueFingerprintBytes || len(decoded) > maxValueFingerprintBytes {
+		return "", errors.ErrInvalidValueFingerprint
+	}
+
+	return hex.EncodeToString(decoded), nil
+}
+
+// healthPolicy reads the health policy of the organization. Organizations without one check nothing but reuse.
+func (s *SecretsSVC) healthPolicy(ctx context.Context, orgId model.OrganizationID) (policy model.HealthPolicy, err error) {
+	objId, err := primitive.ObjectIDFromHex(orgId.String())
+
+	if err != nil {
+		err = errors.ErrInvalidOrganizationID
+		return
+	}
+
+	docOrg := &doc.Organization{}
+
+	err = mgm.Coll(docOrg).FindByIDWithCtx(ctx, objId, docOrg)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			err = errors.ErrOrganizationNotFound
+			return
+		}
+		s.logger.WithContext(ctx).WithField("organizationId", orgId.String()).WithError(err).Error("error while fetching organization for health report")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if docOrg.HealthPolicy != nil {
+		policy = model.HealthPolicy{
+			MaxAgeDays: docOrg.HealthPolicy.MaxAgeDays,
+			MaxShares:  docOrg.HealthPolicy.MaxShares,
+		}
+	}
+
+	return
+}
+
+// GetHealthReport checks the secrets of the organization for reused values, values past their rotation age
+// and shares past the health policy, without decrypting anything. Only admins can see it.
+func (s *SecretsSVC) GetHealthReport(ctx context.Context, orgId model.OrganizationID, userId model.UserID) (report model.HealthReport, err error) {
+	logger := s.logger.WithContext(ctx).WithField("organizationId", orgId.String())
+
+	if orgId == "" {
+		err = errors.ErrInvalidOrganizationID
+		return
+	}
+
+	if !s.userSvc.IsOrganizationAdmin(ctx, userId, orgId) {
+		err = errors.ErrSecretAccessDenied
+		return
+	}
+
+	policy, err := s.healthPolicy(ctx, orgId)
+
+	if err != nil {
+		return
+	}
+
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, bson.M{"organizationId": orgId.String()})
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching secrets for health report")
+		err = errors.ErrUnknown
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	originals := []doc.Secret{}
+	userShares := map[primitive.ObjectID][]string{}
+	teamShares := map[primitive.ObjectID][]string{}
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			logger.WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		switch {
+		case isOriginalSecret(&curDoc):
+			originals = append(originals, curDoc)
+		case curDoc.Team != nil:
+			teamShares[*curDoc.ReferenceKey] = append(teamShares[*curDoc.ReferenceKey], curDoc.Team.ID)
+		default:
+			userShares[*curDoc.ReferenceKey] = append(userShares[*curDoc.ReferenceKey], curDoc.User.ID)
+		}
+	}
+
+	now := time.Now()
+
+	report = model.HealthReport{
+		OrganizationID: orgId.String(),
+		GeneratedAt:    now,
+		Policy:         policy,
+		TotalSecrets:   len(originals),
+		Reused:         []model.ReusedCredential{},
+		Stale:          []model.StaleSecret{},
+		OverShared:     []model.OverSharedSecret{},
+	}
+
+	teamMembers := map[string][]string{}
+	fingerprints := []string{}
+	reused := map[string][]model.HealthSecret{}
+
+	for i := range originals {
+		original := &originals[i]
+		item := mapHealthSecret(original)
+
+		if original.ValueFingerprint != "" {
+			report.Fingerprinted++
+
+			if _, ok := reused[original.ValueFingerprint]; !ok {
+				fingerprints = append(fingerprints, original.ValueFingerprint)
+			}
+
+			reused[original.ValueFingerprint] = append(reused[original.ValueFingerprint], item)
+		}
+
+		if stale, ok := staleSecret(original, item, policy, now); ok {
+			report.Stale = append(report.Stale, stale)
+		}
+
+		if policy.MaxShares <= 0 {
+			continue
+		}
+
+		holders := map[string]bool{}
+
+		for _, holderId := range userShares[original.ID] {
+			holders[holderId] = true
+		}
+
+		for _, teamId := range teamShares[original.ID] {
+			members, ok := teamMembers[teamId]
+
+			if !ok {
+				members = s.activeTeamMembers(ctx, teamId)
+				teamMembers[teamId] = members
+			}
+
+			for _, memberId := range members {
+				holders[memberId] = true
+			}
+		}
+
+		delete(holders, original.User.ID)
+
+		if len(holders) > policy.MaxShares {
+			report.OverShared = append(report.OverShared, model.OverSharedSecret{HealthSecret: item, Shares: len(holders)})
+		}
+	}
+
+	for _, fingerprint := range fingerprints {
+		if len(reused[fingerprint]) > 1 {
+			report.Reused = append(report.Reused, model.ReusedCredential{Secrets: reused[fingerprint]})
+		}
+	}
+
+	return
+}
+
+// staleSecret reports the secret when its rotation is due or required, or its value is older than the max age.
+func staleSecret(original *doc.Secret, item model.HealthSecret, policy model.HealthPolicy, now time.Time) (model.StaleSecret, bool) {
+	lastRotatedAt := original.LastRotatedAt
+
+	if lastRotatedAt.IsZero() {
+		lastRotatedAt = original.CreatedAt
+	}
+
+	overdue := !original.RotationDueAt.IsZero() && !original.RotationDueAt.After(now)
+	tooOld := policy.MaxAgeDays > 0 && !lastRotatedAt.AddDate(0, 0, policy.MaxAgeDays).After(now)
+
+	if !overdue && !tooOld && !original.RotationRequired {
+		return model.StaleSecret{}, false
+	}
+
+	return model.StaleSecret{
+		HealthSecret:     item,
+		LastRotatedAt:    lastRotatedAt,
+		AgeDays:          int(now.Sub(lastRotatedAt).Hours() / 24),
+		RotationDueAt:    original.RotationDueAt,
+		RotationRequired: original.RotationRequired,
+	}, true
+}
+
+// activeTeamMembers lists the members of the team who hold its key.
+func (s *SecretsSVC) activeTeamMembers(ctx context.Context, teamId string) []string {
+	team, err := s.teamSvc.Lookup(ctx, model.TeamID(teamId))
+
+	if err != nil {
+		return nil
+	}
+
+	memberIds := []string{}
+
+	for _, member := range team.Members {
+		if !member.Pending {
+			memberIds = append(memberIds, member.UserID.String())
+		}
+	}
+
+	return memberIds
+}
+
+func mapHealthSecret(original *doc.Secret) model.HealthSecret {
+	item := model.HealthSecret{
+		ID:      model.SecretID(original.ID.Hex()),
+		Name:    original.Name,
+		OwnerID: model.UserID(original.User.ID),
+	}
+
+	if original.CollectionID != nil {
+		collectionId := model.CollectionID(original.CollectionID.Hex())
+		item.CollectionID = &collectionId
+	}
+
+	return item
+}
@@ -0,0 +1,449 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/collection"
+	"secaas_backend/svc/errors"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxImportItems bounds the number of secrets a single import can create.
+const MaxImportItems = 500
+
+// importProfiles is the registry of the export formats the clients can translate into secrets.
+var importProfiles = []model.SecretImportProfile{
+	{
+		Name:   "bitwarden",
+		Label:  "Bitwarden JSON",
+		Format: model.ImportFormatJSON,
+		Fields: []model.SecretImportField{
+			{Source: "name", Target: model.ImportTargetName},
+			{Source: "folder", Target: model.ImportTargetTags},
+			{Source: "login.uris.0.uri", Target: model.ImportTargetMetadata + "url"},
+		},
+		SecretFields: []string{"login.username", "login.password", "login.totp", "notes", "fields"},
+		TypeField:    "type",
+		Types: map[string]string{
+			"1": model.SecretKindLogin,
+			"2": model.SecretKindNote,
+		},
+		DefaultType: model.SecretKindNote,
+	},
+	{
+		Name:   "1password-csv",
+		Label:  "1Password CSV",
+		Format: model.ImportFormatCSV,
+		Fields: []model.SecretImportField{
+			{Source: "Title", Target: model.ImportTargetName},
+			{Source: "Url", Target: model.ImportTargetMetadata + "url"},
+			{Source: "Tags", Target: model.ImportTargetTags},
+		},
+		SecretFields: []string{"Username", "Password", "OTPAuth", "Notes"},
+		DefaultType:  model.SecretKindLogin,
+	},
+	{
+		Name:   "dotenv",
+		Label:  "dotenv file",
+		Format: model.ImportFormatDotenv,
+		Fields: []model.SecretImportField{
+			{Source: "key", Target: model.ImportTargetName},
+			{Source: "comment", Target: model.ImportTargetDescription},
+		},
+		SecretFields: []string{"value"},
+		DefaultType:  model.SecretKindNote,
+	},
+}
+
+// GetImportProfiles lists the registered import profiles.
+func (s *SecretsSVC) GetImportProfiles() []model.SecretImportProfile {
+	return importProfiles
+}
+
+func findImportProfile(name string) (model.SecretImportProfile, bool) {
+	for _, profile := range importProfiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+
+	return model.SecretImportProfile{}, false
+}
+
+// applyImportProfile fills the empty fields of the item from its source record.
+func applyImportProfile(profile model.SecretImportProfile, item model.SecretImportItem) (model.SecretImportItem, error) {
+	for _, field := range profile.SecretFields {
+		if _, ok := item.Source[field]; ok {
+			return item, errors.ErrSecretImportPlaintext
+		}
+	}
+
+	for _, field := range profile.Fields {
+		value := strings.TrimSpace(item.Source[field.Source])
+
+		if value == "" {
+			continue
+		}
+
+		switch {
+		case field.Target == model.ImportTargetName && item.Name == "":
+			item.Name = value
+		case field.Target == model.ImportTargetDescription && item.Description == "":
+			item.Description = value
+		case field.Target == model.ImportTargetTags && len(item.Tags) == 0:
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					item.Tags = append(item.Tags, tag)
+				}
+			}
+		case strings.HasPrefix(field.Target, model.ImportTargetMetadata):
+			key := strings.TrimPrefix(field.Target, model.ImportTargetMetadata)
+
+			if item.Metadata == nil {
+				item.Metadata = map[string]string{}
+			}
+
+			if item.Metadata[key] == "" {
+				item.Metadata[key] = value
+			}
+		}
+	}
+
+	if item.Type == "" && profile.TypeField != "" {
+		item.Type = profile.Types[item.Source[profile.TypeField]]
+	}
+
+	if item.Type == "" {
+		item.Type = profile.DefaultType
+	}
+
+	return item, nil
+}
+
+// importPlacementKey identifies where a name has to be unique, the project environment or the organization.
+func importPlacementKey(projectId *primitive.ObjectID, environment string, name string) string {
+	if projectId == nil {
+		return "/" + name
+	}
+
+	return projectId.Hex() + "/" + environment + "/" + name
+}
+
+// ImportSecrets creates a batch of client encrypted secrets owned by the user. Every item is validated
+// like a single create, and names which already exist in the organization or repeat in the batch are duplicates.
+// Atomic imports return ErrSecretImportAborted with the report when any item fails and create nothing.
+func (s *SecretsSVC) ImportSecrets(ctx context.Context, userId model.UserID, data model.SecretImport) (report model.SecretImportReport, err error) {
+	logger := s.logger.WithContext(ctx).WithField("organizationId", data.OrganizationID)
+
+	if data.OrganizationID == "" {
+		err = errors.ErrInvalidOrganizationID
+		return
+	}
+
+	if len(data.Items) == 0 || len(data.Items) > MaxImportItems {
+		err = errors.ErrInvalidSecretImport
+		return
+	}
+
+	var profile model.SecretImportProfile
+
+	if data.Profile != "" {
+		var ok bool
+
+		profile, ok = findImportProfile(data.Profile)
+
+		if !ok {
+			err = errors.ErrInvalidSecretImport
+			return
+		}
+	}
+
+	_, err = s.userSvc.GetOrganizationMembership(ctx, userId, model.OrganizationID(data.OrganizationID))
+
+	if err != nil {
+		return
+	}
+
+	creatorEmail := data.CreatorEmail
+
+	if creatorEmail == "" {
+		creator, userErr := s.userSvc.GetByID(ctx, userId)
+
+		if userErr != nil {
+			err = userErr
+			return
+		}
+
+		creatorEmail = creator.Email.String()
+	}
+
+	items := make([]model.SecretImportItem, len(data.Items))
+	itemErrs := make([]error, len(data.Items))
+	names := []string{}
+
+	for i, item := range data.Items {
+		if data.Profile != "" {
+			item, itemErrs[i] = applyImportProfile(profile, item)
+		}
+
+		items[i] = item
+		names = append(names, item.Name)
+	}
+
+	existing, err := s.existingPlacements(ctx, data.OrganizationID, names)
+
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+
+	report.Items = make([]model.SecretImportItemResult, len(items))
+	docs := make([]*doc.Secret, len(items))
+
+	for i, item := range items {
+		report.Items[i] = model.SecretImportItemResult{Index: i, Name: item.Name}
+
+		itemErr := itemErrs[i]
+
+		if itemErr == nil {
+			docs[i], itemErr = s.prepareImport(ctx, userId, data.OrganizationID, creatorEmail, item, now)
+		}
+
+		if itemErr == nil {
+			key := importPlacementKey(docs[i].ProjectID, docs[i].Environment, docs[i].Name)
+
+			if existing[key] {
+				itemErr = errors.ErrSecretNameExists
+			}
+
+			existing[key] = true
+		}
+
+		if itemErr == errors.ErrSecretNameExists && data.SkipDuplicates {
+			docs[i] = nil
+			report.Items[i].Status = model.ImportItemSkipped
+			report.Skipped++
+			continue
+		}
+
+		if itemErr != nil {
+			docs[i] = nil
+			report.Items[i].Status = model.ImportItemFailed
+			report.Items[i].Error = itemErr.Error()
+			report.Failed++
+		}
+	}
+
+	if data.Atomic {
+		if report.Failed > 0 {
+			for i := range report.Items {
+				if docs[i] != nil {
+					report.Items[i].Status = model.ImportItemAborted
+				}
+			}
+
+			err = errors.ErrSecretImportAborted
+			return
+		}
+
+		err = s.insertImportAtomic(ctx, docs)
+
+		if err != nil {
+			return
+		}
+	}
+
+	for i, docSecret := range docs {
+		if docSecret == nil {
+			continue
+		}
+
+		if !data.Atomic {
+			createErr := mgm.Coll(docSecret).CreateWithCtx(ctx, docSecret)
+
+			if createErr != nil {
+				logger.WithField("index", i).WithError(createErr).Error("error while creating imported secret")
+				report.Items[i].Status = model.ImportItemFailed
+				report.Items[i].Error = errors.ErrUnknown.Error()
+				report.Failed++
+				continue
+			}
+		}
+
+		sec := s.MapDocToModelSecret(*docSecret)
+
+		report.Items[i].Status = model.ImportItemCreated
+		report.Items[i].Secret = &sec
+		report.Created++
+
+		s.changed(ctx, docSecret, nil)
+	}
+
+	return
+}
+
+// prepareImport validates the item and builds the original secret document for it.
+func (s *SecretsSVC) prepareImport(ctx context.Context, userId model.UserID, orgId string, creatorEmail string, item model.SecretImportItem, now time.Time) (*doc.Secret, error) {
+	if item.EncryptedData == "" || strings.TrimSpace(item.Name) == "" {
+		return nil, errors.ErrInvalidSecretImportItem
+	}
+
+	if item.RotationIntervalDays < 0 || item.RotationIntervalDays > collection.MaxRotationIntervalDays {
+		return nil, errors.ErrInvalidRotationPolicy
+	}
+
+	fingerprint, err := normalizeValueFingerprint(item.ValueFingerprint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	kind, metadata, err := checkKind(item.Type, item.Metadata, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	placement := model.Secret{
+		Name:           item.Name,
+		OrganizationID: orgId,
+		ProjectID:      item.ProjectID,
+		Environment:    item.Environment,
+	}
+
+	projectId, err := s.validateProjectPlacement(ctx, placement, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	collectionId, err := s.collectionPlacement(ctx, item.CollectionID, orgId, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	docSecret := &doc.Secret{
+		EncryptedData: item.EncryptedData,
+		User: doc.SecretUser{
+			ID:   userId.String(),
+			Role: model.SecretRoleOwner,
+		},
+		Name:                 item.Name,
+		Description:          item.Description,
+		Tags:                 item.Tags,
+		CreatorEmail:         creatorEmail,
+		Type:                 kind,
+		Metadata:             metadata,
+		ExpiresAt:            item.ExpiresAt,
+		OrganizationID:       orgId,
+		ProjectID:            projectId,
+		CollectionID:         collectionId,
+		RotationIntervalDays: item.RotationIntervalDays,
+		LastRotatedAt:        now,
+		Version:              1,
+		ValueFingerprint:     fingerprint,
+	}
+
+	if projectId != nil {
+		docSecret.Environment = item.Environment
+	}
+
+	interval, err := s.rotationInterval(ctx, docSecret)
+
+	if err != nil {
+		return nil, err
+	}
+
+	docSecret.RotationDueAt = rotationDue(now, now, interval)
+
+	docSecret.Signature, err = s.verifySignature(ctx, docSecret, userId, item.Signature, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return docSecret, nil
+}
+
+// existingPlacements returns the placement keys of the original secrets of the organization with one of the names.
+func (s *SecretsSVC) existingPlacements(ctx context.Context, orgId string, names []string) (map[string]bool, error) {
+	filter := bson.M{
+		"organizationId": orgId,
+		"name":           bson.M{"$in": names},
+		"$or":            originalSecretFilter(),
+	}
+
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, filter)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching existing secret names for import")
+		return nil, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	existing := map[string]bool{}
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		existing[importPlacementKey(curDoc.ProjectID, curDoc.Environment, curDoc.Name)] = true
+	}
+
+	return existing, nil
+}
+
+// insertImportAtomic inserts all the documents at once. The database has no transactions, so the documents
+// which made it in are removed again when the insert fails. InsertMany skips the mgm hooks, the ids and
+// timestamps are set here so the cleanup and the callers see the stored documents.
+func (s *SecretsSVC) insertImportAtomic(ctx context.Context, docs []*doc.Secret) error {
+	insertDocs := []interface{}{}
+	ids := bson.A{}
+
+	for _, docSecret := range docs {
+		if docSecret == nil {
+			continue
+		}
+
+		docSecret.ID = primitive.NewObjectID()
+		docSecret.Creating()
+		docSecret.Saving()
+		insertDocs = append(insertDocs, docSecret)
+		ids = append(ids, docSecret.ID)
+	}
+
+	if len(insertDocs) == 0 {
+		return nil
+	}
+
+	_, err := mgm.Coll(&doc.Secret{}).InsertMany(ctx, insertDocs)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while inserting imported secrets")
+
+		_, cleanupErr := mgm.Coll(&doc.Secret{}).DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+
+		if cleanupErr != nil {
+			s.logger.WithContext(ctx).WithError(cleanupErr).Error("error while removing partially imported secrets")
+		}
+
+		return errors.ErrUnknown
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package secret
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxMetadataValueLength bounds every metadata value, metadata is public and must stay small.
+const maxMetadataValueLength = 1024
+
+// fingerprintPattern accepts the OpenSSH style fingerprints (SHA256:base64) and colon separated hex digests.
+var fingerprintPattern = regexp.MustCompile(`^(SHA256:[A-Za-z0-9+/]{43}=?|MD5:([0-9a-fA-F]{2}:){15}[0-9a-fA-F]{2}|([0-9a-fA-F]{2}:)+[0-9a-fA-F]{2})$`)
+
+// secretKinds is the registry of the kinds of secrets and the schema of their public metadata.
+var secretKinds = []model.SecretKind{
+	{
+		Name:  model.SecretKindLogin,
+		Label: "Login",
+		Fields: []model.SecretKindField{
+			{Name: "url", Label: "URL", Format: model.MetadataFormatURL},
+			{Name: "usernameHint", Label: "Username hint", Format: model.MetadataFormatText},
+		},
+	},
+	{
+		Name:  model.SecretKindAPIKey,
+		Label: "API key",
+		Fields: []model.SecretKindField{
+			{Name: "url", Label: "Service URL", Format: model.MetadataFormatURL},
+			{Name: "keyId", Label: "Key ID", Format: model.MetadataFormatText},
+		},
+	},
+	{
+		Name:  model.SecretKindSSHKey,
+		Label: "SSH key",
+		Fields: []model.SecretKindField{
+			{Name: "host", Label: "Host", Format: model.MetadataFormatHost},
+			{Name: "port", Label: "Port", Format: model.MetadataFormatPort},
+			{Name: "usernameHint", Label: "Username hint", Format: model.MetadataFormatText},
+			{Name: "fingerprint", Label: "Key fingerprint", Format: model.MetadataFormatFingerprint, Required: true},
+		},
+	},
+	{
+		Name:  model.SecretKindTLSCertificate,
+		Label: "TLS certificate",
+		Fields: []model.SecretKindField{
+			{Name: "host", Label: "Host", Format: model.MetadataFormatHost},
+			{Name: "fingerprint", Label: "Certificate fingerprint", Format: model.MetadataFormatFingerprint},
+			{Name: "certificateExpiresAt", Label: "Certificate expiry", Format: model.MetadataFormatDateTime, Required: true},
+		},
+	},
+	{
+		Name:  model.SecretKindDatabaseCredential,
+		Label: "Database credential",
+		Fields: []model.SecretKindField{
+			{Name: "host", Label: "Host", Format: model.MetadataFormatHost, Required: true},
+			{Name: "port", Label: "Port", Format: model.MetadataFormatPort},
+			{Name: "database", Label: "Database", Format: model.MetadataFormatText},
+			{Name: "usernameHint", Label: "Username hint", Format: model.MetadataFormatText},
+		},
+	},
+	{
+		Name:   model.SecretKindNote,
+		Label:  "Secure note",
+		Fields: []model.SecretKindField{},
+	},
+}
+
+// GetKinds lists the registered secret kinds.
+func (s *SecretsSVC) GetKinds() []model.SecretKind {
+	return secretKinds
+}
+
+// checkKind validates the kind and metadata of a secret being written.
+// Secrets without a kind are notes, secrets created before the registry keep their kind as long as it is not changed.
+func checkKind(kindName string, metadata map[string]string, previousKind string) (string, map[string]string, error) {
+	if kindName == "" {
+		kindName = model.SecretKindNote
+	}
+
+	if _, ok := findKind(kindName); !ok && kindName == previousKind && len(metadata) == 0 {
+		return kindName, nil, nil
+	}
+
+	cleaned, err := validateMetadata(kindName, metadata)
+
+	return kindName, cleaned, err
+}
+
+// findKind looks up the kind in the registry.
+func findKind(name string) (model.SecretKind, bool) {
+	for _, kind := range secretKinds {
+		if kind.Name == name {
+			return kind, true
+		}
+	}
+
+	return model.SecretKind{}, false
+}
+
+// validateMetadata checks the metadata against the schema of the kind and returns the cleaned metadata.
+// Unknown fields are rejected so nothing sensitive ends up stored in plain text by mistake.
+func validateMetadata(kindName string, metadata map[string]string) (map[string]string, error) {
+	kind, ok := findKind(kindName)
+
+	if !ok {
+		return nil, errors.ErrInvalidSecretKind
+	}
+
+	fields := map[string]model.SecretKindField{}
+
+	for _, field := range kind.Fields {
+		fields[field.Name] = field
+	}
+
+	cleaned := map[string]string{}
+
+	for name, value := range metadata {
+		field, ok := fields[name]
+
+		if !ok {
+			return nil, errors.ErrInvalidSecretMetadata
+		}
+
+		value = strings.TrimSpace(value)
+
+		if value == "" {
+			continue
+		}
+
+		if len(value) > maxMetadataValueLength || !isValidMetadataValue(field.Format, value) {
+			return nil, errors.ErrInvalidSecretMetadata
+		}
+
+		cleaned[name] = value
+	}
+
+	for _, field := range kind.Fields {
+		if field.Required && cleaned[field.Name] == "" {
+			return nil, errors.ErrInvalidSecretMetadata
+		}
+	}
+
+	if len(cleaned) == 0 {
+		return nil, nil
+	}
+
+	return cleaned, nil
+}
+
+func isValidMetadataValue(format string, value string) bool {
+	switch format {
+	case model.MetadataFormatText:
+		return true
+	case model.MetadataFormatURL:
+		parsed, err := url.Parse(value)
+		return err == nil && parsed.Scheme != "" && parsed.Host != ""
+	case model.MetadataFormatHost:
+		return net.ParseIP(value) != nil || !strings.ContainsAny(value, "/ :@?#")
+	case model.MetadataFormatPort:
+		port, err := strconv.Atoi(value)
+		return err == nil && port > 0 && port <= 65535
+	case model.MetadataFormatFingerprint:
+		return fingerprintPattern.MatchString(value)
+	case model.MetadataFormatDateTime:
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	}
+
+	return false
+}
@@ -0,0 +1,279 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"strconv"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// OwnerHook is called after the ownership of a secret changed, with the id of the original secret and its new owner.
+type OwnerHook func(ctx context.Context, secretId model.SecretID, ownerId model.UserID)
+
+// RegisterOwnerHook adds a hook which updates the data other services keep about the owner of a secret.
+func (s *SecretsSVC) RegisterOwnerHook(hook OwnerHook) {
+	s.ownerHooks = append(s.ownerHooks, hook)
+}
+
+// TransferOwnership hands the secret to another member of its organization. The owner and org admins can transfer it.
+// Shares of other users and teams are kept as they are.
+func (s *SecretsSVC) TransferOwnership(ctx context.Context, secretId model.SecretID, userId model.UserID, transfer model.SecretOwnershipTransfer) (sec model.Secret, err error) {
+	if transfer.NewOwnerID == "" || (transfer.PreviousOwnerRole != "" && !IsShareableRole(transfer.PreviousOwnerRole)) {
+		err = errors.ErrInvalidOwnershipTransfer
+		return
+	}
+
+	original, err := s.getOriginal(ctx, secretId)
+
+	if err != nil {
+		return
+	}
+
+	role, err := s.resolveRole(ctx, original, userId)
+
+	if err != nil && err != errors.ErrSecretAccessDenied {
+		return
+	}
+
+	if role != model.SecretRoleOwner && !s.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(original.OrganizationID)) {
+		err = errors.ErrSecretAccessDenied
+		return
+	}
+
+	err = s.transferOwnership(ctx, original, userId, transfer.NewOwnerID, transfer.PreviousOwnerRole, nil)
+
+	if err != nil {
+		return
+	}
+
+	sec = s.MapDocToModelSecret(*original)
+
+	return
+}
+
+// ReassignOwnership transfers every secret of the organization owned by one user to another, for members
+// who leave the organization. Only org admins can reassign and the previous owner keeps no access.
+func (s *SecretsSVC) ReassignOwnership(ctx context.Context, orgId model.OrganizationID, userId model.UserID, reassignment model.SecretOwnershipReassignment) (result model.SecretOwnershipReassignmentResult, err error) {
+	logger := s.logger.WithContext(ctx).WithField("organizationId", orgId.String())
+
+	if orgId == "" {
+		err = errors.ErrInvalidOrganizationID
+		return
+	}
+
+	if reassignment.FromUserID == "" || reassignment.ToUserID == "" || reassignment.FromUserID == reassignment.ToUserID {
+		err = errors.ErrInvalidOwnershipTransfer
+		return
+	}
+
+	if !s.userSvc.IsOrganizationAdmin(ctx, userId, orgId) {
+		err = errors.ErrSecretAccessDenied
+		return
+	}
+
+	_, err = s.userSvc.GetOrganizationMembership(ctx, reassignment.ToUserID, orgId)
+
+	if err != nil {
+		return
+	}
+
+	filter := bson.M{
+		"organizationId": orgId.String(),
+		"user.id":        reassignment.FromUserID.String(),
+		"$or":            originalSecretFilter(),
+	}
+
+	// Load the secrets up front since every transfer changes the documents the cursor walks over.
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, filter)
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching secrets to reassign")
+		err = errors.ErrUnknown
+		return
+	}
+
+	originals := []doc.Secret{}
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			logger.WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		originals = append(originals, curDoc)
+	}
+
+	cursor.Close(ctx)
+
+	result = model.SecretOwnershipReassignmentResult{
+		Transferred: []model.SecretID{},
+		Failed:      []model.SecretID{},
+	}
+
+	data := map[string]string{"reassignment": "true"}
+
+	for i := range originals {
+		curId := model.SecretID(originals[i].ID.Hex())
+
+		transferErr := s.transferOwnership(ctx, &originals[i], userId, reassignment.ToUserID, "", data)
+
+		if transferErr != nil {
+			logger.WithField("secretId", curId.String()).WithError(transferErr).Error("error while reassigning secret owner")
+			result.Failed = append(result.Failed, curId)
+			continue
+		}
+
+		result.Transferred = append(result.Transferred, curId)
+	}
+
+	s.auditSvc.Record(ctx, model.AuditEvent{
+		OrganizationID: orgId.String(),
+		ActorID:        userId,
+		Action:         model.AuditSecretOwnersReassigned,
+		TargetType:     model.AuditTargetUser,
+		TargetID:       reassignment.FromUserID.String(),
+		Data: map[string]string{
+			"toUserId":    reassignment.ToUserID.String(),
+			"transferred": strconv.Itoa(len(result.Transferred)),
+			"failed":      strconv.Itoa(len(result.Failed)),
+		},
+	})
+
+	return
+}
+
+// transferOwnership moves the original secret to the new owner. The new owner's own share is dropped since
+// owning the original supersedes it, and the previous owner keeps a share only when a role is given.
+func (s *SecretsSVC) transferOwnership(ctx context.Context, original *doc.Secret, actorId model.UserID, newOwnerId model.UserID, previousOwnerRole string, auditData map[string]string) error {
+	logger := s.logger.WithContext(ctx).WithField("secretId", original.ID.Hex())
+
+	previousOwnerId := original.User.ID
+
+	if previousOwnerId == newOwnerId.String() {
+		return errors.ErrInvalidOwnershipTransfer
+	}
+
+	_, err := s.userSvc.GetOrganizationMembership(ctx, newOwnerId, model.OrganizationID(original.OrganizationID))
+
+	if err != nil {
+		return err
+	}
+
+	holders := s.holders(ctx, original)
+
+	// Only move the secret if nobody transferred it in the meantime.
+	filter := bson.M{
+		"_id":     original.ID,
+		"user.id": previousOwnerId,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"user": doc.SecretUser{
+				ID:   newOwnerId.String(),
+				Role: model.SecretRoleOwner,
+			},
+		},
+	}
+
+	res, err := mgm.Coll(original).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		logger.WithError(err).Error("error while transferring secret owner")
+		return errors.ErrUnknown
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.ErrSecretNotFound
+	}
+
+	original.User = doc.SecretUser{ID: newOwnerId.String(), Role: model.SecretRoleOwner}
+
+	_, err = mgm.Coll(original).DeleteMany(ctx, bson.M{"referenceKey": original.ID, "user.id": newOwnerId.String()})
+
+	if err != nil {
+		logger.WithError(err).Error("error while removing share of new secret owner")
+		return errors.ErrUnknown
+	}
+
+	if previousOwnerRole != "" {
+		refKey := original.ID
+
+		copyDoc := &doc.Secret{
+			Description:   original.Description,
+			EncryptedData: original.EncryptedData,
+			CreatorEmail:  original.CreatorEmail,
+			ReferenceKey:  &refKey,
+			User: doc.SecretUser{
+				ID:   previousOwnerId,
+				Role: previousOwnerRole,
+			},
+			Tags:                 original.Tags,
+			OrganizationID:       original.OrganizationID,
+			ExpiresAt:            original.ExpiresAt,
+			Name:                 original.Name,
+			Type:                 original.Type,
+			Metadata:             original.Metadata,
+			Checkout:             original.Checkout,
+			RotationRequired:     original.RotationRequired,
+			RotationIntervalDays: original.RotationIntervalDays,
+			LastRotatedAt:        original.LastRotatedAt,
+			RotationDueAt:        original.RotationDueAt,
+			Version:              original.Version,
+			Signature:            original.Signature,
+			CertificateChain:     original.CertificateChain,
+			Certificate:          original.Certificate,
+		}
+
+		err = mgm.Coll(copyDoc).CreateWithCtx(ctx, copyDoc)
+
+		if err != nil {
+			logger.WithError(err).Error("error while sharing secret with previous owner")
+			return errors.ErrSecretShareFailed
+		}
+	}
+
+	data := map[string]string{
+		"fromUserId": previousOwnerId,
+		"toUserId":   newOwnerId.String(),
+	}
+
+	if previousOwnerRole != "" {
+		data["previousOwnerRole"] = previousOwnerRole
+	}
+
+	for key, value := range auditData {
+		data[key] = value
+	}
+
+	s.auditSvc.Record(ctx, model.AuditEvent{
+		OrganizationID: original.OrganizationID,
+		ActorID:        actorId,
+		Action:         model.AuditSecretOwnershipTransfer,
+		TargetType:     model.AuditTargetSecret,
+		TargetID:       original.ID.Hex(),
+		Data:           data,
+	})
+
+	for _, hook := range s.ownerHooks {
+		hook(ctx, model.SecretID(original.ID.Hex()), newOwnerId)
+	}
+
+	s.changed(ctx, original, holders)
+
+	s.notificationSvc.Notify(ctx, newOwnerId, model.NotificationOwnershipTransferred, "You are now the owner of a secret", map[string]string{
+		"secretId":       original.ID.Hex(),
+		"organizationId": original.OrganizationID,
+		"fromUserId":     previousOwnerId,
+	})
+
+	return nil
+}
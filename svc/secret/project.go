@@ -0,0 +1,253 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/project"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// validateProjectPlacement checks that the secret can live in its project environment.
+// Secret names are unique inside an environment so the same name can exist once per environment.
+func (s *SecretsSVC) validateProjectPlacement(ctx context.Context, data model.Secret, excludeId *primitive.ObjectID) (*primitive.ObjectID, error) {
+	if data.ProjectID == nil {
+		return nil, nil
+	}
+
+	targetProject, err := s.projectSvc.GetByID(ctx, *data.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if targetProject.OrganizationID != data.OrganizationID {
+		return nil, errors.ErrOrganizationMismatch
+	}
+
+	if !project.HasEnvironment(targetProject, data.Environment) {
+		return nil, errors.ErrEnvironmentNotFound
+	}
+
+	if data.Name == "" {
+		return nil, errors.ErrInvalidProject
+	}
+
+	projectId, _ := primitive.ObjectIDFromHex(data.ProjectID.String())
+
+	filter := bson.M{
+		"projectId":   projectId,
+		"environment": data.Environment,
+		"name":        data.Name,
+		"$or":         originalSecretFilter(),
+	}
+
+	if excludeId != nil {
+		filter["_id"] = bson.M{"$ne": *excludeId}
+	}
+
+	count, err := mgm.Coll(&doc.Secret{}).CountDocuments(ctx, filter)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while checking secret name in environment")
+		return nil, errors.ErrUnknown
+	}
+
+	if count > 0 {
+		return nil, errors.ErrSecretNameExists
+	}
+
+	return &projectId, nil
+}
+
+// GetEnvironmentBundle returns every secret of the project environment which the user can read.
+func (s *SecretsSVC) GetEnvironmentBundle(ctx context.Context, projectId model.ProjectID, environment string, userId model.UserID) (bundle model.EnvironmentBundle, err error) {
+	targetProject, err := s.projectSvc.GetByID(ctx, projectId)
+
+	if err != nil {
+		return
+	}
+
+	if !project.HasEnvironment(targetProject, environment) {
+		err = errors.ErrEnvironmentNotFound
+		return
+	}
+
+	_, err = s.userSvc.GetOrganizationMembership(ctx, userId, model.OrganizationID(targetProject.OrganizationID))
+
+	if err != nil {
+		return
+	}
+
+	objId, _ := primitive.ObjectIDFromHex(projectId.String())
+
+	filter := bson.M{
+		"projectId":   objId,
+		"environment": environment,
+		"$or":         originalSecretFilter(),
+	}
+
+	findOptions := options.Find().SetSort(bson.D{
+		{Key: "name", Value: 1},
+	})
+
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, filter, findOptions)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching secrets of environment")
+		err = errors.ErrUnknown
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	bundle = model.EnvironmentBundle{
+		ProjectID:   projectId,
+		ProjectSlug: targetProject.Slug,
+		Environment: environment,
+		GeneratedAt: time.Now(),
+		Secrets:     []model.Secret{},
+	}
+
+	originals := []*doc.Secret{}
+
+	for cursor.Next(ctx) {
+		curDoc := &doc.Secret{}
+
+		err := cursor.Decode(curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		originals = append(originals, curDoc)
+	}
+
+	// The grants of the user are resolved once for the whole environment, not once per secret.
+	roles, err := s.resolveRoles(ctx, originals, userId)
+
+	if err != nil {
+		return
+	}
+
+	for _, original := range originals {
+		role := roles[original.ID]
+
+		if !CanPerform(role, ActionRead) {
+			continue
+		}
+
+		modelSecret := s.MapDocToModelSecret(*original)
+		modelSecret.User = model.SecretUser{
+			ID:   userId,
+			Role: role,
+		}
+		redactCheckout(&modelSecret, userId)
+
+		bundle.Secrets = append(bundle.Secrets, modelSecret)
+	}
+
+	s.RecordAccesses(ctx, bundle.Secrets, userId)
+
+	return
+}
+
+// PromoteSecret copies the secret to the target environment of its project, or to the next one when no target is given.
+// A secret with the same name in the target environment is updated, otherwise a new one owned by the user is created.
+// Only users who can update the source can promote it, a checked out source only by the holder of its checkout.
+func (s *SecretsSVC) PromoteSecret(ctx context.Context, projectId model.ProjectID, secretId model.SecretID, userId model.UserID, targetEnvironment string, sig *model.SecretSignature) (sec model.Secret, err error) {
+	source, _, err := s.authorize(ctx, secretId, userId, ActionUpdate)
+
+	if err != nil {
+		return
+	}
+
+	if isCheckoutBlocked(source.Checkout, userId) {
+		err = errors.ErrCheckoutRequired
+		return
+	}
+
+	if source.ProjectID == nil || source.ProjectID.Hex() != projectId.String() {
+		err = errors.ErrProjectNotFound
+		return
+	}
+
+	targetProject, err := s.projectSvc.GetByID(ctx, projectId)
+
+	if err != nil {
+		return
+	}
+
+	if targetEnvironment == "" {
+		next, nextErr := project.NextEnvironment(targetProject, source.Environment)
+
+		if nextErr != nil {
+			err = nextErr
+			return
+		}
+
+		targetEnvironment = next.Slug
+	}
+
+	if !project.HasEnvironment(targetProject, targetEnvironment) || targetEnvironment == source.Environment {
+		err = errors.ErrEnvironmentNotFound
+		return
+	}
+
+	existing := &doc.Secret{}
+
+	filter := bson.M{
+		"projectId":   source.ProjectID,
+		"environment": targetEnvironment,
+		"name":        source.Name,
+		"$or":         originalSecretFilter(),
+	}
+
+	err = mgm.Coll(existing).First(filter, existing)
+
+	if err == nil {
+		update := s.MapDocToModelSecret(*existing)
+		update.EncryptedData = source.EncryptedData
+		update.Description = source.Description
+		update.Tags = source.Tags
+		update.Type = source.Type
+		update.Metadata = source.Metadata
+		update.CertificateChain = source.CertificateChain
+		// Mapped secrets leave the fingerprint out, the target takes the one of the value it gets.
+		update.ValueFingerprint = source.ValueFingerprint
+		// The signature of the source covers its own version and author, the promoter signs the target.
+		update.Signature = sig
+
+		return s.Update(ctx, model.SecretID(existing.ID.Hex()), userId, update, nil)
+	}
+
+	if !strings.Contains(err.Error(), "no documents") {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching promotion target secret")
+		err = errors.ErrUnknown
+		return
+	}
+
+	promoter, err := s.userSvc.GetByID(ctx, userId)
+
+	if err != nil {
+		return
+	}
+
+	promoted := s.MapDocToModelSecret(*source)
+	promoted.User = model.SecretUser{ID: userId}
+	promoted.CreatorEmail = promoter.Email.String()
+	promoted.Environment = targetEnvironment
+	promoted.CollectionID = nil
+	promoted.ValueFingerprint = source.ValueFingerprint
+	promoted.Signature = sig
+
+	return s.Create(ctx, promoted)
+}
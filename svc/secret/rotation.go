@@ -0,0 +1,336 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/collection"
+	"secaas_backend/svc/errors"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// RotationReminderLead is how long before the due date the owner is first reminded.
+	RotationReminderLead = 3 * 24 * time.Hour
+	// RotationReminderEvery is how often the owner is reminded again while the rotation is due.
+	RotationReminderEvery = 7 * 24 * time.Hour
+	// RotationCheckInterval is how often the scheduler sends rotation reminders.
+	RotationCheckInterval = time.Hour
+)
+
+// rotationDue returns when a value last rotated at lastRotatedAt must be rotated again. Secrets from before
+// rotation tracking count from their creation. The zero time means no rotation is due.
+func rotationDue(lastRotatedAt time.Time, createdAt time.Time, days int) time.Time {
+	if days <= 0 {
+		return time.Time{}
+	}
+
+	base := lastRotatedAt
+
+	if base.IsZero() {
+		base = createdAt
+	}
+
+	return base.AddDate(0, 0, days)
+}
+
+// rotationInterval returns the interval of the secret, or the one it inherits from its collection.
+func (s *SecretsSVC) rotationInterval(ctx context.Context, original *doc.Secret) (int, error) {
+	if original.RotationIntervalDays > 0 || original.CollectionID == nil {
+		return original.RotationIntervalDays, nil
+	}
+
+	return s.collectionSvc.RotationIntervalDays(ctx, model.CollectionID(original.CollectionID.Hex()))
+}
+
+// refreshRotationDue recomputes the due date of the secret and copies it to the shared copies.
+func (s *SecretsSVC) refreshRotationDue(ctx context.Context, original *doc.Secret) error {
+	interval, err := s.rotationInterval(ctx, original)
+
+	if err != nil {
+		return err
+	}
+
+	return s.setRotationDue(ctx, original, rotationDue(original.LastRotatedAt, original.CreatedAt, interval))
+}
+
+func (s *SecretsSVC) setRotationDue(ctx context.Context, original *doc.Secret, dueAt time.Time) error {
+	if dueAt.Equal(original.RotationDueAt) {
+		return nil
+	}
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"_id": original.ID},
+			bson.M{"referenceKey": original.ID},
+		},
+	}
+
+	var update bson.M
+
+	if dueAt.IsZero() {
+		update = bson.M{"$unset": bson.M{"rotationDueAt": "", "rotationRemindedAt": ""}}
+	} else {
+		update = bson.M{
+			"$set":   bson.M{"rotationDueAt": dueAt},
+			"$unset": bson.M{"rotationRemindedAt": ""},
+		}
+	}
+
+	_, err := mgm.Coll(original).UpdateMany(ctx, filter, update)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", original.ID.Hex()).WithError(err).Error("error while updating rotation due date")
+		return errors.ErrUnknown
+	}
+
+	original.RotationDueAt = dueAt
+
+	return nil
+}
+
+// SetRotationPolicy sets the rotation interval of the secret. Zero makes the secret follow its collection again.
+func (s *SecretsSVC) SetRotationPolicy(ctx context.Context, secretId model.SecretID, userId model.UserID, policy model.RotationPolicy) (sec model.Secret, err error) {
+	if policy.IntervalDays < 0 || policy.IntervalDays > collection.MaxRotationIntervalDays {
+		err = errors.ErrInvalidRotationPolicy
+		return
+	}
+
+	original, role, err := s.authorize(ctx, secretId, userId, ActionUpdate)
+
+	if err != nil {
+		return
+	}
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"_id": original.ID},
+			bson.M{"referenceKey": original.ID},
+		},
+	}
+
+	_, err = mgm.Coll(original).UpdateMany(ctx, filter, bson.M{"$set": bson.M{"rotationIntervalDays": policy.IntervalDays}})
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("error while setting rotation policy")
+		err = errors.ErrUnknown
+		return
+	}
+
+	original.RotationIntervalDays = policy.IntervalDays
+
+	err = s.refreshRotationDue(ctx, original)
+
+	if err != nil {
+		return
+	}
+
+	sec = s.MapDocToModelSecret(*original)
+	sec.User = model.SecretUser{ID: userId, Role: role}
+	redactCheckout(&sec, userId)
+
+	s.changed(ctx, original, nil)
+
+	return
+}
+
+// refreshCollectionRotation recomputes the due dates of the secrets in the collection and its nested collections.
+func (s *SecretsSVC) refreshCollectionRotation(ctx context.Context, collectionId model.CollectionID) {
+	logger := s.logger.WithContext(ctx).WithField("collectionId", collectionId.String())
+
+	rootId, err := primitive.ObjectIDFromHex(collectionId.String())
+
+	if err != nil {
+		return
+	}
+
+	collectionIds, err := s.collectionSvc.GetDescendantIDs(ctx, collectionId)
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching nested collections for rotation refresh")
+		return
+	}
+
+	collectionIds = append(collectionIds, rootId)
+
+	// Secrets with their own interval do not depend on the collections.
+	filter := bson.M{
+		"collectionId":         bson.M{"$in": collectionIds},
+		"rotationIntervalDays": bson.M{"$not": bson.M{"$gt": 0}},
+		"$or":                  originalSecretFilter(),
+	}
+
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, filter)
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching secrets for rotation refresh")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	intervals := map[primitive.ObjectID]int{}
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			logger.WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		interval, ok := intervals[*curDoc.CollectionID]
+
+		if !ok {
+			interval, err = s.collectionSvc.RotationIntervalDays(ctx, model.CollectionID(curDoc.CollectionID.Hex()))
+
+			if err != nil {
+				continue
+			}
+
+			intervals[*curDoc.CollectionID] = interval
+		}
+
+		dueAt := rotationDue(curDoc.LastRotatedAt, curDoc.CreatedAt, interval)
+
+		if dueAt.Equal(curDoc.RotationDueAt) {
+			continue
+		}
+
+		err = s.setRotationDue(ctx, &curDoc, dueAt)
+
+		if err != nil {
+			logger.WithField("secretId", curDoc.ID.Hex()).WithError(err).Error("error while refreshing rotation due date")
+			continue
+		}
+
+		s.changed(ctx, &curDoc, nil)
+	}
+}
+
+// GetOverdueRotations lists the secrets of the organization the user can see whose rotation is due, oldest due first.
+// Secrets flagged for rotation after a checkout are included as well.
+func (s *SecretsSVC) GetOverdueRotations(ctx context.Context, orgId model.OrganizationID, userId model.UserID, params model.PaginationParams) (data []model.Secret, info model.PageInfo, err error) {
+	accessible, err := s.accessibleSecretsFilter(ctx, orgId, userId)
+
+	if err != nil {
+		return
+	}
+
+	filter := bson.M{
+		"$and": bson.A{
+			accessible,
+			bson.M{"$or": bson.A{
+				bson.M{"rotationDueAt": bson.M{"$lte": time.Now()}},
+				bson.M{"rotationRequired": true},
+			}},
+		},
+	}
+
+	findOptions := options.Find().SetLimit(int64(params.Limit + 1)).SetSkip(int64(params.Skip)).SetSort(bson.D{
+		{Key: "rotationDueAt", Value: 1},
+		{Key: "_id", Value: 1},
+	})
+
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, filter, findOptions)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching overdue rotations")
+		err = errors.ErrUnknown
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	data = []model.Secret{}
+
+	for cursor.Next(ctx) {
+		if len(data) == params.Limit {
+			info.HasMore = true
+			break
+		}
+
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		modelSecret := s.MapDocToModelSecret(curDoc)
+		redactCheckout(&modelSecret, userId)
+
+		data = append(data, modelSecret)
+	}
+
+	return
+}
+
+// RemindRotations notifies the owners of the secrets whose rotation is due soon or overdue.
+// Overdue secrets are reminded again every RotationReminderEvery until they are rotated.
+func (s *SecretsSVC) RemindRotations(ctx context.Context) {
+	now := time.Now()
+
+	remindable := bson.A{
+		bson.M{"rotationRemindedAt": bson.M{"$exists": false}},
+		bson.M{"rotationRemindedAt": bson.M{"$lte": now.Add(-RotationReminderEvery)}},
+	}
+
+	filter := bson.M{
+		"rotationDueAt": bson.M{"$lte": now.Add(RotationReminderLead)},
+		"$and": bson.A{
+			bson.M{"$or": originalSecretFilter()},
+			bson.M{"$or": remindable},
+		},
+	}
+
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, filter)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching secrets to remind of rotation")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		// Claim the reminder first so a second scheduler does not send it again.
+		res, err := mgm.Coll(&curDoc).UpdateOne(ctx, bson.M{"_id": curDoc.ID, "$or": remindable}, bson.M{
+			"$set": bson.M{"rotationRemindedAt": now},
+		})
+
+		if err != nil || res.ModifiedCount == 0 {
+			continue
+		}
+
+		message := "A secret you own is due for rotation soon"
+
+		if !curDoc.RotationDueAt.After(now) {
+			message = "A secret you own is overdue for rotation"
+		}
+
+		s.notificationSvc.Notify(ctx, model.UserID(curDoc.User.ID), model.NotificationRotationDue, message, map[string]string{
+			"secretId":       curDoc.ID.Hex(),
+			"organizationId": curDoc.OrganizationID,
+			"dueAt":          curDoc.RotationDueAt.Format(time.RFC3339),
+		})
+	}
+}
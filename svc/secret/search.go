@@ -0,0 +1,215 @@
+package secret
+
+import (
+	"context"
+	"regexp"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// searchSorts maps the sort options of the search to mongo sort documents.
+// Relevance is only available with a full text query.
+var searchSorts = map[string]bson.D{
+	"name":       {{Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+	"-name":      {{Key: "name", Value: -1}, {Key: "_id", Value: -1}},
+	"createdAt":  {{Key: doc.CreatedAtField, Value: 1}, {Key: "_id", Value: 1}},
+	"-createdAt": {{Key: doc.CreatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+	"updatedAt":  {{Key: doc.UpdatedAtField, Value: 1}, {Key: "_id", Value: 1}},
+	"-updatedAt": {{Key: doc.UpdatedAtField, Value: -1}, {Key: "_id", Value: -1}},
+}
+
+// accessibleSecretsFilter matches the secret docs of the organization the user can see.
+// Org admins see every original, other members see what they hold directly, through a team or through a collection grant.
+func (s *SecretsSVC) accessibleSecretsFilter(ctx context.Context, orgId model.OrganizationID, userId model.UserID) (bson.M, error) {
+	membership, err := s.userSvc.GetOrganizationMembership(ctx, userId, orgId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if membership.IsAdmin {
+		return bson.M{
+			"organizationId": orgId.String(),
+			"$or":            originalSecretFilter(),
+		}, nil
+	}
+
+	held, err := s.userSecretsFilter(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	collectionIds, err := s.collectionSvc.GetGrantedIDs(ctx, orgId, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Originals the user already holds a copy of are only matched through the copy, so they show once.
+	heldIds, err := mgm.Coll(&doc.Secret{}).Distinct(ctx, "referenceKey", bson.M{
+		"organizationId": orgId.String(),
+		"$and":           bson.A{held, bson.M{"referenceKey": bson.M{"$ne": nil}}},
+	})
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching secrets held by user")
+		return nil, errors.ErrUnknown
+	}
+
+	if heldIds == nil {
+		heldIds = []interface{}{}
+	}
+
+	filter := bson.M{
+		"organizationId": orgId.String(),
+		"$or": bson.A{
+			held,
+			bson.M{
+				"collectionId": bson.M{"$in": collectionIds},
+				"_id":          bson.M{"$nin": heldIds},
+				"$or":          originalSecretFilter(),
+			},
+		},
+	}
+
+	return filter, nil
+}
+
+// Search finds the secrets the user can access by tags, name, description, type and dates.
+func (s *SecretsSVC) Search(ctx context.Context, userId model.UserID, search model.SecretSearch, params model.PaginationParams) (data []model.Secret, info model.PageInfo, err error) {
+	if search.OrganizationID == "" {
+		err = errors.ErrInvalidOrganizationID
+		return
+	}
+
+	accessFilter, err := s.accessibleSecretsFilter(ctx, model.OrganizationID(search.OrganizationID), userId)
+
+	if err != nil {
+		return
+	}
+
+	conditions := bson.A{accessFilter}
+
+	if search.NamePrefix != "" {
+		// Anchored and case sensitive so the name index can serve the prefix.
+		conditions = append(conditions, bson.M{"name": bson.M{"$regex": "^" + regexp.QuoteMeta(search.NamePrefix)}})
+	}
+
+	if len(search.Tags) > 0 {
+		switch search.TagMode {
+		case "", model.TagModeAll:
+			conditions = append(conditions, bson.M{"tags": bson.M{"$all": search.Tags}})
+		case model.TagModeAny:
+			conditions = append(conditions, bson.M{"tags": bson.M{"$in": search.Tags}})
+		default:
+			err = errors.ErrInvalidSearch
+			return
+		}
+	}
+
+	if len(search.Types) > 0 {
+		conditions = append(conditions, bson.M{"type": bson.M{"$in": search.Types}})
+	}
+
+	if dateFilter := rangeFilter(search.CreatedAfter.IsZero(), search.CreatedBefore.IsZero(), search.CreatedAfter, search.CreatedBefore); dateFilter != nil {
+		conditions = append(conditions, bson.M{doc.CreatedAtField: dateFilter})
+	}
+
+	if dateFilter := rangeFilter(search.UpdatedAfter.IsZero(), search.UpdatedBefore.IsZero(), search.UpdatedAfter, search.UpdatedBefore); dateFilter != nil {
+		conditions = append(conditions, bson.M{doc.UpdatedAtField: dateFilter})
+	}
+
+	filter := bson.M{
+		"$and": conditions,
+	}
+
+	findOptions := options.Find().SetLimit(int64(params.Limit + 1)).SetSkip(int64(params.Skip))
+
+	if search.Query != "" {
+		filter["$text"] = bson.M{"$search": search.Query}
+	}
+
+	switch {
+	case search.Sort == "" && search.Query != "", search.Sort == "relevance":
+		if search.Query == "" {
+			err = errors.ErrInvalidSearch
+			return
+		}
+
+		findOptions.SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}})
+		findOptions.SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: -1}})
+	case search.Sort == "":
+		findOptions.SetSort(searchSorts["-updatedAt"])
+	default:
+		sort, ok := searchSorts[search.Sort]
+
+		if !ok {
+			err = errors.ErrInvalidSearch
+			return
+		}
+
+		findOptions.SetSort(sort)
+	}
+
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, filter, findOptions)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("Error while searching secrets")
+		err = errors.ErrUnknown
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	data = []model.Secret{}
+
+	for cursor.Next(ctx) {
+		if len(data) == params.Limit {
+			info.HasMore = true
+			break
+		}
+
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		modelSecret := s.MapDocToModelSecret(curDoc)
+		redactCheckout(&modelSecret, userId)
+
+		data = append(data, modelSecret)
+	}
+
+	s.RecordAccesses(ctx, data, userId)
+
+	return
+}
+
+// rangeFilter builds a date range condition, it returns nil when both bounds are missing.
+func rangeFilter(noFrom bool, noTo bool, from interface{}, to interface{}) bson.M {
+	if noFrom && noTo {
+		return nil
+	}
+
+	dateFilter := bson.M{}
+
+	if !noFrom {
+		dateFilter["$gte"] = from
+	}
+
+	if !noTo {
+		dateFilter["$lte"] = to
+	}
+
+	return dateFilter
+}
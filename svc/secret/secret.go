@@ -4,25 +4,51 @@ import (
 	"context"
 	"secaas_backend/db/doc"
 	"secaas_backend/model"
+	"secaas_backend/svc/audit"
+	"secaas_backend/svc/collection"
 	"secaas_backend/svc/errors"
+	"secaas_backend/svc/notification"
+	"secaas_backend/svc/pagination"
+	"secaas_backend/svc/project"
+	"secaas_backend/svc/team"
 	"secaas_backend/svc/user"
+	"time"
 
 	"github.com/kamva/mgm/v3"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type SecretsSVC struct {
-	logger  *logrus.Logger
-	userSvc *user.UserSVC
+	logger          *logrus.Logger
+	userSvc         *user.UserSVC
+	teamSvc         *team.TeamSVC
+	collectionSvc   *collection.CollectionSVC
+	projectSvc      *project.ProjectSVC
+	notificationSvc *notification.NotificationSVC
+	auditSvc        *audit.AuditSVC
+	deleteHooks     []DeleteHook
+	ownerHooks      []OwnerHook
+	changeHooks     []ChangeHook
 }
 
-func New(logger *logrus.Logger, userSvc *user.UserSVC) *SecretsSVC {
-	u := &SecretsSVC{logger: logger, userSvc: userSvc}
+// DeleteHook is called after a secret and all its copies are deleted, with the id of the original secret.
+type DeleteHook func(ctx context.Context, secretId model.SecretID)
+
+func New(logger *logrus.Logger, userSvc *user.UserSVC, teamSvc *team.TeamSVC, collectionSvc *collection.CollectionSVC, projectSvc *project.ProjectSVC, notificationSvc *notification.NotificationSVC, auditSvc *audit.AuditSVC) *SecretsSVC {
+	u := &SecretsSVC{logger: logger, userSvc: userSvc, teamSvc: teamSvc, collectionSvc: collectionSvc, projectSvc: projectSvc, notificationSvc: notificationSvc, auditSvc: auditSvc}
+
+	collectionSvc.RegisterPolicyHook(u.refreshCollectionRotation)
+	collectionSvc.RegisterGrantHook(u.collectionGrantsChanged)
+
 	return u
 }
+
+// RegisterDeleteHook adds a hook which cleans up the data other services keep for a secret.
+func (s *SecretsSVC) RegisterDeleteHook(hook DeleteHook) {
+	s.deleteHooks = append(s.deleteHooks, hook)
+}
+
 func (s *SecretsSVC) GetListForUser(ctx context.Context, userId model.UserID, organizationId string, params model.PaginationParams) (sec model.Secret, err error) {
 
 	if userId == "" {
@@ -41,21 +67,70 @@ func (s *SecretsSVC) GetListForUser(ctx context.Context, userId model.UserID, or
 }
 
 func (s *SecretsSVC) Create(ctx context.Context, data model.Secret) (sec model.Secret, err error) {
-	objRefId, _ := primitive.ObjectIDFromHex(*data.ReferenceKey)
+	chain, cert, err := certificateFor(data.Type, data.CertificateChain)
+
+	if err != nil {
+		return
+	}
+
+	kind, metadata, err := checkKind(data.Type, withCertificateMetadata(data.Metadata, cert), "")
+
+	if err != nil {
+		return
+	}
+
+	projectId, err := s.validateProjectPlacement(ctx, data, nil)
+
+	if err != nil {
+		return
+	}
+
+	if data.RotationIntervalDays < 0 || data.RotationIntervalDays > collection.MaxRotationIntervalDays {
+		err = errors.ErrInvalidRotationPolicy
+		return
+	}
+
+	fingerprint, err := normalizeValueFingerprint(data.ValueFingerprint)
+
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+
+	// The creator always owns the original copy, shared copies are only made through ShareSecret.
 	docSecret := &doc.Secret{
 		EncryptedData: data.EncryptedData,
 		User: doc.SecretUser{
 			ID:   string(data.User.ID),
-			Role: data.User.Role,
+			Role: model.SecretRoleOwner,
 		},
-		Name:           data.Name,
-		Description:    data.Description,
-		Tags:           data.Tags,
-		CreatorEmail:   data.CreatorEmail,
-		Type:           data.Type,
-		ReferenceKey:   &objRefId,
-		ExpiresAt:      data.ExpiresAt,
-		OrganizationID: data.OrganizationID,
+		Name:                 data.Name,
+		Description:          data.Description,
+		Tags:                 data.Tags,
+		CreatorEmail:         data.CreatorEmail,
+		Type:                 kind,
+		Metadata:             metadata,
+		ExpiresAt:            data.ExpiresAt,
+		OrganizationID:       data.OrganizationID,
+		ProjectID:            projectId,
+		RotationIntervalDays: data.RotationIntervalDays,
+		LastRotatedAt:        now,
+		RotationDueAt:        rotationDue(now, now, data.RotationIntervalDays),
+		Version:              1,
+		ValueFingerprint:     fingerprint,
+		CertificateChain:     chain,
+		Certificate:          cert,
+	}
+
+	if projectId != nil {
+		docSecret.Environment = data.Environment
+	}
+
+	docSecret.Signature, err = s.verifySignature(ctx, docSecret, data.User.ID, data.Signature, false)
+
+	if err != nil {
+		return
 	}
 
 	err = mgm.Coll(docSecret).Create(docSecret)
@@ -64,47 +139,414 @@ func (s *SecretsSVC) Create(ctx context.Context, data model.Secret) (sec model.S
 		err = errors.ErrUnknown
 		return
 	}
-	docRefKey := docSecret.ReferenceKey.Hex()
-	sec = model.Secret{
-		ID:            model.SecretID(docSecret.ID.Hex()),
-		EncryptedData: docSecret.EncryptedData,
-		CreatedAt:     docSecret.CreatedAt,
-		UpdatedAt:     docSecret.UpdatedAt,
-		Name:          docSecret.Name,
-		User: model.SecretUser{
-			ID:   model.UserID(docSecret.User.ID),
-			Role: docSecret.User.Role,
+
+	sec = s.MapDocToModelSecret(*docSecret)
+
+	s.changed(ctx, docSecret, nil)
+
+	return
+}
+
+func (s *SecretsSVC) GetByID(ctx context.Context, secretId model.SecretID, userId model.UserID) (sec model.Secret, err error) {
+	original, err := s.getOriginal(ctx, secretId)
+
+	if err != nil {
+		return
+	}
+
+	role, grant, err := s.resolveGrant(ctx, original, userId)
+
+	if err != nil {
+		return
+	}
+
+	if !CanPerform(role, ActionRead) {
+		err = errors.ErrSecretAccessDenied
+		return
+	}
+
+	if isCheckoutBlocked(original.Checkout, userId) {
+		err = errors.ErrCheckoutRequired
+		return
+	}
+
+	sec = s.MapDocToModelSecret(*grant)
+	sec.User.ID = userId
+	sec.User.Role = role
+	sec.Version = original.Version
+
+	return
+}
+
+// GetVersion returns the current version of the secret to a user who can read it, checked out or not.
+func (s *SecretsSVC) GetVersion(ctx context.Context, secretId model.SecretID, userId model.UserID) (version int64, err error) {
+	original, _, err := s.authorize(ctx, secretId, userId, ActionRead)
+
+	if err != nil {
+		return
+	}
+
+	version = original.Version
+
+	return
+}
+
+// versionFilter matches the original secret while it still has the version. Secrets from before
+// versioning have no version field and count as version zero.
+func versionFilter(original *doc.Secret, version int64) bson.M {
+	if version == 0 {
+		return bson.M{"_id": original.ID, "version": bson.M{"$in": bson.A{nil, 0}}}
+	}
+
+	return bson.M{"_id": original.ID, "version": version}
+}
+
+// Update changes the secret and moves it to the next version. With an expected version the update
+// fails with ErrVersionConflict unless the secret still has that version.
+func (s *SecretsSVC) Update(ctx context.Context, secretId model.SecretID, userId model.UserID, data model.Secret, expectedVersion *int64) (sec model.Secret, err error) {
+	logger := s.logger.WithContext(ctx).WithField("secretId", secretId.String())
+
+	original, _, err := s.authorize(ctx, secretId, userId, ActionUpdate)
+
+	if err != nil {
+		return
+	}
+
+	// A checked out secret can only be changed by its holder.
+	if isCheckoutBlocked(original.Checkout, userId) {
+		err = errors.ErrNotCheckoutHolder
+
+		if original.Checkout.UserID != "" {
+			err = errors.ErrSecretCheckedOut
+		}
+		return
+	}
+
+	chain, cert, err := certificateFor(data.Type, data.CertificateChain)
+
+	if err != nil {
+		return
+	}
+
+	// A TLS certificate keeps its chain until a new one is written.
+	if chain == "" && data.Type == model.SecretKindTLSCertificate {
+		chain, cert = original.CertificateChain, original.Certificate
+	}
+
+	kind, metadata, err := checkKind(data.Type, withCertificateMetadata(data.Metadata, cert), original.Type)
+
+	if err != nil {
+		return
+	}
+
+	fingerprint, err := normalizeValueFingerprint(data.ValueFingerprint)
+
+	if err != nil {
+		return
+	}
+
+	// Keep the name unique inside the project environment.
+	if original.ProjectID != nil && data.Name != original.Name {
+		placement := s.MapDocToModelSecret(*original)
+		placement.Name = data.Name
+
+		_, err = s.validateProjectPlacement(ctx, placement, &original.ID)
+
+		if err != nil {
+			return
+		}
+	}
+
+	if expectedVersion != nil && *expectedVersion != original.Version {
+		err = errors.ErrVersionConflict
+		return
+	}
+
+	// The signature covers the secret as it will be after the update, with the version it is about to get.
+	signed := *original
+	signed.Version = original.Version + 1
+	signed.Name = data.Name
+	signed.Description = data.Description
+	signed.Tags = data.Tags
+	signed.Type = kind
+	signed.Metadata = metadata
+	signed.ExpiresAt = data.ExpiresAt
+	signed.CertificateChain = chain
+	signed.Certificate = cert
+
+	if data.EncryptedData != "" {
+		signed.EncryptedData = data.EncryptedData
+	}
+
+	sig, err := s.verifySignature(ctx, &signed, userId, data.Signature, original.Signature != nil)
+
+	if err != nil {
+		return
+	}
+
+	// Claim the next version first so a concurrent update of the same version loses.
+	res, err := mgm.Coll(original).UpdateOne(ctx, versionFilter(original, original.Version), bson.M{"$inc": bson.M{"version": 1}})
+
+	if err != nil {
+		logger.WithError(err).Error("error while claiming secret version")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		err = errors.ErrVersionConflict
+		return
+	}
+
+	original.Version++
+
+	// New encrypted data is a rotation, metadata changes are not.
+	rotated := data.EncryptedData != "" && data.EncryptedData != original.EncryptedData
+
+	if data.EncryptedData != "" {
+		original.EncryptedData = data.EncryptedData
+	}
+
+	if rotated {
+		original.RotationRequired = false
+		original.LastRotatedAt = time.Now()
+	}
+
+	// A new value without a new fingerprint would leave the old fingerprint behind.
+	staleFingerprint := rotated && fingerprint == "" && original.ValueFingerprint != ""
+
+	if fingerprint != "" {
+		original.ValueFingerprint = fingerprint
+	}
+	original.Name = data.Name
+	original.Description = data.Description
+	original.Tags = data.Tags
+	original.Type = kind
+	original.Metadata = metadata
+	original.ExpiresAt = data.ExpiresAt
+	original.Signature = sig
+	original.CertificateChain = chain
+	original.Certificate = cert
+
+	err = mgm.Coll(original).UpdateWithCtx(ctx, original)
+
+	if err != nil {
+		logger.WithError(err).Error("error while updating secret")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if staleFingerprint {
+		_, err = mgm.Coll(original).UpdateOne(ctx, bson.M{"_id": original.ID}, bson.M{"$unset": bson.M{"valueFingerprint": ""}})
+
+		if err != nil {
+			logger.WithError(err).Error("error while removing stale value fingerprint")
+			err = errors.ErrUnknown
+			return
+		}
+
+		original.ValueFingerprint = ""
+	}
+
+	// Keep all the shared copies in sync with the original. The original is included because
+	// cleared flags and signatures are left out of the document update.
+	copyFilter := bson.M{
+		"$or": bson.A{
+			bson.M{"_id": original.ID},
+			bson.M{"referenceKey": original.ID},
 		},
-		Description:    docSecret.Description,
-		CreatorEmail:   docSecret.CreatorEmail,
-		Tags:           docSecret.Tags,
-		Type:           docSecret.Type,
-		ReferenceKey:   &docRefKey,
-		ExpiresAt:      docSecret.ExpiresAt,
-		OrganizationID: docSecret.OrganizationID,
 	}
 
+	copyUpdate := bson.M{
+		"$set": bson.M{
+			"encryptedData":    original.EncryptedData,
+			"name":             original.Name,
+			"description":      original.Description,
+			"tags":             original.Tags,
+			"type":             original.Type,
+			"metadata":         original.Metadata,
+			"expiresAt":        original.ExpiresAt,
+			"rotationRequired": original.RotationRequired,
+			"lastRotatedAt":    original.LastRotatedAt,
+			doc.UpdatedAtField: original.UpdatedAt,
+			"version":          original.Version,
+			"signature":        original.Signature,
+			"certificateChain": original.CertificateChain,
+			"certificate":      original.Certificate,
+		},
+	}
+
+	_, err = mgm.Coll(original).UpdateMany(ctx, copyFilter, copyUpdate)
+
+	if err != nil {
+		logger.WithError(err).Error("error while updating shared copies of secret")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if rotated {
+		err = s.refreshRotationDue(ctx, original)
+
+		if err != nil {
+			return
+		}
+	}
+
+	s.changed(ctx, original, nil)
+
+	return s.GetByID(ctx, model.SecretID(original.ID.Hex()), userId)
+}
+
+// Delete removes the secret and all its shares. With an expected version nothing is deleted
+// and ErrVersionConflict is returned unless the secret still has that version.
+func (s *SecretsSVC) Delete(ctx context.Context, secretId model.SecretID, userId model.UserID, expectedVersion *int64) (deleted int, err error) {
+	logger := s.logger.WithContext(ctx).WithField("secretId", secretId.String())
+
+	original, _, err := s.authorize(ctx, secretId, userId, ActionDelete)
+
+	if err != nil {
+		return
+	}
+
+	if original.Checkout != nil && original.Checkout.UserID != "" && original.Checkout.UserID != userId.String() {
+		err = errors.ErrSecretCheckedOut
+		return
+	}
+
+	holders := s.holders(ctx, original)
+
+	originalFilter := bson.M{"_id": original.ID}
+
+	if expectedVersion != nil {
+		originalFilter = versionFilter(original, *expectedVersion)
+	}
+
+	res, err := mgm.Coll(original).DeleteOne(ctx, originalFilter)
+
+	if err != nil {
+		logger.WithError(err).Error("error while deleting secret")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if res.DeletedCount == 0 && expectedVersion != nil {
+		err = errors.ErrVersionConflict
+		return
+	}
+
+	deleted = int(res.DeletedCount)
+
+	res, err = mgm.Coll(original).DeleteMany(ctx, bson.M{"referenceKey": original.ID})
+
+	if err != nil {
+		logger.WithError(err).Error("error while deleting shares of secret")
+		err = errors.ErrUnknown
+		return
+	}
+
+	deleted += int(res.DeletedCount)
+
+	s.forgetAccesses(ctx, original.ID)
+
+	for _, hook := range s.deleteHooks {
+		hook(ctx, model.SecretID(original.ID.Hex()))
+	}
+
+	s.changed(ctx, original, holders)
+
 	return
 }
 
-func (s *SecretsSVC) GetAllSecretsforUser(ctx context.Context, userId model.UserID, params model.PaginationParams) (data []model.Secret, err error) {
+func (s *SecretsSVC) ChangeRole(ctx context.Context, secretId model.SecretID, userId model.UserID, targetUserId model.UserID, role string) (sec model.Secret, err error) {
+	return s.changeRole(ctx, secretId, userId, targetUserId, role, time.Time{})
+}
+
+// UpgradeShare raises the role of a direct share until the access expires. The share then falls back to
+// the role and expiry it had before instead of lapsing.
+func (s *SecretsSVC) UpgradeShare(ctx context.Context, secretId model.SecretID, userId model.UserID, targetUserId model.UserID, role string, accessExpiresAt time.Time) (sec model.Secret, err error) {
+	if !accessExpiresAt.After(time.Now()) {
+		err = errors.ErrInvalidShareExpiry
+		return
+	}
+
+	return s.changeRole(ctx, secretId, userId, targetUserId, role, accessExpiresAt)
+}
+
+// changeRole sets the role of the direct share of the target user, until the given time when it is not zero.
+func (s *SecretsSVC) changeRole(ctx context.Context, secretId model.SecretID, userId model.UserID, targetUserId model.UserID, role string, until time.Time) (sec model.Secret, err error) {
+	if !IsShareableRole(role) {
+		err = errors.ErrInvalidSecretRole
+		return
+	}
+
+	original, _, err := s.authorize(ctx, secretId, userId, ActionChangeRole)
+
+	if err != nil {
+		return
+	}
+
+	copyDoc, err := s.getUserCopy(ctx, original, targetUserId)
+
+	if err != nil {
+		return
+	}
+
+	if !until.IsZero() {
+		if model.SecretRoleRank(copyDoc.User.Role) >= model.SecretRoleRank(role) {
+			err = errors.ErrSecretAlreadyShared
+			return
+		}
+
+		// An upgrade of an upgrade still falls back to the role from before the first one.
+		if copyDoc.User.RestoreRole == "" {
+			copyDoc.User.RestoreRole = copyDoc.User.Role
+			copyDoc.User.RestoreAccessExpiresAt = copyDoc.User.AccessExpiresAt
+		}
+
+		copyDoc.User.AccessExpiresAt = until
+		copyDoc.User.ExpiryReminderSent = false
+	}
+
+	copyDoc.User.Role = role
+
+	err = mgm.Coll(copyDoc).UpdateWithCtx(ctx, copyDoc)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("error while changing role of secret user")
+		err = errors.ErrUnknown
+		return
+	}
+
+	sec = s.MapDocToModelSecret(*copyDoc)
+
+	s.changed(ctx, original, nil)
+
+	return
+}
+
+func (s *SecretsSVC) GetAllSecretsforUser(ctx context.Context, userId model.UserID, params model.PaginationParams) (data []model.Secret, info model.PageInfo, err error) {
 
 	secretDoc := &doc.Secret{}
 
-	filter := bson.M{
-		"user.id": userId,
+	filter, err := s.userSecretsFilter(ctx, userId)
+
+	if err != nil {
+		return
 	}
 
-	findOptions := options.Find().SetLimit(int64(params.Limit)).SetSkip(int64(params.Skip)).SetSort(bson.D{
-		{"updatedAt", -1},
-	})
+	cursor, err := pagination.Find(ctx, mgm.Coll(secretDoc), filter, params)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("Error while fetching secrets for user")
+		err = errors.ErrUnknown
+		return
+	}
 
-	cursor, err := mgm.Coll(secretDoc).Find(ctx, filter, findOptions)
+	defer cursor.Close(ctx)
 
 	for cursor.Next(ctx) {
 		var curDoc doc.Secret
 
-		err := cursor.Decode(curDoc)
+		err := cursor.Decode(&curDoc)
 
 		if err != nil {
 			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret document")
@@ -112,25 +554,36 @@ func (s *SecretsSVC) GetAllSecretsforUser(ctx context.Context, userId model.User
 		}
 
 		modelSecret := s.MapDocToModelSecret(curDoc)
+		redactCheckout(&modelSecret, userId)
 
 		data = append(data, modelSecret)
 	}
+
+	info = cursor.Info()
+
 	return
 }
 
-func (s *SecretsSVC) GetAllSecretsforOrganization(ctx context.Context, orgId model.OrganizationID, params model.PaginationParams) (data []model.Secret, err error) {
+// GetAllSecretsforOrganization lists the secrets of the organization the user can see. Admins see every
+// original, other members what they hold or are granted through a collection.
+func (s *SecretsSVC) GetAllSecretsforOrganization(ctx context.Context, orgId model.OrganizationID, userId model.UserID, params model.PaginationParams) (data []model.Secret, info model.PageInfo, err error) {
 
 	secretDoc := &doc.Secret{}
 
-	filter := bson.M{
-		"organizationId": orgId,
+	filter, err := s.accessibleSecretsFilter(ctx, orgId, userId)
+
+	if err != nil {
+		return
 	}
 
-	findOptions := options.Find().SetLimit(int64(params.Limit)).SetSkip(int64(params.Skip)).SetSort(bson.D{
-		{"updatedAt", -1},
-	})
+	cursor, err := pagination.Find(ctx, mgm.Coll(secretDoc), filter, params)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("Error while fetching secrets for organization")
+		err = errors.ErrUnknown
+		return
+	}
 
-	cursor, err := mgm.Coll(secretDoc).Find(ctx, filter, findOptions)
+	defer cursor.Close(ctx)
 
 	for cursor.Next(ctx) {
 		var curDoc doc.Secret
@@ -143,26 +596,31 @@ func (s *SecretsSVC) GetAllSecretsforOrganization(ctx context.Context, orgId mod
 		}
 
 		modelSecret := s.MapDocToModelSecret(curDoc)
+		redactCheckout(&modelSecret, userId)
 
 		data = append(data, modelSecret)
 	}
+
+	info = cursor.Info()
+
+	s.RecordAccesses(ctx, data, userId)
+
 	return
 }
 
-func (s *SecretsSVC) GetAllSecretsforaUserInOrganization(ctx context.Context, userId model.UserID, orgId model.OrganizationID, params model.PaginationParams) (data []model.Secret, err error) {
+func (s *SecretsSVC) GetAllSecretsforaUserInOrganization(ctx context.Context, userId model.UserID, orgId model.OrganizationID, params model.PaginationParams) (data []model.Secret, info model.PageInfo, err error) {
 
 	secretDoc := &doc.Secret{}
 
-	filter := bson.M{
-		"organizationId": orgId,
-		"user.id":        userId,
+	filter, err := s.userSecretsFilter(ctx, userId)
+
+	if err != nil {
+		return
 	}
 
-	findOptions := options.Find().SetLimit(int64(params.Limit)).SetSkip(int64(params.Skip)).SetSort(bson.D{
-		{"updatedAt", 1},
-	})
+	filter["organizationId"] = orgId
 
-	cursor, err := mgm.Coll(secretDoc).Find(ctx, filter, findOptions)
+	cursor, err := pagination.Find(ctx, mgm.Coll(secretDoc), filter, params)
 	if err != nil {
 		s.logger.WithContext(ctx).WithError(err).Error("Error while fetching organization for user secrets")
 		err = errors.ErrUnknown
@@ -182,30 +640,35 @@ func (s *SecretsSVC) GetAllSecretsforaUserInOrganization(ctx context.Context, us
 		}
 
 		modelSecret := s.MapDocToModelSecret(curDoc)
+		redactCheckout(&modelSecret, userId)
 
 		data = append(data, modelSecret)
 	}
+
+	info = cursor.Info()
+
+	s.RecordAccesses(ctx, data, userId)
+
 	return
 }
 
-func (s *SecretsSVC) GetAllUsersforSecretByAdmin(ctx context.Context, orgId model.OrganizationID, originalKeyID string, params model.PaginationParams) (data []model.User, err error) {
+func (s *SecretsSVC) GetAllUsersforSecretByAdmin(ctx context.Context, orgId model.OrganizationID, originalKeyID string, userId model.UserID, params model.PaginationParams) (data []model.User, info model.PageInfo, err error) {
 
 	secretDoc := &doc.Secret{}
 
-	objRefKey, _ := primitive.ObjectIDFromHex(originalKeyID)
+	original, _, err := s.authorize(ctx, model.SecretID(originalKeyID), userId, ActionListUsers)
+
+	if err != nil {
+		return
+	}
 
 	filter := bson.M{
 		"organizationId": orgId,
-		"referenceKey":   objRefKey,
+		"referenceKey":   original.ID,
+		"user.id":        bson.M{"$exists": true},
 	}
 
-	s.logger.Error(filter)
-
-	findOptions := options.Find().SetLimit(int64(params.Limit)).SetSkip(int64(params.Skip)).SetSort(bson.D{
-		{"updatedAt", 1},
-	})
-
-	cursor, err := mgm.Coll(secretDoc).Find(ctx, filter, findOptions)
+	cursor, err := pagination.Find(ctx, mgm.Coll(secretDoc), filter, params)
 	if err != nil {
 		s.logger.WithContext(ctx).WithError(err).Error("Error while fetching users for a particular secret.")
 		err = errors.ErrUnknown
@@ -233,6 +696,9 @@ func (s *SecretsSVC) GetAllUsersforSecretByAdmin(ctx context.Context, orgId mode
 
 		data = append(data, user)
 	}
+
+	info = cursor.Info()
+
 	return
 }
 
@@ -244,15 +710,26 @@ func (s *SecretsSVC) MapDocToModelSecret(docSecret doc.Secret) model.Secret {
 		UpdatedAt:     docSecret.UpdatedAt,
 		Name:          docSecret.Name,
 		User: model.SecretUser{
-			ID:   model.UserID(docSecret.User.ID),
-			Role: docSecret.User.Role,
+			ID:              model.UserID(docSecret.User.ID),
+			Role:            docSecret.User.Role,
+			AccessExpiresAt: docSecret.User.AccessExpiresAt,
 		},
-		Description:    docSecret.Description,
-		CreatorEmail:   docSecret.CreatorEmail,
-		Tags:           docSecret.Tags,
-		Type:           docSecret.Type,
-		ExpiresAt:      docSecret.ExpiresAt,
-		OrganizationID: docSecret.OrganizationID,
+		Description:          docSecret.Description,
+		CreatorEmail:         docSecret.CreatorEmail,
+		Tags:                 docSecret.Tags,
+		Type:                 docSecret.Type,
+		Metadata:             docSecret.Metadata,
+		ExpiresAt:            docSecret.ExpiresAt,
+		OrganizationID:       docSecret.OrganizationID,
+		Checkout:             mapCheckout(docSecret.Checkout),
+		RotationRequired:     docSecret.RotationRequired,
+		RotationIntervalDays: docSecret.RotationIntervalDays,
+		LastRotatedAt:        docSecret.LastRotatedAt,
+		RotationDueAt:        docSecret.RotationDueAt,
+		Version:              docSecret.Version,
+		Signature:            mapSignature(docSecret.Signature),
+		CertificateChain:     docSecret.CertificateChain,
+		Certificate:          mapCertificate(docSecret.Certificate),
 	}
 
 	if docSecret.ReferenceKey != nil {
@@ -260,32 +737,52 @@ func (s *SecretsSVC) MapDocToModelSecret(docSecret doc.Secret) model.Secret {
 		secretModel.ReferenceKey = &refKey
 	}
 
+	if docSecret.CollectionID != nil {
+		collectionId := model.CollectionID(docSecret.CollectionID.Hex())
+		secretModel.CollectionID = &collectionId
+	}
+
+	if docSecret.ProjectID != nil {
+		projectId := model.ProjectID(docSecret.ProjectID.Hex())
+		secretModel.ProjectID = &projectId
+		secretModel.Environment = docSecret.Environment
+	}
+
+	if docSecret.Team != nil {
+		secretModel.Team = &model.SecretTeam{
+			ID:   model.TeamID(docSecret.Team.ID),
+			Role: docSecret.Team.Role,
+		}
+	}
+
 	return secretModel
 }
 
-func (s *SecretsSVC) ShareSecret(c context.Context, originalID model.SecretID, endUserEmail []model.SecretUser) (resultSet map[string]bool, err error) {
+func (s *SecretsSVC) ShareSecret(c context.Context, originalID model.SecretID, userId model.UserID, endUserEmail []model.SecretUser) (resultSet map[string]bool, err error) {
 	logger := s.logger.WithContext(c).WithField("shareId", originalID.String())
 	resultSet = make(map[string]bool)
 
-	bsonId, err := primitive.ObjectIDFromHex(originalID.String())
+	// Owner role cannot be handed out through a share.
+	for _, userDoc := range endUserEmail {
+		if userDoc.Role != "" && !IsShareableRole(userDoc.Role) {
+			err = errors.ErrInvalidSecretRole
+			return
+		}
 
-	if err != nil {
-		logger.WithError(err).Error("error while converting key to bson in secret share")
-		err = errors.ErrInvalidID
-		return
+		if !userDoc.AccessExpiresAt.IsZero() && !userDoc.AccessExpiresAt.After(time.Now()) {
+			err = errors.ErrInvalidShareExpiry
+			return
+		}
 	}
 
-	secretDoc := &doc.Secret{}
-	insertDocs := []interface{}{}
-
-	err = mgm.Coll(secretDoc).FindByID(bsonId, secretDoc)
+	secretDoc, _, err := s.authorize(c, originalID, userId, ActionShare)
 
 	if err != nil {
-		logger.WithError(err).Error("failed to get the doc by id")
-		err = errors.ErrSecretNotFound
 		return
 	}
 
+	insertDocs := []interface{}{}
+
 	// Loop over all the user emails.
 	for _, userDoc := range endUserEmail {
 
@@ -300,6 +797,19 @@ func (s *SecretsSVC) ShareSecret(c context.Context, originalID model.SecretID, e
 			continue
 		}
 
+		// Users who already have a share keep their role, it can only be changed through ChangeRole.
+		if _, copyErr := s.getUserCopy(c, secretDoc, userDoc.ID); copyErr == nil || secretDoc.User.ID == userDoc.ID.String() {
+			logger.WithField("userId", userDoc.ID).Info("secret already shared with user")
+			resultSet[userDoc.ID.String()] = false
+			continue
+		}
+
+		role := userDoc.Role
+
+		if role == "" {
+			role = model.SecretRoleViewer
+		}
+
 		refKey := secretDoc.ID
 
 		newInsertDoc := doc.Secret{
@@ -308,15 +818,27 @@ func (s *SecretsSVC) ShareSecret(c context.Context, originalID model.SecretID, e
 			CreatorEmail:  secretDoc.CreatorEmail,
 			ReferenceKey:  &refKey,
 			User: doc.SecretUser{
-				ID:   userDoc.ID.String(),
-				Role: userDoc.Role,
+				ID:              userDoc.ID.String(),
+				Role:            role,
+				AccessExpiresAt: userDoc.AccessExpiresAt,
 			},
-			Tags:           secretDoc.Tags,
-			OrganizationID: secretDoc.OrganizationID,
-			ExpiresAt:      secretDoc.ExpiresAt,
-			Name:           secretDoc.Name,
-			Type:           secretDoc.Type,
+			Tags:                 secretDoc.Tags,
+			OrganizationID:       secretDoc.OrganizationID,
+			ExpiresAt:            secretDoc.ExpiresAt,
+			Name:                 secretDoc.Name,
+			Type:                 secretDoc.Type,
+			Metadata:             secretDoc.Metadata,
+			Checkout:             secretDoc.Checkout,
+			RotationRequired:     secretDoc.RotationRequired,
+			RotationIntervalDays: secretDoc.RotationIntervalDays,
+			LastRotatedAt:        secretDoc.LastRotatedAt,
+			RotationDueAt:        secretDoc.RotationDueAt,
+			Version:              secretDoc.Version,
+			Signature:            secretDoc.Signature,
+			CertificateChain:     secretDoc.CertificateChain,
+			Certificate:          secretDoc.Certificate,
 		}
+		newInsertDoc.Creating()
 		insertDocs = append(insertDocs, newInsertDoc)
 	}
 
@@ -342,5 +864,7 @@ func (s *SecretsSVC) ShareSecret(c context.Context, originalID model.SecretID, e
 		}
 	}
 
+	s.changed(c, secretDoc, nil)
+
 	return
 }
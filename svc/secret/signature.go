@@ -0,0 +1,162 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/signature"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// signedPayloadPrefix names the layout of the signed payload, see model.SecretSignature.
+const signedPayloadPrefix = "secaas-secret-v1"
+
+// A signature may be made ahead of the write, by at most signatureMaxAge, and by a clock at most
+// signatureClockSkew ahead of ours.
+const (
+	signatureMaxAge    = 24 * time.Hour
+	signatureClockSkew = time.Minute
+)
+
+// signedPayload writes the fields of the secret the author signs. Every field is prefixed with its
+// length so no two secrets have the same payload.
+func signedPayload(sec *doc.Secret, authorId model.UserID) []byte {
+	payload := []byte(signedPayloadPrefix)
+
+	field := func(value string) {
+		payload = strconv.AppendInt(payload, int64(len(value)), 10)
+		payload = append(payload, ':')
+		payload = append(payload, value...)
+	}
+
+	field(sec.OrganizationID)
+	field(sec.Name)
+	field(sec.Description)
+	field(sec.Type)
+	field(sec.EncryptedData)
+	field(sec.CertificateChain)
+
+	names := make([]string, 0, len(sec.Metadata))
+
+	for name := range sec.Metadata {
+		// The chain is signed, what the server derives from it is not.
+		if sec.Certificate != nil && (name == certificateExpiresAtField || name == fingerprintField) {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	field(strconv.Itoa(len(names)))
+
+	for _, name := range names {
+		field(name)
+		field(sec.Metadata[name])
+	}
+
+	field(strconv.Itoa(len(sec.Tags)))
+
+	for _, tag := range sec.Tags {
+		field(tag)
+	}
+
+	if sec.ExpiresAt.IsZero() {
+		field("")
+	} else {
+		field(strconv.FormatInt(sec.ExpiresAt.UnixMilli(), 10))
+	}
+
+	field(strconv.FormatInt(sec.Version, 10))
+	field(authorId.String())
+
+	return payload
+}
+
+// verifySignature checks the signature of the author over the secret as it will be stored, against the
+// signing key of the author that was current when it was signed. Without a signature the secret is stored
+// unsigned, unless the write replaces a signed secret or the author has a signing key.
+func (s *SecretsSVC) verifySignature(ctx context.Context, sec *doc.Secret, authorId model.UserID, sig *model.SecretSignature, replacesSigned bool) (*doc.SecretSignature, error) {
+	unsigned := sig == nil || sig.Value == ""
+
+	if unsigned && replacesSigned {
+		return nil, errors.ErrSecretSignatureRequired
+	}
+
+	author, err := s.userSvc.GetByID(ctx, authorId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if unsigned {
+		if len(author.SigningKeys) > 0 {
+			return nil, errors.ErrSecretSignatureRequired
+		}
+
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	signedAt := now
+
+	if !sig.SignedAt.IsZero() {
+		signedAt = sig.SignedAt.UTC()
+
+		if signedAt.After(now.Add(signatureClockSkew)) || signedAt.Before(now.Add(-signatureMaxAge)) {
+			return nil, errors.ErrInvalidSecretSignature
+		}
+	}
+
+	key, ok := signingKeyAt(author.SigningKeys, signedAt)
+
+	if !ok || (sig.KeyID != "" && sig.KeyID != key.KeyID) {
+		return nil, errors.ErrSigningKeyNotFound
+	}
+
+	err = signature.Verify(key, signedPayload(sec, authorId), sig.Value)
+
+	if err != nil {
+		return nil, errors.ErrInvalidSecretSignature
+	}
+
+	return &doc.SecretSignature{
+		Value:    sig.Value,
+		KeyID:    key.KeyID,
+		Alg:      key.Alg,
+		SignedBy: authorId.String(),
+		SignedAt: signedAt,
+	}, nil
+}
+
+// signingKeyAt returns the key that was current at the given time, the last one registered before it.
+func signingKeyAt(keys []model.SigningKey, at time.Time) (key model.SigningKey, ok bool) {
+	for _, candidate := range keys {
+		if candidate.RegisteredAt.After(at) || (ok && candidate.RegisteredAt.Before(key.RegisteredAt)) {
+			continue
+		}
+
+		key = candidate
+		ok = true
+	}
+
+	return
+}
+
+func mapSignature(sig *doc.SecretSignature) *model.SecretSignature {
+	if sig == nil {
+		return nil
+	}
+
+	return &model.SecretSignature{
+		Value:    sig.Value,
+		KeyID:    sig.KeyID,
+		Alg:      sig.Alg,
+		SignedBy: model.UserID(sig.SignedBy),
+		SignedAt: sig.SignedAt,
+	}
+}
@@ -0,0 +1,233 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChangeHook is called after a secret or one of its shares changed, with the id of the original secret and
+// the users who held it before or after the change.
+type ChangeHook func(ctx context.Context, secretId model.SecretID, userIds []model.UserID)
+
+// RegisterChangeHook adds a hook which keeps track of the secrets changed for each user.
+func (s *SecretsSVC) RegisterChangeHook(hook ChangeHook) {
+	s.changeHooks = append(s.changeHooks, hook)
+}
+
+// holders lists the owner of the secret, the users it is shared with, the active members of its teams
+// and the users granted access to its collection.
+// Mutations which take access away look the holders up before they change anything.
+func (s *SecretsSVC) holders(ctx context.Context, original *doc.Secret) []model.UserID {
+	if len(s.changeHooks) == 0 {
+		return nil
+	}
+
+	seen := map[string]bool{original.User.ID: true}
+	userIds := []model.UserID{model.UserID(original.User.ID)}
+
+	add := func(userId string) {
+		if userId != "" && !seen[userId] {
+			seen[userId] = true
+			userIds = append(userIds, model.UserID(userId))
+		}
+	}
+
+	if original.CollectionID != nil {
+		grantees, err := s.collectionSvc.GranteeIDs(ctx, model.CollectionID(original.CollectionID.Hex()))
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithField("secretId", original.ID.Hex()).WithError(err).Error("error while fetching collection grantees of secret")
+		}
+
+		for _, grantee := range grantees {
+			add(grantee.String())
+		}
+	}
+
+	cursor, err := mgm.Coll(original).Find(ctx, bson.M{"referenceKey": original.ID})
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", original.ID.Hex()).WithError(err).Error("error while fetching shares of secret")
+		return userIds
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		if curDoc.Team == nil {
+			add(curDoc.User.ID)
+			continue
+		}
+
+		for _, memberId := range s.activeTeamMembers(ctx, curDoc.Team.ID) {
+			add(memberId)
+		}
+	}
+
+	return userIds
+}
+
+// changed calls the change hooks with the current holders of the secret and the holders before the change.
+func (s *SecretsSVC) changed(ctx context.Context, original *doc.Secret, previous []model.UserID) {
+	if len(s.changeHooks) == 0 {
+		return
+	}
+
+	userIds := s.holders(ctx, original)
+
+	for _, userId := range previous {
+		found := false
+
+		for _, holder := range userIds {
+			if holder == userId {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			userIds = append(userIds, userId)
+		}
+	}
+
+	for _, hook := range s.changeHooks {
+		hook(ctx, model.SecretID(original.ID.Hex()), userIds)
+	}
+}
+
+// collectionGrantsChanged notifies the holders of every secret in the collection and its nested
+// collections, together with the users who could read them through a grant before.
+func (s *SecretsSVC) collectionGrantsChanged(ctx context.Context, collectionId model.CollectionID, previous []model.UserID) {
+	if len(s.changeHooks) == 0 {
+		return
+	}
+
+	logger := s.logger.WithContext(ctx).WithField("collectionId", collectionId.String())
+
+	rootId, err := primitive.ObjectIDFromHex(collectionId.String())
+
+	if err != nil {
+		return
+	}
+
+	collectionIds, err := s.collectionSvc.GetDescendantIDs(ctx, collectionId)
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching nested collections for grant change")
+		return
+	}
+
+	collectionIds = append(collectionIds, rootId)
+
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, bson.M{"collectionId": bson.M{"$in": collectionIds}, "$or": originalSecretFilter()})
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching secrets for grant change")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			logger.WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		s.changed(ctx, &curDoc, previous)
+	}
+}
+
+// Holders lists the users who hold the secret directly or through a team.
+func (s *SecretsSVC) Holders(ctx context.Context, secretId model.SecretID) ([]model.UserID, error) {
+	original, err := s.getOriginal(ctx, secretId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.holders(ctx, original), nil
+}
+
+// GetTeamSecretIDs lists the original secrets shared with the team.
+func (s *SecretsSVC) GetTeamSecretIDs(ctx context.Context, teamId model.TeamID) ([]model.SecretID, error) {
+	cursor, err := mgm.Coll(&doc.Secret{}).Find(ctx, bson.M{"team.id": teamId.String()})
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching secrets of team")
+		return nil, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	secretIds := []model.SecretID{}
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		if !isOriginalSecret(&curDoc) {
+			secretIds = append(secretIds, model.SecretID(curDoc.ReferenceKey.Hex()))
+		}
+	}
+
+	return secretIds, nil
+}
+
+// SyncView returns the secret as the user sees it through GetByID. While someone else holds the
+// checkout the secret is returned without its encrypted data instead of failing. Pushed changes are not
+// asked for by the user and do not count as reads, GetChanges of the sync service counts the polled ones.
+func (s *SecretsSVC) SyncView(ctx context.Context, secretId model.SecretID, userId model.UserID) (sec model.Secret, err error) {
+	original, err := s.getOriginal(ctx, secretId)
+
+	if err != nil {
+		return
+	}
+
+	role, grant, err := s.resolveGrant(ctx, original, userId)
+
+	if err != nil {
+		return
+	}
+
+	if !CanPerform(role, ActionRead) {
+		err = errors.ErrSecretAccessDenied
+		return
+	}
+
+	sec = s.MapDocToModelSecret(*grant)
+	sec.User.ID = userId
+	sec.User.Role = role
+	sec.Version = original.Version
+
+	if isCheckoutBlocked(original.Checkout, userId) {
+		sec.EncryptedData = ""
+	}
+
+	return
+}
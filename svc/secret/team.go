@@ -0,0 +1,226 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"strings"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ShareSecretWithTeam shares the secret with every active member of the team.
+// Members who join later get access as soon as the team key is wrapped for them.
+func (s *SecretsSVC) ShareSecretWithTeam(ctx context.Context, secretId model.SecretID, userId model.UserID, share model.SecretTeam) (sec model.Secret, err error) {
+	role := share.Role
+
+	if role == "" {
+		role = model.SecretRoleViewer
+	}
+
+	if !IsShareableRole(role) {
+		err = errors.ErrInvalidSecretRole
+		return
+	}
+
+	original, _, err := s.authorize(ctx, secretId, userId, ActionShare)
+
+	if err != nil {
+		return
+	}
+
+	team, err := s.teamSvc.Lookup(ctx, share.ID)
+
+	if err != nil {
+		return
+	}
+
+	if team.OrganizationID != original.OrganizationID {
+		err = errors.ErrOrganizationMismatch
+		return
+	}
+
+	_, err = s.getTeamCopy(ctx, original, share.ID)
+
+	if err == nil {
+		err = errors.ErrSecretAlreadyShared
+		return
+	}
+
+	if err != errors.ErrSecretUserNotFound {
+		return
+	}
+
+	refKey := original.ID
+
+	teamDoc := &doc.Secret{
+		Description:   original.Description,
+		EncryptedData: original.EncryptedData,
+		CreatorEmail:  original.CreatorEmail,
+		ReferenceKey:  &refKey,
+		Team: &doc.SecretTeam{
+			ID:   share.ID.String(),
+			Role: role,
+		},
+		Tags:                 original.Tags,
+		OrganizationID:       original.OrganizationID,
+		ExpiresAt:            original.ExpiresAt,
+		Name:                 original.Name,
+		Type:                 original.Type,
+		Metadata:             original.Metadata,
+		Checkout:             original.Checkout,
+		RotationRequired:     original.RotationRequired,
+		RotationIntervalDays: original.RotationIntervalDays,
+		LastRotatedAt:        original.LastRotatedAt,
+		RotationDueAt:        original.RotationDueAt,
+		Version:              original.Version,
+		Signature:            original.Signature,
+		CertificateChain:     original.CertificateChain,
+		Certificate:          original.Certificate,
+	}
+
+	err = mgm.Coll(teamDoc).CreateWithCtx(ctx, teamDoc)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("failed to share secret with team")
+		err = errors.ErrSecretShareFailed
+		return
+	}
+
+	sec = s.MapDocToModelSecret(*teamDoc)
+
+	s.changed(ctx, original, nil)
+
+	return
+}
+
+func (s *SecretsSVC) ChangeTeamRole(ctx context.Context, secretId model.SecretID, userId model.UserID, teamId model.TeamID, role string) (sec model.Secret, err error) {
+	if !IsShareableRole(role) {
+		err = errors.ErrInvalidSecretRole
+		return
+	}
+
+	original, _, err := s.authorize(ctx, secretId, userId, ActionChangeRole)
+
+	if err != nil {
+		return
+	}
+
+	teamDoc, err := s.getTeamCopy(ctx, original, teamId)
+
+	if err != nil {
+		return
+	}
+
+	teamDoc.Team.Role = role
+
+	err = mgm.Coll(teamDoc).UpdateWithCtx(ctx, teamDoc)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("error while changing role of secret team")
+		err = errors.ErrUnknown
+		return
+	}
+
+	sec = s.MapDocToModelSecret(*teamDoc)
+
+	s.changed(ctx, original, nil)
+
+	return
+}
+
+func (s *SecretsSVC) RevokeTeamShare(ctx context.Context, secretId model.SecretID, userId model.UserID, teamId model.TeamID) (deleted int, err error) {
+	original, _, err := s.authorize(ctx, secretId, userId, ActionShare)
+
+	if err != nil {
+		return
+	}
+
+	holders := s.holders(ctx, original)
+
+	filter := bson.M{
+		"referenceKey": original.ID,
+		"team.id":      teamId.String(),
+	}
+
+	res, err := mgm.Coll(original).DeleteMany(ctx, filter)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("error while revoking team share")
+		err = errors.ErrUnknown
+		return
+	}
+
+	deleted = int(res.DeletedCount)
+
+	s.changed(ctx, original, holders)
+
+	return
+}
+
+func (s *SecretsSVC) GetTeamsForSecret(ctx context.Context, secretId model.SecretID, userId model.UserID) (data []model.SecretTeam, err error) {
+	original, _, err := s.authorize(ctx, secretId, userId, ActionListUsers)
+
+	if err != nil {
+		return
+	}
+
+	filter := bson.M{
+		"referenceKey": original.ID,
+		"team.id":      bson.M{"$exists": true},
+	}
+
+	cursor, err := mgm.Coll(original).Find(ctx, filter)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching teams for secret")
+		err = errors.ErrUnknown
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	data = []model.SecretTeam{}
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding team share document")
+			continue
+		}
+
+		data = append(data, model.SecretTeam{
+			ID:   model.TeamID(curDoc.Team.ID),
+			Role: curDoc.Team.Role,
+		})
+	}
+
+	return
+}
+
+// getTeamCopy fetches the copy of the original secret which is shared with the team.
+func (s *SecretsSVC) getTeamCopy(ctx context.Context, original *doc.Secret, teamId model.TeamID) (*doc.Secret, error) {
+	teamDoc := &doc.Secret{}
+
+	filter := bson.M{
+		"referenceKey": original.ID,
+		"team.id":      teamId.String(),
+	}
+
+	err := mgm.Coll(teamDoc).First(filter, teamDoc)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrSecretUserNotFound
+		}
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching team copy of secret")
+		return nil, errors.ErrUnknown
+	}
+
+	return teamDoc, nil
+}
@@ -0,0 +1,346 @@
+package secret
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/pagination"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// MaxConsumerIDLength bounds the id a service account or device names itself with.
+	MaxConsumerIDLength = 128
+	// DefaultUnusedDays is how long a secret must go unread to show in the unused report by default.
+	DefaultUnusedDays = 90
+	// MaxUnusedDays bounds the days of the unused report.
+	MaxUnusedDays = 3650
+)
+
+// checkConsumer fills in the user as the consumer of a read when the client did not name one.
+func checkConsumer(userId model.UserID, consumer model.SecretConsumer) (model.SecretConsumer, error) {
+	switch consumer.Kind {
+	case "", model.SecretConsumerUser:
+		if consumer.ID != "" && consumer.ID != userId.String() {
+			return consumer, errors.ErrInvalidSecretConsumer
+		}
+
+		return model.SecretConsumer{Kind: model.SecretConsumerUser, ID: userId.String()}, nil
+	case model.SecretConsumerServiceAccount, model.SecretConsumerDevice:
+		if consumer.ID == "" || len(consumer.ID) > MaxConsumerIDLength {
+			return consumer, errors.ErrInvalidSecretConsumer
+		}
+
+		return consumer, nil
+	}
+
+	return consumer, errors.ErrInvalidSecretConsumer
+}
+
+// recordAccess counts a read of the secret by the consumer. Failures are logged, they never fail the read.
+func (s *SecretsSVC) recordAccess(ctx context.Context, sec model.Secret, userId model.UserID, consumer model.SecretConsumer) {
+	filter, update, ok := accessUpdate(sec, userId, consumer, time.Now().UTC())
+
+	if !ok {
+		return
+	}
+
+	_, err := mgm.Coll(&doc.SecretAccess{}).UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", sec.ID.String()).WithError(err).Error("error while recording secret access")
+	}
+}
+
+// RecordAccesses counts a read by the user of every secret on a page of a list, a search, a bundle or a sync poll.
+// Lists cannot name another consumer, secrets returned without their encrypted data are not counted.
+func (s *SecretsSVC) RecordAccesses(ctx context.Context, secrets []model.Secret, userId model.UserID) {
+	consumer := model.SecretConsumer{Kind: model.SecretConsumerUser, ID: userId.String()}
+	now := time.Now().UTC()
+
+	writes := []mongo.WriteModel{}
+
+	for _, sec := range secrets {
+		if sec.EncryptedData == "" {
+			continue
+		}
+
+		filter, update, ok := accessUpdate(sec, userId, consumer, now)
+
+		if ok {
+			writes = append(writes, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+		}
+	}
+
+	if len(writes) == 0 {
+		return
+	}
+
+	_, err := mgm.Coll(&doc.SecretAccess{}).BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("userId", userId.String()).WithError(err).Error("error while recording secret accesses")
+	}
+}
+
+// accessUpdate builds the upsert counting a read of the secret, copies count for their original.
+func accessUpdate(sec model.Secret, userId model.UserID, consumer model.SecretConsumer, now time.Time) (bson.M, bson.M, bool) {
+	secretId := sec.ID.String()
+
+	if sec.ReferenceKey != nil {
+		secretId = *sec.ReferenceKey
+	}
+
+	objId, err := primitive.ObjectIDFromHex(secretId)
+
+	if err != nil {
+		return nil, nil, false
+	}
+
+	filter := bson.M{
+		"secretId":     objId,
+		"consumerKind": consumer.Kind,
+		"consumerId":   consumer.ID,
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"accessCount": 1},
+		"$max": bson.M{"lastAccessedAt": now},
+		"$set": bson.M{
+			"organizationId":   sec.OrganizationID,
+			"userId":           userId.String(),
+			doc.UpdatedAtField: now,
+		},
+		"$setOnInsert": bson.M{
+			"firstAccessedAt":  now,
+			doc.CreatedAtField: now,
+		},
+	}
+
+	return filter, update, true
+}
+
+// forgetAccesses removes the access counts of a deleted secret.
+func (s *SecretsSVC) forgetAccesses(ctx context.Context, originalId primitive.ObjectID) {
+	_, err := mgm.Coll(&doc.SecretAccess{}).DeleteMany(ctx, bson.M{"secretId": originalId})
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", originalId.Hex()).WithError(err).Error("error while removing accesses of secret")
+	}
+}
+
+// Read returns the secret like GetByID and counts the read for the consumer.
+func (s *SecretsSVC) Read(ctx context.Context, secretId model.SecretID, userId model.UserID, consumer model.SecretConsumer) (sec model.Secret, err error) {
+	consumer, err = checkConsumer(userId, consumer)
+
+	if err != nil {
+		return
+	}
+
+	sec, err = s.GetByID(ctx, secretId, userId)
+
+	if err != nil {
+		return
+	}
+
+	s.recordAccess(ctx, sec, userId, consumer)
+
+	return
+}
+
+// GetAccessSummary tells how often and by whom the secret was read. Users who can list the users
+// of the secret can see it.
+func (s *SecretsSVC) GetAccessSummary(ctx context.Context, secretId model.SecretID, userId model.UserID) (summary model.SecretAccessSummary, err error) {
+	original, _, err := s.authorize(ctx, secretId, userId, ActionListUsers)
+
+	if err != nil {
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "lastAccessedAt", Value: -1}})
+
+	cursor, err := mgm.Coll(&doc.SecretAccess{}).Find(ctx, bson.M{"secretId": original.ID}, findOptions)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("secretId", secretId.String()).WithError(err).Error("error while fetching accesses of secret")
+		err = errors.ErrUnknown
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	summary = model.SecretAccessSummary{
+		SecretID:  model.SecretID(original.ID.Hex()),
+		Consumers: []model.SecretConsumerAccess{},
+	}
+
+	for cursor.Next(ctx) {
+		var curDoc doc.SecretAccess
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding secret access document")
+			continue
+		}
+
+		summary.AccessCount += curDoc.AccessCount
+
+		if curDoc.LastAccessedAt.After(summary.LastAccessedAt) {
+			summary.LastAccessedAt = curDoc.LastAccessedAt
+		}
+
+		summary.Consumers = append(summary.Consumers, model.SecretConsumerAccess{
+			SecretConsumer: model.SecretConsumer{
+				Kind: curDoc.ConsumerKind,
+				ID:   curDoc.ConsumerID,
+			},
+			UserID:          model.UserID(curDoc.UserID),
+			AccessCount:     curDoc.AccessCount,
+			FirstAccessedAt: curDoc.FirstAccessedAt,
+			LastAccessedAt:  curDoc.LastAccessedAt,
+		})
+	}
+
+	return
+}
+
+// GetUnusedSecrets lists the secrets of the organization nobody read for the given days, leaving out the
+// secrets created since. Only admins can see it.
+func (s *SecretsSVC) GetUnusedSecrets(ctx context.Context, orgId model.OrganizationID, userId model.UserID, days int, params model.PaginationParams) (data []model.UnusedSecret, info model.PageInfo, err error) {
+	logger := s.logger.WithContext(ctx).WithField("organizationId", orgId.String())
+
+	if orgId == "" {
+		err = errors.ErrInvalidOrganizationID
+		return
+	}
+
+	if days <= 0 || days > MaxUnusedDays {
+		err = errors.ErrInvalidUnusedDays
+		return
+	}
+
+	if !s.userSvc.IsOrganizationAdmin(ctx, userId, orgId) {
+		err = errors.ErrSecretAccessDenied
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	recentIds, err := mgm.Coll(&doc.SecretAccess{}).Distinct(ctx, "secretId", bson.M{
+		"organizationId": orgId.String(),
+		"lastAccessedAt": bson.M{"$gte": cutoff},
+	})
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching recently read secrets")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if recentIds == nil {
+		recentIds = []interface{}{}
+	}
+
+	filter := bson.M{
+		"organizationId":   orgId.String(),
+		doc.CreatedAtField: bson.M{"$lt": cutoff},
+		"_id":              bson.M{"$nin": recentIds},
+		"$or":              originalSecretFilter(),
+	}
+
+	cursor, err := pagination.Find(ctx, mgm.Coll(&doc.Secret{}), filter, params)
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching unused secrets")
+		err = errors.ErrUnknown
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	data = []model.UnusedSecret{}
+	secretIds := []primitive.ObjectID{}
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Secret
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			logger.WithError(err).Error("error while decoding secret document")
+			continue
+		}
+
+		data = append(data, model.UnusedSecret{
+			HealthSecret: mapHealthSecret(&curDoc),
+			CreatedAt:    curDoc.CreatedAt,
+		})
+		secretIds = append(secretIds, curDoc.ID)
+	}
+
+	info = cursor.Info()
+
+	if len(secretIds) == 0 {
+		return
+	}
+
+	// Secrets read before the cutoff still show when they were last read.
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"secretId": bson.M{"$in": secretIds}}},
+		bson.M{"$group": bson.M{
+			"_id":            "$secretId",
+			"lastAccessedAt": bson.M{"$max": "$lastAccessedAt"},
+			"accessCount":    bson.M{"$sum": "$accessCount"},
+		}},
+	}
+
+	accessCursor, err := mgm.Coll(&doc.SecretAccess{}).Aggregate(ctx, pipeline)
+
+	if err != nil {
+		logger.WithError(err).Error("error while counting accesses of unused secrets")
+		err = errors.ErrUnknown
+		return
+	}
+
+	defer accessCursor.Close(ctx)
+
+	type accessTotal struct {
+		SecretID       primitive.ObjectID `bson:"_id"`
+		LastAccessedAt time.Time          `bson:"lastAccessedAt"`
+		AccessCount    int64              `bson:"accessCount"`
+	}
+
+	totals := map[model.SecretID]accessTotal{}
+
+	for accessCursor.Next(ctx) {
+		var total accessTotal
+
+		err := accessCursor.Decode(&total)
+
+		if err != nil {
+			logger.WithError(err).Error("error while decoding secret access total")
+			continue
+		}
+
+		totals[model.SecretID(total.SecretID.Hex())] = total
+	}
+
+	for i := range data {
+		total, ok := totals[data[i].ID]
+
+		if ok {
+			data[i].LastAccessedAt = total.LastAccessedAt
+			data[i].AccessCount = total.AccessCount
+		}
+	}
+
+	return
+}
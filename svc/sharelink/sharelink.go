@@ -0,0 +1,463 @@
+package sharelink
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/notification"
+	"secaas_backend/svc/pagination"
+	"secaas_backend/svc/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// DefaultLinkTTL is used when a link is created without an expiry.
+	DefaultLinkTTL = 24 * time.Hour
+	// MaxLinkTTL is the longest a link can stay open.
+	MaxLinkTTL = 30 * 24 * time.Hour
+	// MaxLinkViews bounds the views a link can allow.
+	MaxLinkViews = 100
+	// MaxPassphraseAttempts burns the link after that many wrong passphrases.
+	MaxPassphraseAttempts = 5
+	// MaxLinkEvents is the number of access events kept on a link.
+	MaxLinkEvents = 100
+	// LinkExpiryCheckInterval is how often the payloads of expired links are removed.
+	LinkExpiryCheckInterval = time.Minute
+
+	linkIDBytes = 24
+)
+
+type ShareLinkSVC struct {
+	logger          *logrus.Logger
+	userSvc         *user.UserSVC
+	notificationSvc *notification.NotificationSVC
+}
+
+func New(logger *logrus.Logger, userSvc *user.UserSVC, notificationSvc *notification.NotificationSVC) *ShareLinkSVC {
+	s := &ShareLinkSVC{logger: logger, userSvc: userSvc, notificationSvc: notificationSvc}
+	return s
+}
+
+// Create stores the client encrypted payload under a new random link id.
+func (s *ShareLinkSVC) Create(ctx context.Context, userId model.UserID, data model.ShareLinkCreate) (link model.ShareLink, err error) {
+	if data.EncryptedData == "" || data.MaxViews < 0 || data.MaxViews > MaxLinkViews {
+		err = errors.ErrInvalidShareLink
+		return
+	}
+
+	now := time.Now()
+
+	expiresAt := data.ExpiresAt
+
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(DefaultLinkTTL)
+	}
+
+	if !expiresAt.After(now) || expiresAt.After(now.Add(MaxLinkTTL)) {
+		err = errors.ErrInvalidShareLink
+		return
+	}
+
+	_, err = s.userSvc.GetOrganizationMembership(ctx, userId, model.OrganizationID(data.OrganizationID))
+
+	if err != nil {
+		return
+	}
+
+	maxViews := data.MaxViews
+
+	if maxViews == 0 {
+		maxViews = 1
+	}
+
+	linkId, err := newLinkID()
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while generating share link id")
+		err = errors.ErrUnknown
+		return
+	}
+
+	docLink := &doc.ShareLink{
+		LinkID:         linkId,
+		CreatedBy:      userId.String(),
+		OrganizationID: data.OrganizationID,
+		Description:    data.Description,
+		EncryptedData:  data.EncryptedData,
+		MaxViews:       maxViews,
+		ExpiresAt:      expiresAt,
+		Events:         []doc.ShareLinkEvent{},
+	}
+
+	if data.Passphrase != "" {
+		hash, hashErr := bcrypt.GenerateFromPassword([]byte(data.Passphrase), bcrypt.DefaultCost)
+
+		if hashErr != nil {
+			s.logger.WithContext(ctx).WithError(hashErr).Error("error while hashing share link passphrase")
+			err = errors.ErrInvalidShareLink
+			return
+		}
+
+		docLink.PassphraseHash = string(hash)
+	}
+
+	err = mgm.Coll(docLink).CreateWithCtx(ctx, docLink)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while creating share link")
+		err = errors.ErrUnknown
+		return
+	}
+
+	link = s.MapDocToShareLink(docLink)
+
+	return
+}
+
+// GetInfo tells the recipient if the link can still be opened and if it needs a passphrase. It does not use a view.
+func (s *ShareLinkSVC) GetInfo(ctx context.Context, linkId model.ShareLinkID) (info model.ShareLinkInfo, err error) {
+	docLink, err := s.getDoc(ctx, linkId)
+
+	if err != nil {
+		return
+	}
+
+	if !isOpenable(docLink) {
+		err = errors.ErrShareLinkNotFound
+		return
+	}
+
+	info = model.ShareLinkInfo{
+		ID:            model.ShareLinkID(docLink.LinkID),
+		ExpiresAt:     docLink.ExpiresAt,
+		HasPassphrase: docLink.PassphraseHash != "",
+	}
+
+	return
+}
+
+// Open uses one view of the link and returns the encrypted payload. The payload is removed once the last view is used.
+func (s *ShareLinkSVC) Open(ctx context.Context, linkId model.ShareLinkID, passphrase string, access model.ShareLinkAccess) (payload model.ShareLinkPayload, err error) {
+	logger := s.logger.WithContext(ctx).WithField("linkId", linkId.String())
+
+	docLink, err := s.getDoc(ctx, linkId)
+
+	if err != nil {
+		return
+	}
+
+	if !isOpenable(docLink) {
+		s.pushEvent(ctx, docLink.LinkID, model.ShareLinkEventDenied, access, nil)
+		err = errors.ErrShareLinkNotFound
+		return
+	}
+
+	if docLink.PassphraseHash != "" && bcrypt.CompareHashAndPassword([]byte(docLink.PassphraseHash), []byte(passphrase)) != nil {
+		s.failPassphrase(ctx, docLink, access)
+		err = errors.ErrShareLinkPassphrase
+		return
+	}
+
+	now := time.Now()
+
+	// The view is only counted if the link is still open, concurrent openers cannot go over the max views.
+	filter := bson.M{
+		"linkId":    docLink.LinkID,
+		"burned":    false,
+		"expiresAt": bson.M{"$gt": now},
+		"$expr":     bson.M{"$lt": bson.A{"$views", "$maxViews"}},
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"views": 1},
+		"$set": bson.M{doc.UpdatedAtField: now},
+		"$push": bson.M{"events": bson.M{
+			"$each":  bson.A{eventDoc(model.ShareLinkEventViewed, access)},
+			"$slice": -MaxLinkEvents,
+		}},
+	}
+
+	opened := &doc.ShareLink{}
+
+	err = mgm.Coll(opened).FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(opened)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			err = errors.ErrShareLinkNotFound
+			return
+		}
+		logger.WithError(err).Error("error while opening share link")
+		err = errors.ErrUnknown
+		return
+	}
+
+	payload = model.ShareLinkPayload{
+		EncryptedData:  opened.EncryptedData,
+		RemainingViews: opened.MaxViews - opened.Views,
+	}
+
+	if payload.RemainingViews <= 0 {
+		s.burn(ctx, opened.LinkID, model.ShareLinkEventBurned, model.ShareLinkAccess{})
+	}
+
+	s.notificationSvc.Notify(ctx, model.UserID(opened.CreatedBy), model.NotificationShareLinkOpened, "Your share link was opened", map[string]string{
+		"linkId":         opened.LinkID,
+		"remainingViews": strconv.Itoa(payload.RemainingViews),
+	})
+
+	return
+}
+
+// failPassphrase records the wrong passphrase and burns the link once too many were tried. The count is
+// read back from the increment so concurrent wrong guesses cannot go past the max attempts.
+func (s *ShareLinkSVC) failPassphrase(ctx context.Context, docLink *doc.ShareLink, access model.ShareLinkAccess) {
+	update := bson.M{
+		"$inc": bson.M{"failedPassphrases": 1},
+		"$push": bson.M{"events": bson.M{
+			"$each":  bson.A{eventDoc(model.ShareLinkEventPassphraseFailed, access)},
+			"$slice": -MaxLinkEvents,
+		}},
+	}
+
+	failed := &doc.ShareLink{}
+
+	err := mgm.Coll(failed).FindOneAndUpdate(ctx, bson.M{"linkId": docLink.LinkID}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(failed)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("linkId", docLink.LinkID).WithError(err).Error("error while recording failed passphrase")
+		return
+	}
+
+	if failed.FailedPassphrases >= MaxPassphraseAttempts {
+		s.burn(ctx, docLink.LinkID, model.ShareLinkEventBurned, model.ShareLinkAccess{})
+	}
+}
+
+// GetForUser lists the links created by the user together with their access events.
+func (s *ShareLinkSVC) GetForUser(ctx context.Context, userId model.UserID, params model.PaginationParams) ([]model.ShareLink, model.PageInfo, error) {
+	if userId == "" {
+		return nil, model.PageInfo{}, errors.ErrInvalidID
+	}
+
+	cursor, err := pagination.Find(ctx, mgm.Coll(&doc.ShareLink{}), bson.M{"createdBy": userId.String()}, params)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching share links of user")
+		return nil, model.PageInfo{}, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	links := make([]model.ShareLink, 0)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.ShareLink
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding share link doc")
+			continue
+		}
+
+		links = append(links, s.MapDocToShareLink(&curDoc))
+	}
+
+	return links, cursor.Info(), nil
+}
+
+// GetByID returns the link with its access events. Only the creator can see it.
+func (s *ShareLinkSVC) GetByID(ctx context.Context, linkId model.ShareLinkID, userId model.UserID) (link model.ShareLink, err error) {
+	docLink, err := s.getDoc(ctx, linkId)
+
+	if err != nil {
+		return
+	}
+
+	if docLink.CreatedBy != userId.String() {
+		err = errors.ErrShareLinkAccessDenied
+		return
+	}
+
+	link = s.MapDocToShareLink(docLink)
+
+	return
+}
+
+// Revoke burns the link before it was used up.
+func (s *ShareLinkSVC) Revoke(ctx context.Context, linkId model.ShareLinkID, userId model.UserID) (link model.ShareLink, err error) {
+	docLink, err := s.getDoc(ctx, linkId)
+
+	if err != nil {
+		return
+	}
+
+	if docLink.CreatedBy != userId.String() {
+		err = errors.ErrShareLinkAccessDenied
+		return
+	}
+
+	if !docLink.Burned {
+		s.burn(ctx, docLink.LinkID, model.ShareLinkEventRevoked, model.ShareLinkAccess{})
+	}
+
+	return s.GetByID(ctx, linkId, userId)
+}
+
+// BurnExpiredLinks removes the payloads of the links which expired before they were used up.
+func (s *ShareLinkSVC) BurnExpiredLinks(ctx context.Context) {
+	filter := bson.M{
+		"burned":    false,
+		"expiresAt": bson.M{"$lte": time.Now()},
+	}
+
+	cursor, err := mgm.Coll(&doc.ShareLink{}).Find(ctx, filter, options.Find().SetProjection(bson.M{"linkId": 1}))
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching expired share links")
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.ShareLink
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("error while decoding share link doc")
+			continue
+		}
+
+		s.burn(ctx, curDoc.LinkID, model.ShareLinkEventExpired, model.ShareLinkAccess{})
+	}
+}
+
+// burn removes the payload of the link for good and records why.
+func (s *ShareLinkSVC) burn(ctx context.Context, linkId string, reason string, access model.ShareLinkAccess) {
+	filter := bson.M{
+		"linkId": linkId,
+		"burned": false,
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"burned": true, doc.UpdatedAtField: time.Now()},
+		"$unset": bson.M{"encryptedData": ""},
+		"$push": bson.M{"events": bson.M{
+			"$each":  bson.A{eventDoc(reason, access)},
+			"$slice": -MaxLinkEvents,
+		}},
+	}
+
+	_, err := mgm.Coll(&doc.ShareLink{}).UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("linkId", linkId).WithError(err).Error("error while burning share link")
+	}
+}
+
+// pushEvent records an access event on the link, with optional counters to increment.
+func (s *ShareLinkSVC) pushEvent(ctx context.Context, linkId string, eventType string, access model.ShareLinkAccess, inc bson.M) {
+	update := bson.M{
+		"$push": bson.M{"events": bson.M{
+			"$each":  bson.A{eventDoc(eventType, access)},
+			"$slice": -MaxLinkEvents,
+		}},
+	}
+
+	if inc != nil {
+		update["$inc"] = inc
+	}
+
+	_, err := mgm.Coll(&doc.ShareLink{}).UpdateOne(ctx, bson.M{"linkId": linkId}, update)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithField("linkId", linkId).WithError(err).Error("error while recording share link event")
+	}
+}
+
+func (s *ShareLinkSVC) getDoc(ctx context.Context, linkId model.ShareLinkID) (*doc.ShareLink, error) {
+	if linkId == "" {
+		return nil, errors.ErrShareLinkNotFound
+	}
+
+	docLink := &doc.ShareLink{}
+
+	err := mgm.Coll(docLink).First(bson.M{"linkId": linkId.String()}, docLink)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrShareLinkNotFound
+		}
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching share link")
+		return nil, errors.ErrUnknown
+	}
+
+	return docLink, nil
+}
+
+// isOpenable reports if the link still has a payload and views left.
+func isOpenable(docLink *doc.ShareLink) bool {
+	return !docLink.Burned && docLink.ExpiresAt.After(time.Now()) && docLink.Views < docLink.MaxViews
+}
+
+func eventDoc(eventType string, access model.ShareLinkAccess) doc.ShareLinkEvent {
+	return doc.ShareLinkEvent{
+		Type:      eventType,
+		At:        time.Now(),
+		IP:        access.IP,
+		UserAgent: access.UserAgent,
+	}
+}
+
+// newLinkID generates a random URL safe link id.
+func newLinkID() (string, error) {
+	buf := make([]byte, linkIDBytes)
+
+	_, err := rand.Read(buf)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (s *ShareLinkSVC) MapDocToShareLink(docLink *doc.ShareLink) model.ShareLink {
+	link := model.ShareLink{
+		ID:             model.ShareLinkID(docLink.LinkID),
+		CreatedAt:      docLink.CreatedAt,
+		CreatedBy:      model.UserID(docLink.CreatedBy),
+		OrganizationID: docLink.OrganizationID,
+		Description:    docLink.Description,
+		MaxViews:       docLink.MaxViews,
+		Views:          docLink.Views,
+		ExpiresAt:      docLink.ExpiresAt,
+		HasPassphrase:  docLink.PassphraseHash != "",
+		Burned:         docLink.Burned,
+		Events:         []model.ShareLinkEvent{},
+	}
+
+	for _, event := range docLink.Events {
+		link.Events = append(link.Events, model.ShareLinkEvent{
+			Type:      event.Type,
+			At:        event.At,
+			IP:        event.IP,
+			UserAgent: event.UserAgent,
+		})
+	}
+
+	return link
+}
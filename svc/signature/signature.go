@@ -0,0 +1,110 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"strings"
+)
+
+// Raw ECDSA P-256 signatures, as made by WebCrypto, are r and s of 32 bytes each. Longer ones are ASN.1.
+const rawES256Length = 64
+
+// decode accepts standard or URL base64, with or without padding.
+func decode(encoded string) ([]byte, error) {
+	trimmed := strings.TrimRight(encoded, "=")
+
+	decoded, err := base64.RawStdEncoding.DecodeString(trimmed)
+
+	if err != nil {
+		decoded, err = base64.RawURLEncoding.DecodeString(trimmed)
+	}
+
+	return decoded, err
+}
+
+// ParsePublicKey reads a base64 DER encoded SubjectPublicKeyInfo of an Ed25519 or P-256 key. The key id
+// is derived from the key so the same key always gets the same id.
+func ParsePublicKey(encoded string) (model.SigningKey, error) {
+	der, err := decode(encoded)
+
+	if err != nil || len(der) == 0 {
+		return model.SigningKey{}, errors.ErrInvalidSigningKey
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(der)
+
+	if err != nil {
+		return model.SigningKey{}, errors.ErrInvalidSigningKey
+	}
+
+	key := model.SigningKey{Public: base64.StdEncoding.EncodeToString(der)}
+
+	switch pub := pub.(type) {
+	case ed25519.PublicKey:
+		key.Alg = model.SigningAlgEd25519
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return model.SigningKey{}, errors.ErrInvalidSigningKey
+		}
+		key.Alg = model.SigningAlgES256
+	default:
+		return model.SigningKey{}, errors.ErrInvalidSigningKey
+	}
+
+	sum := sha256.Sum256(der)
+	key.KeyID = hex.EncodeToString(sum[:16])
+
+	return key, nil
+}
+
+// Verify checks the base64 encoded signature of the payload against the key.
+func Verify(key model.SigningKey, payload []byte, value string) error {
+	sig, err := decode(value)
+
+	if err != nil || len(sig) == 0 {
+		return errors.ErrInvalidSecretSignature
+	}
+
+	der, err := decode(key.Public)
+
+	if err != nil {
+		return errors.ErrInvalidSigningKey
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(der)
+
+	if err != nil {
+		return errors.ErrInvalidSigningKey
+	}
+
+	valid := false
+
+	switch pub := pub.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(pub, payload, sig)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(payload)
+
+		if len(sig) == rawES256Length {
+			r := new(big.Int).SetBytes(sig[:rawES256Length/2])
+			s := new(big.Int).SetBytes(sig[rawES256Length/2:])
+			valid = ecdsa.Verify(pub, digest[:], r, s)
+		} else {
+			valid = ecdsa.VerifyASN1(pub, digest[:], sig)
+		}
+	}
+
+	if !valid {
+		return errors.ErrInvalidSecretSignature
+	}
+
+	return nil
+}
@@ -2,9 +2,22 @@ package svc
 
 import (
 	"secaas_backend/db"
+	"secaas_backend/svc/accessrequest"
+	"secaas_backend/svc/archive"
+	"secaas_backend/svc/attachment"
+	"secaas_backend/svc/audit"
+	"secaas_backend/svc/breakglass"
+	"secaas_backend/svc/collection"
+	"secaas_backend/svc/emergencyaccess"
 	"secaas_backend/svc/invite"
+	"secaas_backend/svc/notification"
 	"secaas_backend/svc/organization"
+	"secaas_backend/svc/project"
+	"secaas_backend/svc/scheduler"
 	"secaas_backend/svc/secret"
+	"secaas_backend/svc/sharelink"
+	"secaas_backend/svc/sync"
+	"secaas_backend/svc/team"
 	"secaas_backend/svc/user"
 
 	"github.com/sirupsen/logrus"
@@ -14,18 +27,54 @@ type SVC struct {
 	logger *logrus.Logger
 	db     *db.DB
 
-	User         *user.UserSVC
-	Invite       *invite.InviteSVC
-	Organization *organization.OrganizationSVC
-	Secrets      *secret.SecretsSVC
+	User            *user.UserSVC
+	Invite          *invite.InviteSVC
+	Organization    *organization.OrganizationSVC
+	Secrets         *secret.SecretsSVC
+	Notification    *notification.NotificationSVC
+	Team            *team.TeamSVC
+	Collection      *collection.CollectionSVC
+	Project         *project.ProjectSVC
+	Attachment      *attachment.AttachmentSVC
+	ShareLink       *sharelink.ShareLinkSVC
+	AccessRequest   *accessrequest.AccessRequestSVC
+	Audit           *audit.AuditSVC
+	BreakGlass      *breakglass.BreakGlassSVC
+	EmergencyAccess *emergencyaccess.EmergencyAccessSVC
+	Archive         *archive.ArchiveSVC
+	Sync            *sync.SyncSVC
+	Scheduler       *scheduler.Scheduler
 }
 
 func New(logger *logrus.Logger, db *db.DB) *SVC {
 	u := user.New(logger)
 	i := invite.New(logger)
-	org := organization.New(logger)
-	sec := secret.New(logger, u)
+	org := organization.New(logger, u)
+	n := notification.New(logger)
+	t := team.New(logger, u)
+	col := collection.New(logger, u, t)
+	p := project.New(logger, u)
+	aud := audit.New(logger, u)
+	sec := secret.New(logger, u, t, col, p, n, aud)
+	att := attachment.New(logger, db, u, sec)
+	sl := sharelink.New(logger, u, n)
+	ar := accessrequest.New(logger, u, sec, n)
+	bg := breakglass.New(logger, u, aud, n)
+	ea := emergencyaccess.New(logger, u, sec, n)
+	arc := archive.New(logger, u, aud)
+	syn := sync.New(logger, u, t, org, i, sec, arc)
 
-	s := &SVC{logger: logger, db: db, User: u, Invite: i, Organization: org, Secrets: sec}
+	sch := scheduler.New(logger)
+	sch.Add("revoke-expired-shares", secret.ShareExpiryCheckInterval, sec.RevokeExpiredShares)
+	sch.Add("remind-expiring-shares", secret.ShareExpiryCheckInterval, sec.RemindExpiringShares)
+	sch.Add("expire-secret-checkouts", secret.CheckoutExpiryCheckInterval, sec.ExpireCheckouts)
+	sch.Add("remind-secret-rotations", secret.RotationCheckInterval, sec.RemindRotations)
+	sch.Add("remove-expired-attachment-uploads", attachment.UploadCleanupInterval, att.RemoveExpiredUploads)
+	sch.Add("burn-expired-share-links", sharelink.LinkExpiryCheckInterval, sl.BurnExpiredLinks)
+	sch.Add("expire-access-requests", accessrequest.ExpiryCheckInterval, ar.ExpireRequests)
+	sch.Add("expire-break-glass-unlocks", breakglass.ExpiryCheckInterval, bg.ExpireUnlocks)
+	sch.Add("approve-emergency-access-requests", emergencyaccess.ApprovalCheckInterval, ea.ApproveWaitedRequests)
+
+	s := &SVC{logger: logger, db: db, User: u, Invite: i, Organization: org, Secrets: sec, Notification: n, Team: t, Collection: col, Project: p, Attachment: att, ShareLink: sl, AccessRequest: ar, Audit: aud, BreakGlass: bg, EmergencyAccess: ea, Archive: arc, Sync: syn, Scheduler: sch}
 	return s
 }
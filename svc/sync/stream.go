@@ -0,0 +1,142 @@
+package sync
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StreamHeartbeat is how often an idle stream sends a comment so proxies keep the connection open.
+const StreamHeartbeat = 30 * time.Second
+
+// resumeErrorCodes are the server errors of a change stream which cannot continue after its resume token:
+// InvalidResumeToken, ChangeStreamFatalError and ChangeStreamHistoryLost.
+var resumeErrorCodes = []int{260, 280, 286}
+
+func isResumeError(err error) bool {
+	serverErr, ok := err.(mongo.ServerError)
+
+	if !ok {
+		return false
+	}
+
+	for _, code := range resumeErrorCodes {
+		if serverErr.HasErrorCode(code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Subscription follows the sync events of one user through a change stream on the sync events.
+type Subscription struct {
+	svc    *SyncSVC
+	userId model.UserID
+	stream *mongo.ChangeStream
+	email  model.Email
+}
+
+// Subscribe opens a change stream on the sync events of the user, after the resume token when one is given.
+// ErrSyncResetRequired is returned when the stream cannot resume after the token any more, the client catches
+// up through GetChanges then. Change streams need a replica set, without one ErrChangeStreamUnavailable is returned.
+func (s *SyncSVC) Subscribe(ctx context.Context, userId model.UserID, resumeToken string) (*Subscription, error) {
+	logger := s.logger.WithContext(ctx).WithField("userId", userId.String())
+
+	_, err := s.userSvc.GetByID(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"operationType":       "insert",
+			"fullDocument.userId": userId.String(),
+		}}},
+	}
+
+	streamOptions := options.ChangeStream()
+
+	if resumeToken != "" {
+		streamOptions.SetResumeAfter(bson.M{"_data": resumeToken})
+	}
+
+	stream, err := mgm.Coll(&doc.SyncEvent{}).Watch(ctx, pipeline, streamOptions)
+
+	if err != nil {
+		if resumeToken != "" && isResumeError(err) {
+			return nil, errors.ErrSyncResetRequired
+		}
+		logger.WithError(err).Error("error while opening sync change stream")
+		return nil, errors.ErrChangeStreamUnavailable
+	}
+
+	return &Subscription{svc: s, userId: userId, stream: stream}, nil
+}
+
+// Next waits for the next change of the user and returns it with the resume token which follows it.
+// The change carries the state of the object at the time it is read, so access is checked for every change.
+func (sub *Subscription) Next(ctx context.Context) (resumeToken string, change model.SyncChange, err error) {
+	logger := sub.svc.logger.WithContext(ctx).WithField("userId", sub.userId.String())
+
+	for sub.stream.Next(ctx) {
+		var curEvent struct {
+			FullDocument doc.SyncEvent `bson:"fullDocument"`
+		}
+
+		err := sub.stream.Decode(&curEvent)
+
+		if err != nil {
+			logger.WithError(err).Error("error while decoding sync change event")
+			continue
+		}
+
+		event := curEvent.FullDocument
+
+		known, err := sub.svc.knownObjects(ctx, sub.userId, event.Revision-1, bson.A{event.ObjectID})
+
+		if err != nil {
+			return "", model.SyncChange{}, err
+		}
+
+		change, err = sub.svc.resolve(ctx, sub.userId, event, known[event.ObjectID], &sub.email)
+
+		if err != nil {
+			return "", model.SyncChange{}, err
+		}
+
+		resumeToken, _ = sub.stream.ResumeToken().Lookup("_data").StringValueOK()
+
+		return resumeToken, change, nil
+	}
+
+	if ctx.Err() != nil {
+		err = ctx.Err()
+		return
+	}
+
+	err = sub.stream.Err()
+
+	if isResumeError(err) {
+		err = errors.ErrSyncResetRequired
+		return
+	}
+
+	logger.WithError(err).Error("sync change stream was interrupted")
+	err = errors.ErrChangeStreamUnavailable
+
+	return
+}
+
+// Close stops the change stream.
+func (sub *Subscription) Close(ctx context.Context) {
+	sub.stream.Close(ctx)
+}
@@ -0,0 +1,483 @@
+package sync
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/archive"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/invite"
+	"secaas_backend/svc/organization"
+	"secaas_backend/svc/secret"
+	"secaas_backend/svc/team"
+	"secaas_backend/svc/user"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultChangeLimit is the number of change events read for one sync call.
+	DefaultChangeLimit = 100
+	// MaxChangeLimit bounds the limit a client can ask for.
+	MaxChangeLimit = 500
+
+	// eventWriteGrace is how long a revision may be allocated without its event before it counts as lost.
+	eventWriteGrace = time.Minute
+)
+
+// SyncSVC hands out a monotonic revision to every change a user can see. Only the fact that an object changed
+// is recorded, the changes returned to the client carry the current state of the objects.
+type SyncSVC struct {
+	logger    *logrus.Logger
+	userSvc   *user.UserSVC
+	inviteSvc *invite.InviteSVC
+	secretSvc *secret.SecretsSVC
+}
+
+func New(logger *logrus.Logger, userSvc *user.UserSVC, teamSvc *team.TeamSVC, organizationSvc *organization.OrganizationSVC, inviteSvc *invite.InviteSVC, secretSvc *secret.SecretsSVC, archiveSvc *archive.ArchiveSVC) *SyncSVC {
+	s := &SyncSVC{logger: logger, userSvc: userSvc, inviteSvc: inviteSvc, secretSvc: secretSvc}
+
+	secretSvc.RegisterChangeHook(s.secretChanged)
+	teamSvc.RegisterMemberHook(s.teamMembersChanged)
+	organizationSvc.RegisterMemberHook(s.membersChanged)
+	inviteSvc.RegisterChangeHook(s.inviteChanged)
+	archiveSvc.RegisterImportHook(s.organizationImported)
+
+	return s
+}
+
+// GetRevision returns the latest revision of the user, clients start syncing from it after a full download.
+func (s *SyncSVC) GetRevision(ctx context.Context, userId model.UserID) (revision int64, err error) {
+	if userId == "" {
+		err = errors.ErrInvalidID
+		return
+	}
+
+	revisionDoc := &doc.SyncRevision{}
+
+	err = mgm.Coll(revisionDoc).First(bson.M{"userId": userId.String()}, revisionDoc)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			err = nil
+			return
+		}
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching sync revision")
+		err = errors.ErrUnknown
+		return
+	}
+
+	revision = revisionDoc.Revision
+
+	return
+}
+
+// GetChanges returns the objects which changed after the since revision, each once with its current state.
+// Objects the user can no longer see are returned as deleted tombstones. ErrSyncResetRequired is returned
+// when the changes after since are no longer retained and the client has to download everything again.
+// The returned secrets count as read by the user, the ones pushed through a stream do not.
+func (s *SyncSVC) GetChanges(ctx context.Context, userId model.UserID, since int64, limit int) (result model.SyncResult, err error) {
+	logger := s.logger.WithContext(ctx).WithField("userId", userId.String())
+
+	current, err := s.GetRevision(ctx, userId)
+
+	if err != nil {
+		return
+	}
+
+	if since < 0 || since > current {
+		err = errors.ErrInvalidSyncRevision
+		return
+	}
+
+	if limit <= 0 || limit > MaxChangeLimit {
+		limit = DefaultChangeLimit
+	}
+
+	result = model.SyncResult{Revision: current, Changes: []model.SyncChange{}}
+
+	if since == current {
+		return
+	}
+
+	// Every revision gets an event, a missing one was dropped by the retention or is still being written.
+	next, err := mgm.Coll(&doc.SyncEvent{}).CountDocuments(ctx, bson.M{"userId": userId.String(), "revision": since + 1})
+
+	if err != nil {
+		logger.WithError(err).Error("error while checking sync retention")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if next == 0 {
+		pending, pendingErr := s.pendingRevision(ctx, userId, since)
+
+		if pendingErr != nil {
+			err = pendingErr
+			return
+		}
+
+		if !pending {
+			err = errors.ErrSyncResetRequired
+			return
+		}
+
+		// A concurrent change has not written its event yet, the client asks again from the same revision.
+		result.Revision = since
+		result.HasMore = true
+		return
+	}
+
+	findOptions := options.Find().SetLimit(int64(limit + 1)).SetSort(bson.D{{Key: "revision", Value: 1}})
+
+	cursor, err := mgm.Coll(&doc.SyncEvent{}).Find(ctx, bson.M{"userId": userId.String(), "revision": bson.M{"$gt": since}}, findOptions)
+
+	if err != nil {
+		logger.WithError(err).Error("error while fetching sync events")
+		err = errors.ErrUnknown
+		return
+	}
+
+	events := []doc.SyncEvent{}
+
+	for cursor.Next(ctx) {
+		var curDoc doc.SyncEvent
+
+		err := cursor.Decode(&curDoc)
+
+		if err != nil {
+			logger.WithError(err).Error("error while decoding sync event")
+			result.HasMore = true
+			break
+		}
+
+		// Stop at a gap, a concurrent change may not have written its event yet.
+		if curDoc.Revision != since+int64(len(events))+1 {
+			result.HasMore = true
+			break
+		}
+
+		events = append(events, curDoc)
+	}
+
+	cursor.Close(ctx)
+
+	if len(events) > limit {
+		events = events[:limit]
+		result.HasMore = true
+	}
+
+	if len(events) == 0 {
+		err = errors.ErrUnknown
+		return
+	}
+
+	result.Revision = events[len(events)-1].Revision
+
+	// Keep the last event of every object, the state is read once per object.
+	latest := map[string]int{}
+	objectIds := bson.A{}
+
+	for i, event := range events {
+		key := event.Type + "/" + event.ObjectID
+
+		if _, ok := latest[key]; !ok {
+			objectIds = append(objectIds, event.ObjectID)
+		}
+
+		latest[key] = i
+	}
+
+	known, err := s.knownObjects(ctx, userId, since, objectIds)
+
+	if err != nil {
+		return
+	}
+
+	var email model.Email
+
+	polled := []model.Secret{}
+
+	for i, event := range events {
+		if latest[event.Type+"/"+event.ObjectID] != i {
+			continue
+		}
+
+		change, resolveErr := s.resolve(ctx, userId, event, known[event.ObjectID], &email)
+
+		if resolveErr != nil {
+			err = resolveErr
+			return
+		}
+
+		result.Changes = append(result.Changes, change)
+
+		if change.Secret != nil {
+			polled = append(polled, *change.Secret)
+		}
+	}
+
+	s.secretSvc.RecordAccesses(ctx, polled, userId)
+
+	return
+}
+
+// resolve reads the current state of the object of the event as the user sees it. Objects the user
+// cannot see are tombstones, visible objects the client did not know of are created.
+// The email of the user is looked up once on the first invite and kept for the next events.
+func (s *SyncSVC) resolve(ctx context.Context, userId model.UserID, event doc.SyncEvent, known bool, email *model.Email) (change model.SyncChange, err error) {
+	change = model.SyncChange{
+		Revision: event.Revision,
+		Type:     event.Type,
+		Action:   model.SyncActionUpdated,
+		ObjectID: event.ObjectID,
+	}
+
+	visible := false
+
+	switch event.Type {
+	case model.SyncTypeSecret:
+		sec, viewErr := s.secretSvc.SyncView(ctx, model.SecretID(event.ObjectID), userId)
+
+		if viewErr != nil && viewErr != errors.ErrSecretNotFound && viewErr != errors.ErrSecretAccessDenied && viewErr != errors.ErrInvalidID {
+			err = viewErr
+			return
+		}
+
+		if viewErr == nil {
+			visible = true
+			change.OrganizationID = sec.OrganizationID
+			change.Secret = &sec
+		}
+	case model.SyncTypeMembership:
+		membership, memberErr := s.userSvc.GetOrganizationMembership(ctx, userId, model.OrganizationID(event.ObjectID))
+
+		if memberErr != nil && memberErr != errors.ErrNotOrganizationMember {
+			err = memberErr
+			return
+		}
+
+		if memberErr == nil {
+			visible = true
+			change.OrganizationID = membership.ID
+			change.Membership = &membership
+		}
+	case model.SyncTypeInvite:
+		if *email == "" {
+			caller, userErr := s.userSvc.GetByID(ctx, userId)
+
+			if userErr != nil {
+				err = userErr
+				return
+			}
+
+			*email = caller.Email
+		}
+
+		inv, inviteErr := s.inviteSvc.GetByID(ctx, model.InviteID(event.ObjectID))
+
+		if inviteErr != nil && inviteErr != errors.ErrInviteNotFound && inviteErr != errors.ErrInvalidID {
+			err = inviteErr
+			return
+		}
+
+		if inviteErr == nil && inv.ExpiresAt.After(time.Now()) && (inv.ToUserEmail == email.String() || s.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(inv.OrganizationID))) {
+			visible = true
+			change.OrganizationID = inv.OrganizationID
+			change.Invite = &inv
+		}
+	}
+
+	if !visible {
+		change.Action = model.SyncActionDeleted
+	} else if !known {
+		change.Action = model.SyncActionCreated
+	}
+
+	return
+}
+
+// knownObjects returns the objects the client was told about up to the since revision, as far as the
+// events are retained. The other objects which are still visible are reported as created.
+func (s *SyncSVC) knownObjects(ctx context.Context, userId model.UserID, since int64, objectIds bson.A) (map[string]bool, error) {
+	known := map[string]bool{}
+
+	if since == 0 || len(objectIds) == 0 {
+		return known, nil
+	}
+
+	filter := bson.M{
+		"userId":   userId.String(),
+		"objectId": bson.M{"$in": objectIds},
+		"revision": bson.M{"$lte": since},
+	}
+
+	values, err := mgm.Coll(&doc.SyncEvent{}).Distinct(ctx, "objectId", filter)
+
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("error while fetching known sync objects")
+		return nil, errors.ErrUnknown
+	}
+
+	for _, value := range values {
+		if objectId, ok := value.(string); ok {
+			known[objectId] = true
+		}
+	}
+
+	return known, nil
+}
+
+// pendingRevision tells whether the revision after since was handed out so recently that its event may still
+// be written. The event of the next retained revision, or the revision counter when there is none, was created
+// after the missing revision was allocated.
+func (s *SyncSVC) pendingRevision(ctx context.Context, userId model.UserID, since int64) (bool, error) {
+	logger := s.logger.WithContext(ctx).WithField("userId", userId.String())
+
+	allocatedBefore := time.Time{}
+
+	event := &doc.SyncEvent{}
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "revision", Value: 1}})
+
+	err := mgm.Coll(event).FindOne(ctx, bson.M{"userId": userId.String(), "revision": bson.M{"$gt": since + 1}}, findOptions).Decode(event)
+
+	if err == nil {
+		allocatedBefore = event.CreatedAt
+	} else if !strings.Contains(err.Error(), "no documents") {
+		logger.WithError(err).Error("error while fetching next sync event")
+		return false, errors.ErrUnknown
+	} else {
+		revisionDoc := &doc.SyncRevision{}
+
+		err = mgm.Coll(revisionDoc).First(bson.M{"userId": userId.String()}, revisionDoc)
+
+		if err != nil {
+			logger.WithError(err).Error("error while fetching sync revision")
+			return false, errors.ErrUnknown
+		}
+
+		allocatedBefore = revisionDoc.UpdatedAt
+	}
+
+	return time.Since(allocatedBefore) < eventWriteGrace, nil
+}
+
+// record adds an event for the object at the next revision of every user.
+// A revision whose event could not be written looks like a dropped one once eventWriteGrace has passed
+// and makes the client start over.
+func (s *SyncSVC) record(ctx context.Context, userIds []model.UserID, changeType string, objectId string) {
+	logger := s.logger.WithContext(ctx).WithField("type", changeType).WithField("objectId", objectId)
+
+	seen := map[model.UserID]bool{}
+
+	for _, userId := range userIds {
+		if userId == "" || seen[userId] {
+			continue
+		}
+
+		seen[userId] = true
+
+		now := time.Now()
+		revisionDoc := &doc.SyncRevision{}
+
+		update := bson.M{
+			"$inc":         bson.M{"revision": 1},
+			"$set":         bson.M{doc.UpdatedAtField: now},
+			"$setOnInsert": bson.M{doc.CreatedAtField: now},
+		}
+
+		updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+		err := mgm.Coll(revisionDoc).FindOneAndUpdate(ctx, bson.M{"userId": userId.String()}, update, updateOptions).Decode(revisionDoc)
+
+		if err != nil {
+			logger.WithField("userId", userId.String()).WithError(err).Error("error while allocating sync revision")
+			continue
+		}
+
+		event := &doc.SyncEvent{
+			UserID:   userId.String(),
+			Revision: revisionDoc.Revision,
+			Type:     changeType,
+			ObjectID: objectId,
+		}
+
+		err = mgm.Coll(event).CreateWithCtx(ctx, event)
+
+		if err != nil {
+			logger.WithField("userId", userId.String()).WithError(err).Error("error while recording sync event")
+		}
+	}
+}
+
+func (s *SyncSVC) secretChanged(ctx context.Context, secretId model.SecretID, userIds []model.UserID) {
+	s.record(ctx, userIds, model.SyncTypeSecret, secretId.String())
+}
+
+// teamMembersChanged records the secrets of the team for the members who joined or left it.
+func (s *SyncSVC) teamMembersChanged(ctx context.Context, teamId model.TeamID, memberIds []model.UserID) {
+	secretIds, err := s.secretSvc.GetTeamSecretIDs(ctx, teamId)
+
+	if err != nil {
+		return
+	}
+
+	for _, secretId := range secretIds {
+		s.record(ctx, memberIds, model.SyncTypeSecret, secretId.String())
+	}
+}
+
+func (s *SyncSVC) membersChanged(ctx context.Context, orgId model.OrganizationID, memberIds []model.UserID) {
+	s.record(ctx, memberIds, model.SyncTypeMembership, orgId.String())
+}
+
+// inviteChanged records the invite for its recipient and the admins of the organization.
+func (s *SyncSVC) inviteChanged(ctx context.Context, inv model.Invite, accepted bool) {
+	userIds, err := s.userSvc.GetOrganizationAdminIDs(ctx, model.OrganizationID(inv.OrganizationID))
+
+	if err != nil {
+		userIds = []model.UserID{}
+	}
+
+	recipient, err := s.userSvc.GetByEmail(ctx, model.Email(inv.ToUserEmail))
+
+	if err == nil {
+		userIds = append(userIds, recipient.ID)
+
+		if accepted {
+			s.membersChanged(ctx, model.OrganizationID(inv.OrganizationID), []model.UserID{recipient.ID})
+		}
+	}
+
+	s.record(ctx, userIds, model.SyncTypeInvite, inv.ID.String())
+}
+
+// organizationImported records the memberships, secrets and invites of an imported organization.
+func (s *SyncSVC) organizationImported(ctx context.Context, orgId model.OrganizationID, memberIds []model.UserID, secretIds []model.SecretID, inviteIds []model.InviteID) {
+	s.membersChanged(ctx, orgId, memberIds)
+
+	for _, secretId := range secretIds {
+		holders, err := s.secretSvc.Holders(ctx, secretId)
+
+		if err != nil {
+			continue
+		}
+
+		s.secretChanged(ctx, secretId, holders)
+	}
+
+	for _, inviteId := range inviteIds {
+		inv, err := s.inviteSvc.GetByID(ctx, inviteId)
+
+		if err != nil {
+			continue
+		}
+
+		s.inviteChanged(ctx, inv, false)
+	}
+}
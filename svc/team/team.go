@@ -0,0 +1,491 @@
+package team
+
+import (
+	"context"
+	"secaas_backend/db/doc"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/pagination"
+	"secaas_backend/svc/user"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TeamSVC struct {
+	logger      *logrus.Logger
+	userSvc     *user.UserSVC
+	memberHooks []MemberHook
+}
+
+// MemberHook is called when members gained or lost access to the secrets of the team. On team deletion
+// it is called before the shares made to the team are removed.
+type MemberHook func(ctx context.Context, teamId model.TeamID, memberIds []model.UserID)
+
+func New(logger *logrus.Logger, userSvc *user.UserSVC) *TeamSVC {
+	t := &TeamSVC{logger: logger, userSvc: userSvc}
+	return t
+}
+
+// RegisterMemberHook adds a hook which follows the members who can access the secrets of a team.
+func (t *TeamSVC) RegisterMemberHook(hook MemberHook) {
+	t.memberHooks = append(t.memberHooks, hook)
+}
+
+// activeMemberIDs lists the members who hold a wrapped team key.
+func activeMemberIDs(docTeam *doc.Team) []model.UserID {
+	memberIds := []model.UserID{}
+
+	for _, member := range docTeam.Members {
+		if member.WrappedKey.EncryptedData != "" {
+			memberIds = append(memberIds, model.UserID(member.UserID))
+		}
+	}
+
+	return memberIds
+}
+
+func (t *TeamSVC) Create(ctx context.Context, data model.Team, userId model.UserID) (team model.Team, err error) {
+	if data.OrganizationID == "" {
+		err = errors.ErrInvalidOrganizationID
+		return
+	}
+
+	if !t.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(data.OrganizationID)) {
+		err = errors.ErrTeamAccessDenied
+		return
+	}
+
+	docTeam := &doc.Team{
+		OrganizationID: data.OrganizationID,
+		Name:           data.Name,
+		Description:    data.Description,
+		Members:        []doc.TeamMember{},
+	}
+
+	for _, member := range data.Members {
+		_, err = t.userSvc.GetOrganizationMembership(ctx, member.UserID, model.OrganizationID(data.OrganizationID))
+
+		if err != nil {
+			return
+		}
+
+		docTeam.Members = append(docTeam.Members, doc.TeamMember{
+			UserID:     member.UserID.String(),
+			WrappedKey: doc.SymKey(member.WrappedKey),
+			Admin:      member.Admin,
+			AddedAt:    time.Now(),
+		})
+	}
+
+	err = mgm.Coll(docTeam).CreateWithCtx(ctx, docTeam)
+
+	if err != nil {
+		t.logger.WithContext(ctx).WithError(err).Error("error while creating team")
+		err = errors.ErrUnknown
+		return
+	}
+
+	team = t.MapDocToTeam(docTeam)
+
+	return
+}
+
+// GetByID returns the team to a member of its organization.
+func (t *TeamSVC) GetByID(ctx context.Context, teamId model.TeamID, userId model.UserID) (team model.Team, err error) {
+	docTeam, err := t.getDoc(ctx, teamId)
+
+	if err != nil {
+		return
+	}
+
+	err = t.checkMember(ctx, model.OrganizationID(docTeam.OrganizationID), userId)
+
+	if err != nil {
+		return
+	}
+
+	team = t.MapDocToTeam(docTeam)
+
+	return
+}
+
+// Lookup returns the team without checking the caller, for services which decide on access themselves.
+func (t *TeamSVC) Lookup(ctx context.Context, teamId model.TeamID) (team model.Team, err error) {
+	docTeam, err := t.getDoc(ctx, teamId)
+
+	if err != nil {
+		return
+	}
+
+	team = t.MapDocToTeam(docTeam)
+
+	return
+}
+
+// GetForOrganization lists the teams of the organization to one of its members.
+func (t *TeamSVC) GetForOrganization(ctx context.Context, orgId model.OrganizationID, userId model.UserID, params model.PaginationParams) ([]model.Team, model.PageInfo, error) {
+	if orgId == "" {
+		return nil, model.PageInfo{}, errors.ErrInvalidOrganizationID
+	}
+
+	err := t.checkMember(ctx, orgId, userId)
+
+	if err != nil {
+		return nil, model.PageInfo{}, err
+	}
+
+	filter := bson.M{
+		"organizationId": orgId.String(),
+	}
+
+	cursor, err := pagination.Find(ctx, mgm.Coll(&doc.Team{}), filter, params)
+
+	if err != nil {
+		t.logger.WithContext(ctx).WithError(err).Error("error while fetching teams for organization")
+		return nil, model.PageInfo{}, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	teams := make([]model.Team, 0)
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Team
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			t.logger.WithContext(ctx).WithError(err).Error("error while decoding team doc")
+			continue
+		}
+
+		teams = append(teams, t.MapDocToTeam(&curDoc))
+	}
+
+	return teams, cursor.Info(), nil
+}
+
+func (t *TeamSVC) Delete(ctx context.Context, teamId model.TeamID, userId model.UserID) (deleted int, err error) {
+	docTeam, err := t.getDoc(ctx, teamId)
+
+	if err != nil {
+		return
+	}
+
+	if !t.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(docTeam.OrganizationID)) {
+		err = errors.ErrTeamAccessDenied
+		return
+	}
+
+	res, err := mgm.Coll(docTeam).DeleteOne(ctx, bson.M{"_id": docTeam.ID})
+
+	if err != nil {
+		t.logger.WithContext(ctx).WithError(err).Error("error while deleting team")
+		err = errors.ErrUnknown
+		return
+	}
+
+	deleted = int(res.DeletedCount)
+
+	for _, hook := range t.memberHooks {
+		hook(ctx, teamId, activeMemberIDs(docTeam))
+	}
+
+	// Remove all the secret shares made to the team.
+	_, err = mgm.Coll(&doc.Secret{}).DeleteMany(ctx, bson.M{"team.id": teamId.String()})
+
+	if err != nil {
+		t.logger.WithContext(ctx).WithError(err).Error("error while deleting secret shares of team")
+		err = nil
+	}
+
+	return
+}
+
+// AddMember adds the user to the team. The member stays pending until a wrapped team key is set for them.
+func (t *TeamSVC) AddMember(ctx context.Context, teamId model.TeamID, userId model.UserID, member model.TeamMember) (team model.Team, err error) {
+	docTeam, err := t.getDoc(ctx, teamId)
+
+	if err != nil {
+		return
+	}
+
+	if !t.canManage(ctx, docTeam, userId) {
+		err = errors.ErrTeamAccessDenied
+		return
+	}
+
+	_, err = t.userSvc.GetOrganizationMembership(ctx, member.UserID, model.OrganizationID(docTeam.OrganizationID))
+
+	if err != nil {
+		return
+	}
+
+	// Only add the user when they are not a member yet, a concurrent add of the same user loses.
+	filter := bson.M{
+		"_id":            docTeam.ID,
+		"members.userId": bson.M{"$ne": member.UserID.String()},
+	}
+
+	update := bson.M{
+		"$push": bson.M{"members": doc.TeamMember{
+			UserID:     member.UserID.String(),
+			WrappedKey: doc.SymKey(member.WrappedKey),
+			Admin:      member.Admin,
+			AddedAt:    time.Now(),
+		}},
+		"$set": bson.M{doc.UpdatedAtField: time.Now()},
+	}
+
+	updated := &doc.Team{}
+	updateOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err = mgm.Coll(updated).FindOneAndUpdate(ctx, filter, update, updateOptions).Decode(updated)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			err = errors.ErrTeamMemberExists
+			return
+		}
+		t.logger.WithContext(ctx).WithError(err).Error("error while adding team member")
+		err = errors.ErrUnknown
+		return
+	}
+
+	team = t.MapDocToTeam(updated)
+
+	return
+}
+
+// SetMemberKey stores the team key wrapped for the member, which activates their access.
+func (t *TeamSVC) SetMemberKey(ctx context.Context, teamId model.TeamID, userId model.UserID, memberId model.UserID, wrappedKey model.SymKey) (team model.Team, err error) {
+	if wrappedKey.EncryptedData == "" || wrappedKey.Alg == "" {
+		err = errors.ErrInvalidSymmetricKey
+		return
+	}
+
+	docTeam, err := t.getDoc(ctx, teamId)
+
+	if err != nil {
+		return
+	}
+
+	if !t.canManage(ctx, docTeam, userId) {
+		err = errors.ErrTeamAccessDenied
+		return
+	}
+
+	// The team as it was before the key was set tells whether this activated the member.
+	now := time.Now()
+	previous := &doc.Team{}
+	updateOptions := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	err = mgm.Coll(previous).FindOneAndUpdate(ctx, bson.M{"_id": docTeam.ID, "members.userId": memberId.String()}, bson.M{
+		"$set": bson.M{
+			"members.$.wrappedKey": doc.SymKey(wrappedKey),
+			doc.UpdatedAtField:     now,
+		},
+	}, updateOptions).Decode(previous)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			err = errors.ErrTeamMemberNotFound
+			return
+		}
+		t.logger.WithContext(ctx).WithError(err).Error("error while setting team member key")
+		err = errors.ErrUnknown
+		return
+	}
+
+	activated := false
+
+	for i, member := range previous.Members {
+		if member.UserID == memberId.String() {
+			activated = member.WrappedKey.EncryptedData == ""
+			previous.Members[i].WrappedKey = doc.SymKey(wrappedKey)
+		}
+	}
+
+	previous.UpdatedAt = now
+
+	if activated {
+		for _, hook := range t.memberHooks {
+			hook(ctx, teamId, []model.UserID{memberId})
+		}
+	}
+
+	team = t.MapDocToTeam(previous)
+
+	return
+}
+
+// RemoveMember removes the user from the team. Members can always remove themselves.
+func (t *TeamSVC) RemoveMember(ctx context.Context, teamId model.TeamID, userId model.UserID, memberId model.UserID) (team model.Team, err error) {
+	docTeam, err := t.getDoc(ctx, teamId)
+
+	if err != nil {
+		return
+	}
+
+	if userId != memberId && !t.canManage(ctx, docTeam, userId) {
+		err = errors.ErrTeamAccessDenied
+		return
+	}
+
+	// The team as it was before the member left tells whether they had access.
+	now := time.Now()
+	previous := &doc.Team{}
+	updateOptions := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	err = mgm.Coll(previous).FindOneAndUpdate(ctx, bson.M{"_id": docTeam.ID, "members.userId": memberId.String()}, bson.M{
+		"$pull": bson.M{"members": bson.M{"userId": memberId.String()}},
+		"$set":  bson.M{doc.UpdatedAtField: now},
+	}, updateOptions).Decode(previous)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			err = errors.ErrTeamMemberNotFound
+			return
+		}
+		t.logger.WithContext(ctx).WithError(err).Error("error while removing team member")
+		err = errors.ErrUnknown
+		return
+	}
+
+	members := []doc.TeamMember{}
+	wasActive := false
+
+	for _, member := range previous.Members {
+		if member.UserID != memberId.String() {
+			members = append(members, member)
+		} else {
+			wasActive = member.WrappedKey.EncryptedData != ""
+		}
+	}
+
+	previous.Members = members
+	previous.UpdatedAt = now
+
+	if wasActive {
+		for _, hook := range t.memberHooks {
+			hook(ctx, teamId, []model.UserID{memberId})
+		}
+	}
+
+	team = t.MapDocToTeam(previous)
+
+	return
+}
+
+// GetActiveTeamIDs lists the teams in which the user holds a wrapped team key.
+func (t *TeamSVC) GetActiveTeamIDs(ctx context.Context, userId model.UserID) ([]string, error) {
+	filter := bson.M{
+		"members": bson.M{
+			"$elemMatch": bson.M{
+				"userId":                   userId.String(),
+				"wrappedKey.encryptedData": bson.M{"$nin": bson.A{nil, ""}},
+			},
+		},
+	}
+
+	cursor, err := mgm.Coll(&doc.Team{}).Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+
+	if err != nil {
+		t.logger.WithContext(ctx).WithError(err).Error("error while fetching active teams of user")
+		return nil, errors.ErrUnknown
+	}
+
+	defer cursor.Close(ctx)
+
+	teamIds := []string{}
+
+	for cursor.Next(ctx) {
+		var curDoc doc.Team
+
+		err = cursor.Decode(&curDoc)
+
+		if err != nil {
+			t.logger.WithContext(ctx).WithError(err).Error("error while decoding team doc")
+			continue
+		}
+
+		teamIds = append(teamIds, curDoc.ID.Hex())
+	}
+
+	return teamIds, nil
+}
+
+// canManage reports if the user can change the team, which org admins and active team admins can do.
+func (t *TeamSVC) canManage(ctx context.Context, docTeam *doc.Team, userId model.UserID) bool {
+	for _, member := range docTeam.Members {
+		if member.UserID == userId.String() && member.Admin && member.WrappedKey.EncryptedData != "" {
+			return true
+		}
+	}
+
+	return t.userSvc.IsOrganizationAdmin(ctx, userId, model.OrganizationID(docTeam.OrganizationID))
+}
+
+// checkMember fails with ErrTeamAccessDenied when the user is not a member of the organization.
+func (t *TeamSVC) checkMember(ctx context.Context, orgId model.OrganizationID, userId model.UserID) error {
+	_, err := t.userSvc.GetOrganizationMembership(ctx, userId, orgId)
+
+	if err == errors.ErrNotOrganizationMember || err == errors.ErrInvalidID {
+		return errors.ErrTeamAccessDenied
+	}
+
+	return err
+}
+
+func (t *TeamSVC) getDoc(ctx context.Context, teamId model.TeamID) (*doc.Team, error) {
+	objId, err := primitive.ObjectIDFromHex(teamId.String())
+
+	if err != nil {
+		t.logger.WithContext(ctx).WithError(err).Error("invalid team id found")
+		return nil, errors.ErrInvalidID
+	}
+
+	docTeam := &doc.Team{}
+
+	err = mgm.Coll(docTeam).FindByID(objId, docTeam)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			return nil, errors.ErrTeamNotFound
+		}
+		t.logger.WithContext(ctx).WithError(err).Error("error while fetching team")
+		return nil, errors.ErrUnknown
+	}
+
+	return docTeam, nil
+}
+
+func (t *TeamSVC) MapDocToTeam(docTeam *doc.Team) model.Team {
+	team := model.Team{
+		ID:             model.TeamID(docTeam.ID.Hex()),
+		CreatedAt:      docTeam.CreatedAt,
+		UpdatedAt:      docTeam.UpdatedAt,
+		OrganizationID: docTeam.OrganizationID,
+		Name:           docTeam.Name,
+		Description:    docTeam.Description,
+		Members:        []model.TeamMember{},
+	}
+
+	for _, member := range docTeam.Members {
+		team.Members = append(team.Members, model.TeamMember{
+			UserID:     model.UserID(member.UserID),
+			WrappedKey: model.SymKey(member.WrappedKey),
+			Admin:      member.Admin,
+			AddedAt:    member.AddedAt,
+			Pending:    member.WrappedKey.EncryptedData == "",
+		})
+	}
+
+	return team
+}
@@ -5,13 +5,15 @@ import (
 	"secaas_backend/db/doc"
 	"secaas_backend/model"
 	"secaas_backend/svc/errors"
+	"secaas_backend/svc/pagination"
+	"secaas_backend/svc/signature"
 	"strings"
+	"time"
 
 	"github.com/kamva/mgm/v3"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -60,12 +62,12 @@ func (u *UserSVC) GetByEmail(ctx context.Context, email model.Email) (user model
 	return
 }
 
-func (u *UserSVC) GetUsersByOrganization(ctx context.Context, orgId model.OrganizationID, params model.PaginationParams) ([]model.User, error) {
+func (u *UserSVC) GetUsersByOrganization(ctx context.Context, orgId model.OrganizationID, params model.PaginationParams) ([]model.User, model.PageInfo, error) {
 	log := u.logger.WithContext(ctx)
 
 	if orgId == "" {
 		log.Error("invalid orgId")
-		return nil, errors.ErrInvalidID
+		return nil, model.PageInfo{}, errors.ErrInvalidID
 	}
 
 	coll := mgm.Coll(&doc.User{})
@@ -74,28 +76,20 @@ func (u *UserSVC) GetUsersByOrganization(ctx context.Context, orgId model.Organi
 		"organizations.id": orgId.String(),
 	}
 
-	findOptions := options.Find().SetLimit(int64(params.Limit)).SetSkip(int64(params.Skip)).SetSort(bson.D{
-		{"updatedAt", -1},
-	})
-
-	cur, err := coll.Find(ctx, filter, findOptions)
+	cur, err := pagination.Find(ctx, coll, filter, params)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			log.WithError(err).Error("Users not found.")
-			return nil, errors.ErrUserNotFound
-		}
 		log.WithError(err).Error("Unknown error occurred when getting list of users by organization.")
-		return nil, errors.ErrUnknown
+		return nil, model.PageInfo{}, errors.ErrUnknown
 	}
 	defer cur.Close(ctx)
 
-	var users []model.User
+	users := []model.User{}
 
 	for cur.Next(ctx) {
 		var userDoc doc.User
 		if err := cur.Decode(&userDoc); err != nil {
 			log.WithError(err).Error("Error decoding user document.")
-			return nil, errors.ErrUnknown
+			return nil, model.PageInfo{}, errors.ErrUnknown
 		}
 		user := u.MapDocToUser(&userDoc)
 		users = append(users, user)
@@ -103,10 +97,10 @@ func (u *UserSVC) GetUsersByOrganization(ctx context.Context, orgId model.Organi
 
 	if err := cur.Err(); err != nil {
 		log.WithError(err).Error("Cursor error occurred.")
-		return nil, errors.ErrUnknown
+		return nil, model.PageInfo{}, errors.ErrUnknown
 	}
 
-	return users, nil
+	return users, cur.Info(), nil
 }
 
 func (u *UserSVC) CreateUser(ctx context.Context, user model.User) (data model.User, err error) {
@@ -201,6 +195,145 @@ func (u *UserSVC) GetByID(ctx context.Context, id model.UserID) (user model.User
 	return
 }
 
+// GetOrganizationMembership returns the organization entry of the user, including the admin flag.
+func (u *UserSVC) GetOrganizationMembership(ctx context.Context, userId model.UserID, orgId model.OrganizationID) (membership model.UserOrganization, err error) {
+	log := u.logger.WithContext(ctx)
+
+	objId, err := primitive.ObjectIDFromHex(userId.String())
+
+	if err != nil {
+		log.WithError(err).Error("invalid user id to get organization membership")
+		err = errors.ErrInvalidID
+		return
+	}
+
+	userDoc := &doc.User{}
+
+	filter := bson.M{
+		"_id":              objId,
+		"organizations.id": orgId.String(),
+	}
+
+	err = mgm.Coll(userDoc).First(filter, userDoc)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents") {
+			err = errors.ErrNotOrganizationMember
+			return
+		}
+		log.WithError(err).Error("Unknown error occured when finding organization membership.")
+		err = errors.ErrUnknown
+		return
+	}
+
+	for _, org := range userDoc.Organization {
+		if org.ID == orgId.String() {
+			membership = model.UserOrganization{
+				ID:      org.ID,
+				IsAdmin: org.IsAdmin,
+			}
+			return
+		}
+	}
+
+	err = errors.ErrNotOrganizationMember
+	return
+}
+
+// IsOrganizationAdmin reports if the user is an admin of the organization.
+func (u *UserSVC) IsOrganizationAdmin(ctx context.Context, userId model.UserID, orgId model.OrganizationID) bool {
+	membership, err := u.GetOrganizationMembership(ctx, userId, orgId)
+
+	return err == nil && membership.IsAdmin
+}
+
+// GetOrganizationAdminIDs returns the IDs of all the admins of the organization.
+func (u *UserSVC) GetOrganizationAdminIDs(ctx context.Context, orgId model.OrganizationID) ([]model.UserID, error) {
+	filter := bson.M{
+		"organizations": bson.M{"$elemMatch": bson.M{
+			"id":      orgId.String(),
+			"isAdmin": true,
+		}},
+	}
+
+	cur, err := mgm.Coll(&doc.User{}).Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error("error while fetching organization admins")
+		return nil, errors.ErrUnknown
+	}
+
+	defer cur.Close(ctx)
+
+	adminIds := make([]model.UserID, 0)
+
+	for cur.Next(ctx) {
+		var userDoc doc.User
+
+		err = cur.Decode(&userDoc)
+
+		if err != nil {
+			u.logger.WithContext(ctx).WithError(err).Error("Error decoding user document.")
+			continue
+		}
+
+		adminIds = append(adminIds, model.UserID(userDoc.ID.Hex()))
+	}
+
+	return adminIds, nil
+}
+
+// AddSigningKey registers the public key as the current signing key of the user. A key registered
+// before becomes the current one again, its older signatures stay valid.
+func (u *UserSVC) AddSigningKey(ctx context.Context, userId model.UserID, public string) (key model.SigningKey, err error) {
+	log := u.logger.WithContext(ctx).WithField("userId", userId.String())
+
+	objId, err := primitive.ObjectIDFromHex(userId.String())
+
+	if err != nil {
+		err = errors.ErrInvalidID
+		return
+	}
+
+	key, err = signature.ParsePublicKey(public)
+
+	if err != nil {
+		return
+	}
+
+	key.RegisteredAt = time.Now().UTC()
+
+	coll := mgm.Coll(&doc.User{})
+
+	res, err := coll.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{"$pull": bson.M{"signingKeys": bson.M{"keyId": key.KeyID}}})
+
+	if err != nil {
+		log.WithError(err).Error("error while removing previous registration of signing key")
+		err = errors.ErrUnknown
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		err = errors.ErrUserNotFound
+		return
+	}
+
+	update := bson.M{
+		"$push": bson.M{"signingKeys": doc.SigningKey(key)},
+		"$set":  bson.M{doc.UpdatedAtField: key.RegisteredAt},
+	}
+
+	_, err = coll.UpdateOne(ctx, bson.M{"_id": objId}, update)
+
+	if err != nil {
+		log.WithError(err).Error("error while adding signing key")
+		err = errors.ErrUnknown
+		return
+	}
+
+	return
+}
+
 func (u *UserSVC) MapDocToUser(userDoc *doc.User) model.User {
 	user := model.User{
 		ID:            model.UserID(userDoc.ID.Hex()),
@@ -214,6 +347,10 @@ func (u *UserSVC) MapDocToUser(userDoc *doc.User) model.User {
 		IsBlackListed: userDoc.IsBlackListed,
 	}
 
+	for _, key := range userDoc.SigningKeys {
+		user.SigningKeys = append(user.SigningKeys, model.SigningKey(key))
+	}
+
 	if 0 < len(userDoc.Organization) {
 		modelOrgs := []model.UserOrganization{}
 
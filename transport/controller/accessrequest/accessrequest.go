@@ -0,0 +1,264 @@
+package accessrequest
+
+import (
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/accessrequest"
+	"secaas_backend/svc/errors"
+	"secaas_backend/transport/controller/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type AccessRequestController struct {
+	logger *logrus.Logger
+	svc    *accessrequest.AccessRequestSVC
+}
+
+func New(svc *accessrequest.AccessRequestSVC, logger *logrus.Logger) *AccessRequestController {
+	ac := &AccessRequestController{logger: logger, svc: svc}
+	return ac
+}
+
+func (a *AccessRequestController) Create() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var request model.AccessRequest
+
+		err := gCtx.BindJSON(&request)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			a.logger.WithError(err).Error("error in decoding body in access request create")
+			return
+		}
+
+		userId := gCtx.Query("userId")
+
+		newRequest, err := a.svc.Create(gCtx.Request.Context(), model.UserID(userId), request)
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusCreated, newRequest)
+
+	}
+}
+
+func (a *AccessRequestController) Get() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		requestId := gCtx.Param("requestId")
+		userId := gCtx.Query("userId")
+
+		request, err := a.svc.GetByID(gCtx.Request.Context(), model.AccessRequestID(requestId), model.UserID(userId))
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, request)
+
+	}
+}
+
+func (a *AccessRequestController) GetForUser() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		userId := gCtx.Param("userId")
+		status := gCtx.Query("status")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := a.svc.GetForUser(gCtx.Request.Context(), model.UserID(userId), status, pageParams)
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (a *AccessRequestController) GetForOrganization() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+		status := gCtx.Query("status")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := a.svc.GetForApprover(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId), status, pageParams)
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (a *AccessRequestController) GetForSecret() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := a.svc.GetForSecret(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), pageParams)
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (a *AccessRequestController) Approve() gin.HandlerFunc {
+	return a.decide(true)
+}
+
+func (a *AccessRequestController) Deny() gin.HandlerFunc {
+	return a.decide(false)
+}
+
+func (a *AccessRequestController) decide(approve bool) gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var decision model.AccessRequestDecision
+
+		if gCtx.Request.ContentLength > 0 {
+			err := gCtx.BindJSON(&decision)
+
+			if err != nil {
+				gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+					Code:    "data/invalid-payload",
+					Message: "Payload format is not valid",
+				})
+				a.logger.WithError(err).Error("error in decoding body in access request decision")
+				return
+			}
+		}
+
+		requestId := gCtx.Param("requestId")
+		userId := gCtx.Query("userId")
+
+		var request model.AccessRequest
+		var err error
+
+		if approve {
+			request, err = a.svc.Approve(gCtx.Request.Context(), model.AccessRequestID(requestId), model.UserID(userId), decision)
+		} else {
+			request, err = a.svc.Deny(gCtx.Request.Context(), model.AccessRequestID(requestId), model.UserID(userId), decision)
+		}
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, request)
+
+	}
+}
+
+func (a *AccessRequestController) Cancel() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		requestId := gCtx.Param("requestId")
+		userId := gCtx.Query("userId")
+
+		request, err := a.svc.Cancel(gCtx.Request.Context(), model.AccessRequestID(requestId), model.UserID(userId))
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, request)
+
+	}
+}
+
+func (a *AccessRequestController) writeError(gCtx *gin.Context, err error) {
+	switch err {
+	case errors.ErrInvalidID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "access-request/invalid-id",
+			Message: "ID is not valid",
+		})
+	case errors.ErrInvalidAccessRequest:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "access-request/invalid",
+			Message: "Access request is not valid",
+		})
+	case errors.ErrAccessRequestNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "access-request/not-found",
+			Message: "Access request not found",
+		})
+	case errors.ErrAccessRequestDecided:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "access-request/not-pending",
+			Message: "Access request is no longer pending",
+		})
+	case errors.ErrAccessRequestAlreadyOpen:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "access-request/already-open",
+			Message: "A pending access request already exists for the secret",
+		})
+	case errors.ErrAccessRequestDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "access-request/access-denied",
+			Message: "User is not allowed to perform this action on the access request",
+		})
+	case errors.ErrSecretAlreadyShared:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "secret/already-shared",
+			Message: "User already has this access to the secret",
+		})
+	case errors.ErrSecretNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "secret/not-found",
+			Message: "Secret not found",
+		})
+	case errors.ErrNotOrganizationMember:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "organization/not-member",
+			Message: "User is not a member of the organization",
+		})
+	default:
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
+	}
+}
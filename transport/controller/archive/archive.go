@@ -0,0 +1,118 @@
+package archive
+
+import (
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/archive"
+	"secaas_backend/svc/errors"
+	"secaas_backend/transport/controller/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type ArchiveController struct {
+	logger *logrus.Logger
+	svc    *archive.ArchiveSVC
+}
+
+func New(svc *archive.ArchiveSVC, logger *logrus.Logger) *ArchiveController {
+	ac := &ArchiveController{logger: logger, svc: svc}
+	return ac
+}
+
+// Export streams the archive of the organization as a gzipped tar.
+func (a *ArchiveController) Export() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		export, err := a.svc.Export(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId))
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.Header("Content-Type", "application/gzip")
+		gCtx.Header("Content-Disposition", "attachment; filename="+strconv.Quote("organization-"+orgId+".tar.gz"))
+		gCtx.Status(http.StatusOK)
+
+		// The response has started, a failure can only cut the archive short.
+		_, err = export.WriteTo(gCtx.Writer)
+
+		if err != nil {
+			a.logger.WithError(err).WithField("organizationId", orgId).Error("organization export was interrupted")
+		}
+
+	}
+}
+
+// Import creates an organization from the archive in the request body. With dryRun=true nothing is written.
+func (a *ArchiveController) Import() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		userId := gCtx.Query("userId")
+		dryRun := gCtx.Query("dryRun") == "true"
+
+		body := http.MaxBytesReader(gCtx.Writer, gCtx.Request.Body, archive.MaxArchiveSize)
+
+		report, err := a.svc.Import(gCtx.Request.Context(), model.UserID(userId), body, dryRun)
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		status := http.StatusCreated
+
+		if dryRun {
+			status = http.StatusOK
+		}
+
+		gCtx.JSON(status, report)
+
+	}
+}
+
+func (a *ArchiveController) writeError(gCtx *gin.Context, err error) {
+	switch err {
+	case errors.ErrInvalidOrganizationID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "organization/invalid-id",
+			Message: "Organization ID is not valid",
+		})
+	case errors.ErrInvalidArchive:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "archive/invalid",
+			Message: "Archive is not a valid organization archive",
+		})
+	case errors.ErrArchiveIntegrity:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "archive/integrity",
+			Message: "Archive files do not match the hashes of its manifest",
+		})
+	case errors.ErrArchiveVersion:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "archive/unsupported-version",
+			Message: "Archive format version is not supported",
+		})
+	case errors.ErrOrganizationNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "organization/not-found",
+			Message: "Organization not found",
+		})
+	case errors.ErrArchiveAccessDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "archive/access-denied",
+			Message: "Only admins of the organization can export or import it",
+		})
+	default:
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package attachment
+
+import (
+	"io"
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/attachment"
+	"secaas_backend/svc/errors"
+	"secaas_backend/transport/controller/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type AttachmentController struct {
+	logger *logrus.Logger
+	svc    *attachment.AttachmentSVC
+}
+
+func New(svc *attachment.AttachmentSVC, logger *logrus.Logger) *AttachmentController {
+	ac := &AttachmentController{logger: logger, svc: svc}
+	return ac
+}
+
+func (a *AttachmentController) StartUpload() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var upload model.AttachmentUpload
+
+		err := gCtx.BindJSON(&upload)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			a.logger.WithError(err).Error("error in decoding body in attachment upload")
+			return
+		}
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		newUpload, err := a.svc.StartUpload(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), upload)
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusCreated, newUpload)
+
+	}
+}
+
+func (a *AttachmentController) GetUpload() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		uploadId := gCtx.Param("uploadId")
+		userId := gCtx.Query("userId")
+
+		upload, err := a.svc.GetUpload(gCtx.Request.Context(), model.SecretID(secretId), model.AttachmentUploadID(uploadId), model.UserID(userId))
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, upload)
+
+	}
+}
+
+// UploadChunk appends the raw request body to the upload at the offset given in the query.
+func (a *AttachmentController) UploadChunk() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		uploadId := gCtx.Param("uploadId")
+		userId := gCtx.Query("userId")
+
+		offset, err := strconv.ParseInt(gCtx.Query("offset"), 10, 64)
+
+		if err != nil || offset < 0 {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "attachment/invalid-offset",
+				Message: "Chunk offset is not valid",
+			})
+			return
+		}
+
+		data, err := io.ReadAll(http.MaxBytesReader(gCtx.Writer, gCtx.Request.Body, attachment.MaxChunkSize))
+
+		if err != nil {
+			gCtx.JSON(http.StatusRequestEntityTooLarge, response.ErrorResponse{
+				Code:    "attachment/chunk-too-large",
+				Message: "Chunk is larger than the allowed size",
+			})
+			return
+		}
+
+		upload, err := a.svc.AppendChunk(gCtx.Request.Context(), model.SecretID(secretId), model.AttachmentUploadID(uploadId), model.UserID(userId), offset, data)
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, upload)
+
+	}
+}
+
+func (a *AttachmentController) CompleteUpload() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		uploadId := gCtx.Param("uploadId")
+		userId := gCtx.Query("userId")
+
+		newAttachment, err := a.svc.CompleteUpload(gCtx.Request.Context(), model.SecretID(secretId), model.AttachmentUploadID(uploadId), model.UserID(userId))
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusCreated, newAttachment)
+
+	}
+}
+
+func (a *AttachmentController) CancelUpload() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		uploadId := gCtx.Param("uploadId")
+		userId := gCtx.Query("userId")
+
+		err := a.svc.CancelUpload(gCtx.Request.Context(), model.SecretID(secretId), model.AttachmentUploadID(uploadId), model.UserID(userId))
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, gin.H{
+			"deleted": true,
+			"id":      uploadId,
+		})
+
+	}
+}
+
+func (a *AttachmentController) GetForSecret() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		data, err := a.svc.GetForSecret(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId))
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, data)
+
+	}
+}
+
+// Download streams the encrypted content of the attachment. The hash is sent so the client can verify it.
+func (a *AttachmentController) Download() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		attachmentId := gCtx.Param("attachmentId")
+		userId := gCtx.Query("userId")
+
+		data, stream, err := a.svc.Download(gCtx.Request.Context(), model.SecretID(secretId), model.AttachmentID(attachmentId), model.UserID(userId))
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		defer stream.Close()
+
+		headers := map[string]string{
+			"Content-Disposition": "attachment; filename=" + strconv.Quote(data.Name),
+			"X-Content-SHA256":    data.SHA256,
+		}
+
+		gCtx.DataFromReader(http.StatusOK, data.Size, data.ContentType, stream, headers)
+
+	}
+}
+
+func (a *AttachmentController) Delete() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		attachmentId := gCtx.Param("attachmentId")
+		userId := gCtx.Query("userId")
+
+		deleteCount, err := a.svc.Delete(gCtx.Request.Context(), model.SecretID(secretId), model.AttachmentID(attachmentId), model.UserID(userId))
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		if deleteCount > 0 {
+			attachmentId = ""
+		}
+
+		gCtx.JSON(http.StatusOK, gin.H{
+			"deleted": deleteCount > 0,
+			"id":      attachmentId,
+		})
+
+	}
+}
+
+func (a *AttachmentController) GetUsage() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		usage, err := a.svc.GetUsage(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId))
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, usage)
+
+	}
+}
+
+func (a *AttachmentController) SetQuota() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var quota model.AttachmentQuota
+
+		err := gCtx.BindJSON(&quota)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			a.logger.WithError(err).Error("error in decoding body in attachment quota")
+			return
+		}
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		usage, err := a.svc.SetQuota(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId), quota.Quota)
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, usage)
+
+	}
+}
+
+func (a *AttachmentController) writeError(gCtx *gin.Context, err error) {
+	switch err {
+	case errors.ErrInvalidID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "attachment/invalid-id",
+			Message: "ID is not valid",
+		})
+	case errors.ErrInvalidOrganizationID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "organization/invalid-id",
+			Message: "Organization ID is not valid",
+		})
+	case errors.ErrOrganizationNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "organization/not-found",
+			Message: "Organization not found",
+		})
+	case errors.ErrInvalidAttachment:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "attachment/invalid",
+			Message: "Attachment details are not valid",
+		})
+	case errors.ErrAttachmentChunkOffset:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "attachment/offset-mismatch",
+			Message: "Chunk offset does not match the received size, fetch the upload to resume",
+		})
+	case errors.ErrAttachmentUploadIncomplete:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "attachment/upload-incomplete",
+			Message: "Attachment upload is not complete",
+		})
+	case errors.ErrAttachmentIntegrity:
+		gCtx.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Code:    "attachment/integrity-mismatch",
+			Message: "Attachment hash does not match the uploaded content",
+		})
+	case errors.ErrAttachmentQuotaExceeded:
+		gCtx.JSON(http.StatusRequestEntityTooLarge, response.ErrorResponse{
+			Code:    "attachment/quota-exceeded",
+			Message: "Organization attachment quota exceeded",
+		})
+	case errors.ErrAttachmentNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "attachment/not-found",
+			Message: "Attachment not found",
+		})
+	case errors.ErrAttachmentUploadNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "attachment/upload-not-found",
+			Message: "Attachment upload not found or expired",
+		})
+	case errors.ErrAttachmentAccessDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "attachment/access-denied",
+			Message: "User is not allowed to perform this action on the attachments",
+		})
+	case errors.ErrSecretNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "secret/not-found",
+			Message: "Secret not found",
+		})
+	case errors.ErrSecretAccessDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "secret/access-denied",
+			Message: "User is not allowed to perform this action on the secret",
+		})
+	case errors.ErrNotOrganizationMember:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "organization/not-member",
+			Message: "User is not a member of the organization",
+		})
+	case errors.ErrCheckoutRequired:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "secret/checkout-required",
+			Message: "Secret must be checked out before it can be read",
+		})
+	case errors.ErrSecretCheckedOut:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "secret/checked-out",
+			Message: "Secret is checked out by another user",
+		})
+	case errors.ErrNotCheckoutHolder:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "secret/not-checkout-holder",
+			Message: "User does not hold the checkout of the secret",
+		})
+	default:
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
+	}
+}
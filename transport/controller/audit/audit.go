@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/audit"
+	"secaas_backend/svc/errors"
+	"secaas_backend/transport/controller/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditController struct {
+	logger *logrus.Logger
+	svc    *audit.AuditSVC
+}
+
+func New(svc *audit.AuditSVC, logger *logrus.Logger) *AuditController {
+	ac := &AuditController{logger: logger, svc: svc}
+	return ac
+}
+
+func (a *AuditController) GetForOrganization() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+		action := gCtx.Query("action")
+		targetId := gCtx.Query("targetId")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := a.svc.GetForOrganization(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId), action, targetId, pageParams)
+
+		if err != nil {
+			a.writeError(gCtx, err)
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (a *AuditController) writeError(gCtx *gin.Context, err error) {
+	switch err {
+	case errors.ErrInvalidOrganizationID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "organization/invalid-id",
+			Message: "Organization ID is not valid",
+		})
+	case errors.ErrAuditAccessDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "audit/access-denied",
+			Message: "User is not allowed to read the audit log of the organization",
+		})
+	default:
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package breakglass
+
+import (
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/breakglass"
+	"secaas_backend/svc/errors"
+	"secaas_backend/transport/controller/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type BreakGlassController struct {
+	logger *logrus.Logger
+	svc    *breakglass.BreakGlassSVC
+}
+
+func New(svc *breakglass.BreakGlassSVC, logger *logrus.Logger) *BreakGlassController {
+	bc := &BreakGlassController{logger: logger, svc: svc}
+	return bc
+}
+
+func (b *BreakGlassController) CreateVault() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var vault model.BreakGlassVault
+
+		err := gCtx.BindJSON(&vault)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			b.logger.WithError(err).Error("error in decoding body in break-glass vault create")
+			return
+		}
+
+		userId := gCtx.Query("userId")
+
+		newVault, err := b.svc.CreateVault(gCtx.Request.Context(), model.UserID(userId), vault)
+
+		if err != nil {
+			b.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusCreated, newVault)
+
+	}
+}
+
+func (b *BreakGlassController) GetVault() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		vaultId := gCtx.Param("vaultId")
+		userId := gCtx.Query("userId")
+
+		vault, err := b.svc.GetVault(gCtx.Request.Context(), model.BreakGlassVaultID(vaultId), model.UserID(userId))
+
+		if err != nil {
+			b.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, vault)
+
+	}
+}
+
+func (b *BreakGlassController) GetVaultsForOrganization() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := b.svc.GetVaultsForOrganization(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId), pageParams)
+
+		if err != nil {
+			b.writeError(gCtx, err)
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (b *BreakGlassController) DeleteVault() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		vaultId := gCtx.Param("vaultId")
+		userId := gCtx.Query("userId")
+
+		deleteCount, err := b.svc.DeleteVault(gCtx.Request.Context(), model.BreakGlassVaultID(vaultId), model.UserID(userId))
+
+		if err != nil {
+			b.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, gin.H{
+			"deleted": deleteCount > 0,
+			"id":      vaultId,
+		})
+
+	}
+}
+
+func (b *BreakGlassController) StartUnlock() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var start model.BreakGlassUnlockStart
+
+		err := gCtx.BindJSON(&start)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			b.logger.WithError(err).Error("error in decoding body in break-glass unlock start")
+			return
+		}
+
+		vaultId := gCtx.Param("vaultId")
+		userId := gCtx.Query("userId")
+
+		unlock, err := b.svc.StartUnlock(gCtx.Request.Context(), model.BreakGlassVaultID(vaultId), model.UserID(userId), start)
+
+		if err != nil {
+			b.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusCreated, unlock)
+
+	}
+}
+
+func (b *BreakGlassController) GetUnlock() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		unlockId := gCtx.Param("unlockId")
+		userId := gCtx.Query("userId")
+
+		unlock, err := b.svc.GetUnlock(gCtx.Request.Context(), model.BreakGlassUnlockID(unlockId), model.UserID(userId))
+
+		if err != nil {
+			b.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, unlock)
+
+	}
+}
+
+func (b *BreakGlassController) GetUnlocksForOrganization() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+		status := gCtx.Query("status")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := b.svc.GetUnlocksForOrganization(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId), status, pageParams)
+
+		if err != nil {
+			b.writeError(gCtx, err)
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (b *BreakGlassController) SubmitShare() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var submission model.BreakGlassShareSubmission
+
+		err := gCtx.BindJSON(&submission)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			b.logger.WithError(err).Error("error in decoding body in break-glass share submission")
+			return
+		}
+
+		unlockId := gCtx.Param("unlockId")
+		userId := gCtx.Query("userId")
+
+		unlock, err := b.svc.SubmitShare(gCtx.Request.Context(), model.BreakGlassUnlockID(unlockId), model.UserID(userId), submission)
+
+		if err != nil {
+			b.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, unlock)
+
+	}
+}
+
+// CollectShares returns the sealed payload and the submitted shares to the requester once the quorum is reached.
+func (b *BreakGlassController) CollectShares() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		unlockId := gCtx.Param("unlockId")
+		userId := gCtx.Query("userId")
+
+		material, err := b.svc.CollectShares(gCtx.Request.Context(), model.BreakGlassUnlockID(unlockId), model.UserID(userId))
+
+		if err != nil {
+			b.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, material)
+
+	}
+}
+
+func (b *BreakGlassController) CancelUnlock() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		unlockId := gCtx.Param("unlockId")
+		userId := gCtx.Query("userId")
+
+		unlock, err := b.svc.CancelUnlock(gCtx.Request.Context(), model.BreakGlassUnlockID(unlockId), model.UserID(userId))
+
+		if err != nil {
+			b.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, unlock)
+
+	}
+}
+
+func (b *BreakGlassController) writeError(gCtx *gin.Context, err error) {
+	switch err {
+	case errors.ErrInvalidID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "break-glass/invalid-id",
+			Message: "ID is not valid",
+		})
+	case errors.ErrInvalidOrganizationID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "organization/invalid-id",
+			Message: "Organization ID is not valid",
+		})
+	case errors.ErrInvalidBreakGlassVault:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "break-glass/invalid-vault",
+			Message: "Vault details are not valid, custodians must be distinct org admins with a share each",
+		})
+	case errors.ErrInvalidBreakGlassUnlock:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "break-glass/invalid-unlock",
+			Message: "Unlock needs a reason and a recipient public key",
+		})
+	case errors.ErrInvalidBreakGlassShare:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "break-glass/invalid-share",
+			Message: "Share is not valid",
+		})
+	case errors.ErrBreakGlassVaultNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "break-glass/vault-not-found",
+			Message: "Vault not found",
+		})
+	case errors.ErrBreakGlassUnlockNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "break-glass/unlock-not-found",
+			Message: "Unlock not found",
+		})
+	case errors.ErrBreakGlassUnlockOpen:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "break-glass/unlock-open",
+			Message: "Vault already has an open unlock",
+		})
+	case errors.ErrBreakGlassUnlockClosed:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "break-glass/unlock-closed",
+			Message: "Unlock is no longer open",
+		})
+	case errors.ErrBreakGlassShareSubmitted:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "break-glass/share-submitted",
+			Message: "Share was already submitted for the unlock",
+		})
+	case errors.ErrBreakGlassNotUnlocked:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "break-glass/quorum-pending",
+			Message: "Unlock has not reached the quorum of the vault",
+		})
+	case errors.ErrBreakGlassAccessDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "break-glass/access-denied",
+			Message: "User is not allowed to perform this action on the vault",
+		})
+	default:
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package collection
+
+import (
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/collection"
+	"secaas_backend/svc/errors"
+	"secaas_backend/transport/controller/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type CollectionController struct {
+	logger *logrus.Logger
+	svc    *collection.CollectionSVC
+}
+
+func New(svc *collection.CollectionSVC, logger *logrus.Logger) *CollectionController {
+	cc := &CollectionController{logger: logger, svc: svc}
+	return cc
+}
+
+func (c *CollectionController) Create() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var collection model.Collection
+
+		err := gCtx.BindJSON(&collection)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			c.logger.WithError(err).Error("error in decoding body in collection create")
+			return
+		}
+
+		userId := gCtx.Query("userId")
+
+		newCollection, err := c.svc.Create(gCtx.Request.Context(), collection, model.UserID(userId))
+
+		if err != nil {
+			c.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusCreated, newCollection)
+
+	}
+}
+
+func (c *CollectionController) Get() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		collectionId := gCtx.Param("collectionId")
+
+		collection, err := c.svc.GetByID(gCtx.Request.Context(), model.CollectionID(collectionId))
+
+		if err != nil {
+			c.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, collection)
+
+	}
+}
+
+func (c *CollectionController) GetForOrganization() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		data, err := c.svc.GetForOrganization(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId))
+
+		if err != nil {
+			c.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, data)
+
+	}
+}
+
+func (c *CollectionController) SetGrants() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var grants []model.CollectionGrant
+
+		err := gCtx.BindJSON(&grants)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			c.logger.WithError(err).Error("error in decoding body in collection grants")
+			return
+		}
+
+		collectionId := gCtx.Param("collectionId")
+		userId := gCtx.Query("userId")
+
+		collection, err := c.svc.SetGrants(gCtx.Request.Context(), model.CollectionID(collectionId), model.UserID(userId), grants)
+
+		if err != nil {
+			c.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, collection)
+
+	}
+}
+
+func (c *CollectionController) Move() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var move model.CollectionMove
+
+		err := gCtx.BindJSON(&move)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			c.logger.WithError(err).Error("error in decoding body in collection move")
+			return
+		}
+
+		collectionId := gCtx.Param("collectionId")
+		userId := gCtx.Query("userId")
+
+		collection, err := c.svc.Move(gCtx.Request.Context(), model.CollectionID(collectionId), model.UserID(userId), move.ParentID)
+
+		if err != nil {
+			c.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, collection)
+
+	}
+}
+
+// SetRotationPolicy sets the rotation interval inherited by the secrets in the collection. Zero clears it.
+func (c *CollectionController) SetRotationPolicy() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var policy model.RotationPolicy
+
+		err := gCtx.BindJSON(&policy)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			c.logger.WithError(err).Error("error in decoding body in collection rotation policy")
+			return
+		}
+
+		collectionId := gCtx.Param("collectionId")
+		userId := gCtx.Query("userId")
+
+		collection, err := c.svc.SetRotationPolicy(gCtx.Request.Context(), model.CollectionID(collectionId), model.UserID(userId), policy)
+
+		if err != nil {
+			c.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, collection)
+
+	}
+}
+
+func (c *CollectionController) Delete() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		collectionId := gCtx.Param("collectionId")
+		userId := gCtx.Query("userId")
+
+		deleteCount, err := c.svc.Delete(gCtx.Request.Context(), model.CollectionID(collectionId), model.UserID(userId))
+
+		if err != nil {
+			c.writeError(gCtx, err)
+			return
+		}
+
+		if deleteCount > 0 {
+			collectionId = ""
+		}
+
+		gCtx.JSON(http.StatusOK, gin.H{
+			"deleted": deleteCount > 0,
+			"id":      collectionId,
+		})
+
+	}
+}
+
+func (c *CollectionController) writeError(gCtx *gin.Context, err error) {
+	switch err {
+	case errors.ErrInvalidID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "collection/invalid-id",
+			Message: "ID is not valid",
+		})
+	case errors.ErrInvalidCollectionName:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "collection/invalid-name",
+			Message: "Collection name is not valid",
+		})
+	case errors.ErrInvalidSecretRole:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "collection/invalid-role",
+			Message: "Collection grant role must be editor, viewer or use-only",
+		})
+	case errors.ErrInvalidCollectionGrant:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "collection/invalid-grant",
+			Message: "Collection grants must name members or teams of the organization",
+		})
+	case errors.ErrInvalidRotationPolicy:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "collection/invalid-rotation-policy",
+			Message: "Rotation interval must be between 0 and 3650 days",
+		})
+	case errors.ErrInvalidCollectionMove:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "collection/invalid-move",
+			Message: "Collection cannot be moved into itself",
+		})
+	case errors.ErrOrganizationMismatch:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "collection/organization-mismatch",
+			Message: "Collections belong to different organizations",
+		})
+	case errors.ErrNotOrganizationMember:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "organization/not-member",
+			Message: "User is not a member of the organization",
+		})
+	case errors.ErrCollectionNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "collection/not-found",
+			Message: "Collection not found",
+		})
+	case errors.ErrCollectionExists:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "collection/exists",
+			Message: "A collection already exists at this path",
+		})
+	case errors.ErrCollectionNotEmpty:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "collection/not-empty",
+			Message: "Collection still holds secrets or collections",
+		})
+	case errors.ErrCollectionAccessDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "collection/access-denied",
+			Message: "User is not allowed to perform this action on the collection",
+		})
+	default:
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
+	}
+}
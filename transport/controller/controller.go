@@ -2,9 +2,21 @@ package controller
 
 import (
 	"secaas_backend/svc"
+	"secaas_backend/transport/controller/accessrequest"
+	"secaas_backend/transport/controller/archive"
+	"secaas_backend/transport/controller/attachment"
+	"secaas_backend/transport/controller/audit"
+	"secaas_backend/transport/controller/breakglass"
+	"secaas_backend/transport/controller/collection"
+	"secaas_backend/transport/controller/emergencyaccess"
 	"secaas_backend/transport/controller/invite"
+	"secaas_backend/transport/controller/notification"
 	"secaas_backend/transport/controller/organization"
+	"secaas_backend/transport/controller/project"
 	"secaas_backend/transport/controller/secret"
+	"secaas_backend/transport/controller/sharelink"
+	"secaas_backend/transport/controller/sync"
+	"secaas_backend/transport/controller/team"
 	"secaas_backend/transport/controller/user"
 
 	"github.com/sirupsen/logrus"
@@ -14,10 +26,22 @@ type Controller struct {
 	logger *logrus.Logger
 	svc    *svc.SVC
 
-	User         *user.UserController
-	Invite       *invite.InviteController
-	Organization *organization.OrganizationController
-	Secrets      *secret.SecretsController
+	User            *user.UserController
+	Invite          *invite.InviteController
+	Organization    *organization.OrganizationController
+	Secrets         *secret.SecretsController
+	Notification    *notification.NotificationController
+	Team            *team.TeamController
+	Collection      *collection.CollectionController
+	Project         *project.ProjectController
+	Attachment      *attachment.AttachmentController
+	ShareLink       *sharelink.ShareLinkController
+	AccessRequest   *accessrequest.AccessRequestController
+	Audit           *audit.AuditController
+	BreakGlass      *breakglass.BreakGlassController
+	EmergencyAccess *emergencyaccess.EmergencyAccessController
+	Archive         *archive.ArchiveController
+	Sync            *sync.SyncController
 }
 
 func New(logger *logrus.Logger, svc *svc.SVC) *Controller {
@@ -25,7 +49,19 @@ func New(logger *logrus.Logger, svc *svc.SVC) *Controller {
 	i := invite.New(svc.Invite, logger)
 	o := organization.New(svc.Organization, svc.User, logger)
 	sec := secret.New(svc.Secrets, logger)
+	n := notification.New(svc.Notification, logger)
+	t := team.New(svc.Team, logger)
+	col := collection.New(svc.Collection, logger)
+	p := project.New(svc.Project, svc.Secrets, logger)
+	att := attachment.New(svc.Attachment, logger)
+	sl := sharelink.New(svc.ShareLink, logger)
+	ar := accessrequest.New(svc.AccessRequest, logger)
+	aud := audit.New(svc.Audit, logger)
+	bg := breakglass.New(svc.BreakGlass, logger)
+	ea := emergencyaccess.New(svc.EmergencyAccess, logger)
+	arc := archive.New(svc.Archive, logger)
+	syn := sync.New(svc.Sync, logger)
 
-	c := &Controller{logger: logger, svc: svc, User: u, Invite: i, Organization: o, Secrets: sec}
+	c := &Controller{logger: logger, svc: svc, User: u, Invite: i, Organization: o, Secrets: sec, Notification: n, Team: t, Collection: col, Project: p, Attachment: att, ShareLink: sl, AccessRequest: ar, Audit: aud, BreakGlass: bg, EmergencyAccess: ea, Archive: arc, Sync: syn}
 	return c
 }
@@ -0,0 +1,291 @@
+package emergencyaccess
+
+import (
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/emergencyaccess"
+	"secaas_backend/svc/errors"
+	"secaas_backend/transport/controller/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type EmergencyAccessController struct {
+	logger *logrus.Logger
+	svc    *emergencyaccess.EmergencyAccessSVC
+}
+
+func New(svc *emergencyaccess.EmergencyAccessSVC, logger *logrus.Logger) *EmergencyAccessController {
+	ec := &EmergencyAccessController{logger: logger, svc: svc}
+	return ec
+}
+
+func (e *EmergencyAccessController) Create() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var grant model.EmergencyAccess
+
+		err := gCtx.BindJSON(&grant)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			e.logger.WithError(err).Error("error in decoding body in emergency access create")
+			return
+		}
+
+		userId := gCtx.Query("userId")
+
+		newGrant, err := e.svc.Create(gCtx.Request.Context(), model.UserID(userId), grant)
+
+		if err != nil {
+			e.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusCreated, newGrant)
+
+	}
+}
+
+func (e *EmergencyAccessController) Get() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		grantId := gCtx.Param("grantId")
+		userId := gCtx.Query("userId")
+
+		grant, err := e.svc.GetByID(gCtx.Request.Context(), model.EmergencyAccessID(grantId), model.UserID(userId))
+
+		if err != nil {
+			e.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, grant)
+
+	}
+}
+
+func (e *EmergencyAccessController) GetForGrantor() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		userId := gCtx.Param("userId")
+		status := gCtx.Query("status")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := e.svc.GetForGrantor(gCtx.Request.Context(), model.UserID(userId), status, pageParams)
+
+		if err != nil {
+			e.writeError(gCtx, err)
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (e *EmergencyAccessController) GetForGrantee() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		userId := gCtx.Param("userId")
+		status := gCtx.Query("status")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := e.svc.GetForGrantee(gCtx.Request.Context(), model.UserID(userId), status, pageParams)
+
+		if err != nil {
+			e.writeError(gCtx, err)
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (e *EmergencyAccessController) UpdateKey() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var key model.EmergencyAccessKey
+
+		err := gCtx.BindJSON(&key)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			e.logger.WithError(err).Error("error in decoding body in emergency access key update")
+			return
+		}
+
+		grantId := gCtx.Param("grantId")
+		userId := gCtx.Query("userId")
+
+		grant, err := e.svc.UpdateKey(gCtx.Request.Context(), model.EmergencyAccessID(grantId), model.UserID(userId), key)
+
+		if err != nil {
+			e.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, grant)
+
+	}
+}
+
+func (e *EmergencyAccessController) Request() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		grantId := gCtx.Param("grantId")
+		userId := gCtx.Query("userId")
+
+		grant, err := e.svc.Request(gCtx.Request.Context(), model.EmergencyAccessID(grantId), model.UserID(userId))
+
+		if err != nil {
+			e.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, grant)
+
+	}
+}
+
+func (e *EmergencyAccessController) Approve() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		grantId := gCtx.Param("grantId")
+		userId := gCtx.Query("userId")
+
+		grant, err := e.svc.Approve(gCtx.Request.Context(), model.EmergencyAccessID(grantId), model.UserID(userId))
+
+		if err != nil {
+			e.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, grant)
+
+	}
+}
+
+func (e *EmergencyAccessController) Reject() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		grantId := gCtx.Param("grantId")
+		userId := gCtx.Query("userId")
+
+		grant, err := e.svc.Reject(gCtx.Request.Context(), model.EmergencyAccessID(grantId), model.UserID(userId))
+
+		if err != nil {
+			e.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, grant)
+
+	}
+}
+
+func (e *EmergencyAccessController) Revoke() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		grantId := gCtx.Param("grantId")
+		userId := gCtx.Query("userId")
+
+		grant, err := e.svc.Revoke(gCtx.Request.Context(), model.EmergencyAccessID(grantId), model.UserID(userId))
+
+		if err != nil {
+			e.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, grant)
+
+	}
+}
+
+// GetVault returns the wrapped key and a page of the secrets of the grantor once access is approved.
+func (e *EmergencyAccessController) GetVault() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		grantId := gCtx.Param("grantId")
+		userId := gCtx.Query("userId")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		vault, err := e.svc.GetVault(gCtx.Request.Context(), model.EmergencyAccessID(grantId), model.UserID(userId), pageParams)
+
+		if err != nil {
+			e.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, vault)
+
+	}
+}
+
+func (e *EmergencyAccessController) writeError(gCtx *gin.Context, err error) {
+	switch err {
+	case errors.ErrInvalidID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "emergency-access/invalid-id",
+			Message: "ID is not valid",
+		})
+	case errors.ErrInvalidEmergencyAccess:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "emergency-access/invalid",
+			Message: "Emergency access details are not valid",
+		})
+	case errors.ErrEmergencyAccessNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "emergency-access/not-found",
+			Message: "Emergency access grant not found",
+		})
+	case errors.ErrEmergencyAccessExists:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "emergency-access/already-exists",
+			Message: "An emergency access grant already exists for the contact",
+		})
+	case errors.ErrEmergencyAccessState:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "emergency-access/invalid-state",
+			Message: "Emergency access grant is not in a state for this action",
+		})
+	case errors.ErrEmergencyAccessDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "emergency-access/access-denied",
+			Message: "User is not allowed to perform this action on the emergency access grant",
+		})
+	case errors.ErrUserNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "user/not-found",
+			Message: "User not found",
+		})
+	default:
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
+	}
+}
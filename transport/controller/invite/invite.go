@@ -1,13 +1,11 @@
 package invite
 
 import (
-	"math"
 	"net/http"
 	"secaas_backend/model"
 	"secaas_backend/svc/errors"
 	"secaas_backend/svc/invite"
 	"secaas_backend/transport/controller/response"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -109,28 +107,13 @@ func (i *InviteController) GetByOrganization() gin.HandlerFunc {
 		}
 
 		getOnlyActive := gCtx.Query("active") == "true"
-		rawPage := gCtx.Query("page")
-		rawLimit := gCtx.Query("limit")
+		pageParams, ok := response.Pagination(gCtx)
 
-		page, err := strconv.Atoi(rawPage)
-
-		if err != nil || page < 0 {
-			page = 1
-		}
-
-		limit, err := strconv.Atoi(rawLimit)
-
-		if err != nil || limit < 0 || limit > 100 {
-			limit = 10
-		}
-
-		pageParams := model.PaginationParams{
-			Page:  page,
-			Limit: limit,
-			Skip:  int(math.Max(float64(page-1), 0)) * limit,
+		if !ok {
+			return
 		}
 
-		inviteList, err := i.svc.GetInvitesByOrganization(gCtx.Request.Context(), orgId, pageParams, getOnlyActive)
+		inviteList, info, err := i.svc.GetInvitesByOrganization(gCtx.Request.Context(), orgId, pageParams, getOnlyActive)
 
 		if err != nil {
 			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
@@ -140,14 +123,7 @@ func (i *InviteController) GetByOrganization() gin.HandlerFunc {
 			return
 		}
 
-		pageResp := model.PaginationResponse{
-			CurrentPage: page,
-			Data:        inviteList,
-			Limit:       limit,
-			NextPage:    page + 1,
-		}
-
-		gCtx.JSON(http.StatusOK, pageResp)
+		response.WritePage(gCtx, pageParams, inviteList, info)
 
 	}
 }
@@ -167,28 +143,13 @@ func (i *InviteController) GetForUser() gin.HandlerFunc {
 		}
 
 		getOnlyActive := gCtx.Query("active") == "true"
-		rawPage := gCtx.Query("page")
-		rawLimit := gCtx.Query("limit")
+		pageParams, ok := response.Pagination(gCtx)
 
-		page, err := strconv.Atoi(rawPage)
-
-		if err != nil || page < 0 {
-			page = 1
-		}
-
-		limit, err := strconv.Atoi(rawLimit)
-
-		if err != nil || limit < 0 || limit > 100 {
-			limit = 10
-		}
-
-		pageParams := model.PaginationParams{
-			Page:  page,
-			Limit: limit,
-			Skip:  int(math.Max(float64(page-1), 0)) * limit,
+		if !ok {
+			return
 		}
 
-		inviteList, err := i.svc.GetInvitesForUser(gCtx.Request.Context(), email, pageParams, getOnlyActive)
+		inviteList, info, err := i.svc.GetInvitesForUser(gCtx.Request.Context(), email, pageParams, getOnlyActive)
 
 		if err != nil {
 			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
@@ -198,14 +159,7 @@ func (i *InviteController) GetForUser() gin.HandlerFunc {
 			return
 		}
 
-		pageResp := model.PaginationResponse{
-			CurrentPage: page,
-			Data:        inviteList,
-			Limit:       limit,
-			NextPage:    page + 1,
-		}
-
-		gCtx.JSON(http.StatusOK, pageResp)
+		response.WritePage(gCtx, pageParams, inviteList, info)
 
 	}
 }
@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/notification"
+	"secaas_backend/transport/controller/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type NotificationController struct {
+	logger *logrus.Logger
+	svc    *notification.NotificationSVC
+}
+
+func New(svc *notification.NotificationSVC, logger *logrus.Logger) *NotificationController {
+	nc := &NotificationController{logger: logger, svc: svc}
+	return nc
+}
+
+func (n *NotificationController) GetForUser() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		userId := gCtx.Param("userId")
+
+		if userId == "" {
+			err := response.ErrorResponse{
+				Code:    "user/invalid-id",
+				Message: "User ID is not valid",
+			}
+			gCtx.JSON(http.StatusBadRequest, err)
+			return
+		}
+
+		onlyUnread := gCtx.Query("unread") == "true"
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := n.svc.GetForUser(gCtx.Request.Context(), model.UserID(userId), pageParams, onlyUnread)
+
+		if err != nil {
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (n *NotificationController) MarkRead() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		notificationId := gCtx.Param("notificationId")
+		userId := gCtx.Query("userId")
+
+		err := n.svc.MarkRead(gCtx.Request.Context(), model.NotificationID(notificationId), model.UserID(userId))
+
+		if err != nil {
+			if err == errors.ErrInvalidID {
+				gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+					Code:    "notification/invalid-id",
+					Message: "Notification ID is not valid",
+				})
+				return
+			}
+
+			if err == errors.ErrNotificationNotFound {
+				gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+					Code:    "notification/not-found",
+					Message: "Notification not found",
+				})
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, gin.H{})
+
+	}
+}
@@ -110,9 +110,20 @@ func (o *OrganizationController) DeleteOrganization() gin.HandlerFunc {
 			return
 		}
 
-		deleteCount, err := o.svc.DeleteOrganization(gCtx.Request.Context(), model.OrganizationID(organizationId))
+		expectedVersion, ok := response.IfMatch(gCtx)
+
+		if !ok {
+			return
+		}
+
+		deleteCount, err := o.svc.DeleteOrganization(gCtx.Request.Context(), model.OrganizationID(organizationId), expectedVersion)
 
 		if err != nil {
+			if err == errors.ErrVersionConflict {
+				o.writeVersionConflict(gCtx, model.OrganizationID(organizationId))
+				return
+			}
+
 			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
 				Code:    "server/internal-error",
 				Message: "An Internal Server error has occurred",
@@ -137,6 +148,12 @@ func (u *OrganizationController) GetOrganizationsForUser() gin.HandlerFunc {
 
 		userId := gCtx.Param("userId")
 
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
 		user, err := u.userSvc.GetByID(gCtx.Request.Context(), model.UserID(userId))
 
 		if err != nil {
@@ -162,7 +179,7 @@ func (u *OrganizationController) GetOrganizationsForUser() gin.HandlerFunc {
 			}
 		}
 
-		data, err := u.svc.GetOrganizationList(gCtx.Request.Context(), organizations)
+		data, info, err := u.svc.GetOrganizationList(gCtx.Request.Context(), organizations, pageParams)
 
 		if err != nil {
 			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
@@ -172,7 +189,153 @@ func (u *OrganizationController) GetOrganizationsForUser() gin.HandlerFunc {
 			return
 		}
 
-		gCtx.JSON(http.StatusOK, data)
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (o *OrganizationController) GetOrganization() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		organizationId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		org, err := o.svc.GetByID(gCtx.Request.Context(), model.OrganizationID(organizationId), model.UserID(userId))
+
+		if err != nil {
+			o.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.Header("ETag", response.ETag(org.Version))
+		gCtx.JSON(http.StatusOK, org)
+
+	}
+}
+
+func (o *OrganizationController) UpdateOrganization() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var orgReq model.Organization
+
+		err := gCtx.BindJSON(&orgReq)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			o.logger.WithError(err).Error("error in decoding body in organization update")
+			return
+		}
+
+		expectedVersion, ok := response.IfMatch(gCtx)
+
+		if !ok {
+			return
+		}
+
+		organizationId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		org, err := o.svc.Update(gCtx.Request.Context(), model.OrganizationID(organizationId), model.UserID(userId), orgReq, expectedVersion)
+
+		if err != nil {
+			if err == errors.ErrVersionConflict {
+				o.writeVersionConflict(gCtx, model.OrganizationID(organizationId))
+				return
+			}
+
+			o.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.Header("ETag", response.ETag(org.Version))
+		gCtx.JSON(http.StatusOK, org)
+
+	}
+}
+
+func (o *OrganizationController) SetHealthPolicy() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var policy model.HealthPolicy
+
+		err := gCtx.BindJSON(&policy)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			o.logger.WithError(err).Error("error in decoding body in organization health policy")
+			return
+		}
+
+		organizationId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		org, err := o.svc.SetHealthPolicy(gCtx.Request.Context(), model.OrganizationID(organizationId), model.UserID(userId), policy)
+
+		if err != nil {
+			o.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.Header("ETag", response.ETag(org.Version))
+		gCtx.JSON(http.StatusOK, org)
+
+	}
+}
+
+// writeVersionConflict answers a request whose If-Match no longer matches the organization with its current version.
+func (o *OrganizationController) writeVersionConflict(gCtx *gin.Context, organizationId model.OrganizationID) {
+	version, err := o.svc.GetVersion(gCtx.Request.Context(), organizationId)
 
+	if err != nil {
+		o.writeError(gCtx, err)
+		return
+	}
+
+	response.WriteVersionConflict(gCtx, version)
+}
+
+func (o *OrganizationController) writeError(gCtx *gin.Context, err error) {
+	switch err {
+	case errors.ErrInvalidID, errors.ErrInvalidOrganizationID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "organization/invalid-id",
+			Message: "Organization ID is not valid",
+		})
+	case errors.ErrInvalidOrganizationName:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "organization/invalid-name",
+			Message: "Organization name is not valid",
+		})
+	case errors.ErrInvalidHealthPolicy:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "organization/invalid-health-policy",
+			Message: "Health policy limits must not be negative and the max age at most 3650 days",
+		})
+	case errors.ErrNotOrganizationMember:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "organization/not-member",
+			Message: "User is not a member of the organization",
+		})
+	case errors.ErrOrganizationAccessDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "organization/access-denied",
+			Message: "Only organization admins can change the organization",
+		})
+	case errors.ErrOrganizationNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "organization/not-found",
+			Message: "Organization not found",
+		})
+	default:
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
 	}
 }
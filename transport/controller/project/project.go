@@ -0,0 +1,271 @@
+package project
+
+import (
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/project"
+	"secaas_backend/svc/secret"
+	"secaas_backend/transport/controller/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type ProjectController struct {
+	logger    *logrus.Logger
+	svc       *project.ProjectSVC
+	secretSvc *secret.SecretsSVC
+}
+
+func New(svc *project.ProjectSVC, secretSvc *secret.SecretsSVC, logger *logrus.Logger) *ProjectController {
+	pc := &ProjectController{logger: logger, svc: svc, secretSvc: secretSvc}
+	return pc
+}
+
+func (p *ProjectController) Create() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var project model.Project
+
+		err := gCtx.BindJSON(&project)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			p.logger.WithError(err).Error("error in decoding body in project create")
+			return
+		}
+
+		userId := gCtx.Query("userId")
+
+		newProject, err := p.svc.Create(gCtx.Request.Context(), project, model.UserID(userId))
+
+		if err != nil {
+			p.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusCreated, newProject)
+
+	}
+}
+
+func (p *ProjectController) Get() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		projectId := gCtx.Param("projectId")
+
+		project, err := p.svc.GetByID(gCtx.Request.Context(), model.ProjectID(projectId))
+
+		if err != nil {
+			p.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, project)
+
+	}
+}
+
+func (p *ProjectController) GetForOrganization() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		data, err := p.svc.GetForOrganization(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId))
+
+		if err != nil {
+			p.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, data)
+
+	}
+}
+
+func (p *ProjectController) SetEnvironments() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var environments []model.ProjectEnvironment
+
+		err := gCtx.BindJSON(&environments)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			p.logger.WithError(err).Error("error in decoding body in project environments")
+			return
+		}
+
+		projectId := gCtx.Param("projectId")
+		userId := gCtx.Query("userId")
+
+		project, err := p.svc.SetEnvironments(gCtx.Request.Context(), model.ProjectID(projectId), model.UserID(userId), environments)
+
+		if err != nil {
+			p.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, project)
+
+	}
+}
+
+func (p *ProjectController) Delete() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		projectId := gCtx.Param("projectId")
+		userId := gCtx.Query("userId")
+
+		deleteCount, err := p.svc.Delete(gCtx.Request.Context(), model.ProjectID(projectId), model.UserID(userId))
+
+		if err != nil {
+			p.writeError(gCtx, err)
+			return
+		}
+
+		if deleteCount > 0 {
+			projectId = ""
+		}
+
+		gCtx.JSON(http.StatusOK, gin.H{
+			"deleted": deleteCount > 0,
+			"id":      projectId,
+		})
+
+	}
+}
+
+func (p *ProjectController) GetEnvironmentBundle() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		projectId := gCtx.Param("projectId")
+		environment := gCtx.Param("environment")
+		userId := gCtx.Query("userId")
+
+		bundle, err := p.secretSvc.GetEnvironmentBundle(gCtx.Request.Context(), model.ProjectID(projectId), environment, model.UserID(userId))
+
+		if err != nil {
+			p.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, bundle)
+
+	}
+}
+
+func (p *ProjectController) PromoteSecret() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var promotion model.SecretPromotion
+
+		err := gCtx.BindJSON(&promotion)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			p.logger.WithError(err).Error("error in decoding body in secret promotion")
+			return
+		}
+
+		projectId := gCtx.Param("projectId")
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		promoted, err := p.secretSvc.PromoteSecret(gCtx.Request.Context(), model.ProjectID(projectId), model.SecretID(secretId), model.UserID(userId), promotion.TargetEnvironment, promotion.Signature)
+
+		if err != nil {
+			p.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, promoted)
+
+	}
+}
+
+func (p *ProjectController) writeError(gCtx *gin.Context, err error) {
+	switch err {
+	case errors.ErrInvalidID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "project/invalid-id",
+			Message: "ID is not valid",
+		})
+	case errors.ErrInvalidProject:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "project/invalid",
+			Message: "Project details are not valid",
+		})
+	case errors.ErrEnvironmentNotFound:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "project/environment-not-found",
+			Message: "Environment not found in project",
+		})
+	case errors.ErrNoNextEnvironment:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "project/no-next-environment",
+			Message: "Environment has no next environment to promote to",
+		})
+	case errors.ErrProjectNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "project/not-found",
+			Message: "Project not found",
+		})
+	case errors.ErrSecretNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "secret/not-found",
+			Message: "Secret not found",
+		})
+	case errors.ErrProjectExists:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "project/exists",
+			Message: "A project with this slug already exists",
+		})
+	case errors.ErrProjectNotEmpty:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "project/not-empty",
+			Message: "Project or environment still holds secrets",
+		})
+	case errors.ErrSecretNameExists:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "secret/name-exists",
+			Message: "A secret with this name already exists in the environment",
+		})
+	case errors.ErrSecretSignatureRequired:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/signature-required",
+			Message: "Secret is signed or the promoter has a signing key, the promotion has to be signed",
+		})
+	case errors.ErrInvalidSecretSignature:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-signature",
+			Message: "Signature does not verify against the signing key of the promoter",
+		})
+	case errors.ErrSigningKeyNotFound:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/signing-key-not-found",
+			Message: "Promoter had no signing key with this id when signing",
+		})
+	case errors.ErrProjectAccessDenied, errors.ErrSecretAccessDenied, errors.ErrNotOrganizationMember:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "project/access-denied",
+			Message: "User is not allowed to perform this action on the project",
+		})
+	default:
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package response
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag formats the version of a resource as an entity tag.
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// IfMatch reads the version the If-Match header expects. It returns nil when the header is missing or
+// is *, and false when it does not hold a version, after writing the bad request response.
+func IfMatch(gCtx *gin.Context) (*int64, bool) {
+	header := strings.TrimSpace(gCtx.GetHeader("If-Match"))
+
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+
+	version, err := strconv.ParseInt(tag, 10, 64)
+
+	if err != nil || version < 0 {
+		gCtx.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    "data/invalid-if-match",
+			Message: "If-Match header does not hold a version",
+		})
+		return nil, false
+	}
+
+	return &version, true
+}
+
+// WriteVersionConflict answers a request whose If-Match did not match with the current version.
+func WriteVersionConflict(gCtx *gin.Context, version int64) {
+	gCtx.Header("ETag", ETag(version))
+	gCtx.JSON(http.StatusPreconditionFailed, ErrorResponse{
+		Code:    "data/version-conflict",
+		Message: "Resource was changed since the version in If-Match",
+		Data:    gin.H{"currentVersion": version},
+	})
+}
@@ -0,0 +1,126 @@
+package response
+
+import (
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/pagination"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPageLimit = 10
+	MaxPageLimit     = 100
+)
+
+// Pagination reads the page, limit, cursor and total query parameters of a list request. A cursor
+// takes the place of the page. It returns false after writing the bad request response when the
+// cursor is not valid.
+func Pagination(gCtx *gin.Context) (model.PaginationParams, bool) {
+	page, err := strconv.Atoi(gCtx.Query("page"))
+
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(gCtx.Query("limit"))
+
+	if err != nil || limit < 1 || limit > MaxPageLimit {
+		limit = DefaultPageLimit
+	}
+
+	params := model.PaginationParams{
+		Page:      page,
+		Limit:     limit,
+		Skip:      (page - 1) * limit,
+		WithTotal: gCtx.Query("total") == "true",
+	}
+
+	if rawCursor := gCtx.Query("cursor"); rawCursor != "" {
+		cursor, err := pagination.DecodeCursor(rawCursor)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    "data/invalid-cursor",
+				Message: "Page cursor is not valid",
+			})
+			return params, false
+		}
+
+		params.Cursor = cursor
+	}
+
+	return params, true
+}
+
+// WritePage answers a list request with one page of data. The next page is linked in the Link header
+// and the first page whenever the request was not for it.
+func WritePage(gCtx *gin.Context, params model.PaginationParams, data any, info model.PageInfo) {
+	resp := model.PaginationResponse{
+		CurrentPage: params.Page,
+		Data:        data,
+		Limit:       params.Limit,
+		HasMore:     info.HasMore,
+		NextCursor:  info.NextCursor,
+		Total:       info.Total,
+	}
+
+	links := []string{}
+
+	if info.HasMore {
+		links = append(links, pageLink(gCtx, "next", info.NextCursor))
+
+		// Clients which page by number keep doing so, the next page only exists while there is more.
+		if params.Cursor == nil {
+			resp.NextPage = params.Page + 1
+		}
+	}
+
+	if params.Cursor != nil || params.Page > 1 {
+		links = append(links, pageLink(gCtx, "first", ""))
+	}
+
+	for _, link := range links {
+		gCtx.Writer.Header().Add("Link", link)
+	}
+
+	gCtx.JSON(http.StatusOK, resp)
+}
+
+// WriteNumberedPage answers a list request with one page of data for lists which are not sorted by
+// the cursor key. They page by number only, the next page is set while there is more.
+func WriteNumberedPage(gCtx *gin.Context, params model.PaginationParams, data any, info model.PageInfo) {
+	resp := model.PaginationResponse{
+		CurrentPage: params.Page,
+		Data:        data,
+		Limit:       params.Limit,
+		HasMore:     info.HasMore,
+		Total:       info.Total,
+	}
+
+	if info.HasMore {
+		resp.NextPage = params.Page + 1
+	}
+
+	gCtx.JSON(http.StatusOK, resp)
+}
+
+// pageLink links the request with the page replaced by the cursor, or the first page without a cursor.
+func pageLink(gCtx *gin.Context, rel string, cursor string) string {
+	query := gCtx.Request.URL.Query()
+	query.Del("page")
+	query.Del("cursor")
+
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+
+	target := gCtx.Request.URL.Path
+
+	if encoded := query.Encode(); encoded != "" {
+		target += "?" + encoded
+	}
+
+	return "<" + target + `>; rel="` + rel + `"`
+}
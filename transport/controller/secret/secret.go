@@ -1,13 +1,13 @@
 package secret
 
 import (
-	"math"
 	"net/http"
 	"secaas_backend/model"
 	"secaas_backend/svc/errors"
 	"secaas_backend/svc/secret"
 	"secaas_backend/transport/controller/response"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -68,6 +68,10 @@ func (s *SecretsController) Create() gin.HandlerFunc {
 				return
 			}
 
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
 			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
 				Code:    "server/internal-error",
 				Message: "An Internal Server error has occurred",
@@ -86,27 +90,13 @@ func (s *SecretsController) GetForUserOrganization() gin.HandlerFunc {
 		orgId := gCtx.Param("organizationId")
 		userId := gCtx.Param("userId")
 
-		rawPage := gCtx.Query("page")
-		rawLimit := gCtx.Query("limit")
-		page, err := strconv.Atoi(rawPage)
-
-		if err != nil || page < 0 {
-			page = 1
-		}
-
-		limit, err := strconv.Atoi(rawLimit)
-
-		if err != nil || limit < 0 || limit > 100 {
-			limit = 10
-		}
+		pageParams, ok := response.Pagination(gCtx)
 
-		pageParams := model.PaginationParams{
-			Page:  page,
-			Limit: limit,
-			Skip:  int(math.Max(float64(page-1), 0)) * limit,
+		if !ok {
+			return
 		}
 
-		data, err := s.svc.GetAllSecretsforaUserInOrganization(gCtx.Request.Context(), model.UserID(userId), model.OrganizationID(orgId), pageParams)
+		data, info, err := s.svc.GetAllSecretsforaUserInOrganization(gCtx.Request.Context(), model.UserID(userId), model.OrganizationID(orgId), pageParams)
 
 		if err != nil {
 			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
@@ -116,13 +106,7 @@ func (s *SecretsController) GetForUserOrganization() gin.HandlerFunc {
 			return
 		}
 
-		resp := model.PaginationResponse{
-			CurrentPage: page,
-			Data:        data,
-			Limit:       limit,
-			NextPage:    page + 1,
-		}
-		gCtx.JSON(http.StatusOK, resp)
+		response.WritePage(gCtx, pageParams, data, info)
 
 	}
 }
@@ -131,30 +115,21 @@ func (s *SecretsController) GetForOrganization() gin.HandlerFunc {
 	return func(gCtx *gin.Context) {
 
 		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
 
-		rawPage := gCtx.Query("page")
-		rawLimit := gCtx.Query("limit")
-		page, err := strconv.Atoi(rawPage)
-
-		if err != nil || page < 0 {
-			page = 1
-		}
-
-		limit, err := strconv.Atoi(rawLimit)
-
-		if err != nil || limit < 0 || limit > 100 {
-			limit = 10
-		}
+		pageParams, ok := response.Pagination(gCtx)
 
-		pageParams := model.PaginationParams{
-			Page:  page,
-			Limit: limit,
-			Skip:  int(math.Max(float64(page-1), 0)) * limit,
+		if !ok {
+			return
 		}
 
-		data, err := s.svc.GetAllSecretsforOrganization(gCtx.Request.Context(), model.OrganizationID(orgId), pageParams)
+		data, info, err := s.svc.GetAllSecretsforOrganization(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId), pageParams)
 
 		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
 			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
 				Code:    "server/internal-error",
 				Message: "An Internal Server error has occurred",
@@ -162,13 +137,7 @@ func (s *SecretsController) GetForOrganization() gin.HandlerFunc {
 			return
 		}
 
-		resp := model.PaginationResponse{
-			CurrentPage: page,
-			Data:        data,
-			Limit:       limit,
-			NextPage:    page + 1,
-		}
-		gCtx.JSON(http.StatusOK, resp)
+		response.WritePage(gCtx, pageParams, data, info)
 
 	}
 }
@@ -179,29 +148,21 @@ func (s *SecretsController) GetUsersForSecret() gin.HandlerFunc {
 		secretId := gCtx.Param("secretId")
 		orgId := gCtx.Param("organizationId")
 
-		rawPage := gCtx.Query("page")
-		rawLimit := gCtx.Query("limit")
-		page, err := strconv.Atoi(rawPage)
+		pageParams, ok := response.Pagination(gCtx)
 
-		if err != nil || page < 0 {
-			page = 1
-		}
-
-		limit, err := strconv.Atoi(rawLimit)
-
-		if err != nil || limit < 0 || limit > 100 {
-			limit = 10
+		if !ok {
+			return
 		}
 
-		pageParams := model.PaginationParams{
-			Page:  page,
-			Limit: limit,
-			Skip:  int(math.Max(float64(page-1), 0)) * limit,
-		}
+		userId := gCtx.Query("userId")
 
-		data, err := s.svc.GetAllUsersforSecretByAdmin(gCtx.Request.Context(), model.OrganizationID(orgId), secretId, pageParams)
+		data, info, err := s.svc.GetAllUsersforSecretByAdmin(gCtx.Request.Context(), model.OrganizationID(orgId), secretId, model.UserID(userId), pageParams)
 
 		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
 			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
 				Code:    "server/internal-error",
 				Message: "An Internal Server error has occurred",
@@ -209,13 +170,7 @@ func (s *SecretsController) GetUsersForSecret() gin.HandlerFunc {
 			return
 		}
 
-		resp := model.PaginationResponse{
-			CurrentPage: page,
-			Data:        data,
-			Limit:       limit,
-			NextPage:    page + 1,
-		}
-		gCtx.JSON(http.StatusOK, resp)
+		response.WritePage(gCtx, pageParams, data, info)
 
 	}
 }
@@ -247,7 +202,9 @@ func (s *SecretsController) ShareKey() gin.HandlerFunc {
 			return
 		}
 
-		keyInsertStatus, err := s.svc.ShareSecret(gCtx.Request.Context(), model.SecretID(keyId), userData)
+		userId := gCtx.Query("userId")
+
+		keyInsertStatus, err := s.svc.ShareSecret(gCtx.Request.Context(), model.SecretID(keyId), model.UserID(userId), userData)
 
 		if err != nil {
 			if err == errors.ErrInvalidID {
@@ -266,6 +223,10 @@ func (s *SecretsController) ShareKey() gin.HandlerFunc {
 				return
 			}
 
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
 			if err != nil {
 				gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
 					Code:    "server/internal-error",
@@ -280,3 +241,1243 @@ func (s *SecretsController) ShareKey() gin.HandlerFunc {
 
 	}
 }
+
+func (s *SecretsController) Get() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		secret, err := s.svc.Read(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), consumer(gCtx))
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.Header("ETag", response.ETag(secret.Version))
+		gCtx.JSON(http.StatusOK, secret)
+
+	}
+}
+
+// writeVersionConflict answers a request whose If-Match no longer matches the secret with its current version.
+func (s *SecretsController) writeVersionConflict(gCtx *gin.Context, secretId model.SecretID, userId model.UserID) {
+	version, err := s.svc.GetVersion(gCtx.Request.Context(), secretId, userId)
+
+	if err != nil {
+		if s.writeAccessError(gCtx, err) {
+			return
+		}
+
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
+		return
+	}
+
+	response.WriteVersionConflict(gCtx, version)
+}
+
+func (s *SecretsController) Update() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var secret model.Secret
+
+		err := gCtx.BindJSON(&secret)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			s.logger.WithError(err).Error("error in decoding body in secret update")
+			return
+		}
+
+		expectedVersion, ok := response.IfMatch(gCtx)
+
+		if !ok {
+			return
+		}
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		updated, err := s.svc.Update(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), secret, expectedVersion)
+
+		if err != nil {
+			if err == errors.ErrVersionConflict {
+				s.writeVersionConflict(gCtx, model.SecretID(secretId), model.UserID(userId))
+				return
+			}
+
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.Header("ETag", response.ETag(updated.Version))
+		gCtx.JSON(http.StatusOK, updated)
+
+	}
+}
+
+func (s *SecretsController) Delete() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		expectedVersion, ok := response.IfMatch(gCtx)
+
+		if !ok {
+			return
+		}
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		deleteCount, err := s.svc.Delete(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), expectedVersion)
+
+		if err != nil {
+			if err == errors.ErrVersionConflict {
+				s.writeVersionConflict(gCtx, model.SecretID(secretId), model.UserID(userId))
+				return
+			}
+
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		if deleteCount > 0 {
+			secretId = ""
+		}
+
+		gCtx.JSON(http.StatusOK, gin.H{
+			"deleted": deleteCount > 0,
+			"id":      secretId,
+		})
+
+	}
+}
+
+func (s *SecretsController) ChangeRole() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var roleChange model.SecretRoleChange
+
+		err := gCtx.BindJSON(&roleChange)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			s.logger.WithError(err).Error("error in decoding body in secret role change")
+			return
+		}
+
+		secretId := gCtx.Param("secretId")
+		memberId := gCtx.Param("memberId")
+		userId := gCtx.Query("userId")
+
+		secret, err := s.svc.ChangeRole(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), model.UserID(memberId), roleChange.Role)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, secret)
+
+	}
+}
+
+func (s *SecretsController) RenewShare() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var renewal model.SecretShareRenewal
+
+		err := gCtx.BindJSON(&renewal)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			s.logger.WithError(err).Error("error in decoding body in secret share renewal")
+			return
+		}
+
+		secretId := gCtx.Param("secretId")
+		memberId := gCtx.Param("memberId")
+		userId := gCtx.Query("userId")
+
+		secret, err := s.svc.RenewShare(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), model.UserID(memberId), renewal.AccessExpiresAt, renewal.KeepUpgrade)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, secret)
+
+	}
+}
+
+func (s *SecretsController) GetExpiringShares() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		withinHours, err := strconv.Atoi(gCtx.Query("withinHours"))
+
+		if err != nil || withinHours <= 0 {
+			withinHours = int(secret.ShareExpiryReminderWindow.Hours())
+		}
+
+		data, err := s.svc.GetExpiringShares(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId), time.Duration(withinHours)*time.Hour)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, data)
+
+	}
+}
+
+func (s *SecretsController) ShareWithTeam() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var share model.SecretTeam
+
+		err := gCtx.BindJSON(&share)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			s.logger.WithError(err).Error("error in decoding body in secret team share")
+			return
+		}
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		secret, err := s.svc.ShareSecretWithTeam(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), share)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusCreated, secret)
+
+	}
+}
+
+func (s *SecretsController) GetTeamsForSecret() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		data, err := s.svc.GetTeamsForSecret(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId))
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, data)
+
+	}
+}
+
+func (s *SecretsController) ChangeTeamRole() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var roleChange model.SecretRoleChange
+
+		err := gCtx.BindJSON(&roleChange)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			s.logger.WithError(err).Error("error in decoding body in secret team role change")
+			return
+		}
+
+		secretId := gCtx.Param("secretId")
+		teamId := gCtx.Param("teamId")
+		userId := gCtx.Query("userId")
+
+		secret, err := s.svc.ChangeTeamRole(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), model.TeamID(teamId), roleChange.Role)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, secret)
+
+	}
+}
+
+func (s *SecretsController) RevokeTeamShare() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		teamId := gCtx.Param("teamId")
+		userId := gCtx.Query("userId")
+
+		deleteCount, err := s.svc.RevokeTeamShare(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), model.TeamID(teamId))
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, gin.H{
+			"deleted": deleteCount > 0,
+		})
+
+	}
+}
+
+func (s *SecretsController) MoveToCollection() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var move model.SecretCollectionMove
+
+		err := gCtx.BindJSON(&move)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			s.logger.WithError(err).Error("error in decoding body in secret move")
+			return
+		}
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		secret, err := s.svc.MoveSecret(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), move.CollectionID)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, secret)
+
+	}
+}
+
+func (s *SecretsController) GetByCollectionPath() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+		path := gCtx.Query("path")
+		recursive := gCtx.Query("recursive") == "true"
+
+		if path == "" {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "collection/invalid-path",
+				Message: "Collection path is not valid",
+			})
+			return
+		}
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := s.svc.GetSecretsByPath(gCtx.Request.Context(), model.OrganizationID(orgId), path, recursive, model.UserID(userId), pageParams)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+// GetKinds lists the secret kinds and the schema of their metadata.
+func (s *SecretsController) GetKinds() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+		gCtx.JSON(http.StatusOK, s.svc.GetKinds())
+	}
+}
+
+// GetImportProfiles lists the export formats the clients can translate into an import.
+func (s *SecretsController) GetImportProfiles() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+		gCtx.JSON(http.StatusOK, s.svc.GetImportProfiles())
+	}
+}
+
+// Import creates a batch of secrets and reports the outcome of every item.
+// Atomic imports which were aborted answer 422 with the report.
+func (s *SecretsController) Import() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var data model.SecretImport
+
+		err := gCtx.BindJSON(&data)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			s.logger.WithError(err).Error("error in decoding body in secret import")
+			return
+		}
+
+		userId := gCtx.Query("userId")
+
+		report, err := s.svc.ImportSecrets(gCtx.Request.Context(), model.UserID(userId), data)
+
+		if err != nil {
+			if err == errors.ErrSecretImportAborted {
+				gCtx.JSON(http.StatusUnprocessableEntity, report)
+				return
+			}
+
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, report)
+
+	}
+}
+
+// Search finds the secrets of the organization by their tags, name, description, type and dates.
+func (s *SecretsController) Search() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		userId := gCtx.Query("userId")
+
+		var search model.SecretSearch
+
+		err := gCtx.ShouldBindQuery(&search)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "secret/invalid-search",
+				Message: "Search query is not valid",
+			})
+			return
+		}
+
+		search.OrganizationID = gCtx.Param("organizationId")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := s.svc.Search(gCtx.Request.Context(), model.UserID(userId), search, pageParams)
+
+		if err != nil {
+			if err == errors.ErrInvalidSearch {
+				gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+					Code:    "secret/invalid-search",
+					Message: "Search query is not valid",
+				})
+				return
+			}
+
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		// Results are sorted by the search, not by the cursor key.
+		response.WriteNumberedPage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (s *SecretsController) SetCheckoutPolicy() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var policy model.SecretCheckoutPolicy
+
+		err := gCtx.BindJSON(&policy)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			s.logger.WithError(err).Error("error in decoding body in secret checkout policy")
+			return
+		}
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		state, err := s.svc.SetCheckoutPolicy(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), policy)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, state)
+
+	}
+}
+
+func (s *SecretsController) GetCheckout() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		state, err := s.svc.GetCheckout(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId))
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, state)
+
+	}
+}
+
+// Checkout returns the secret with its encrypted data once the user holds the lease, or 202 with the queue when it is held by someone else.
+func (s *SecretsController) Checkout() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		secret, queued, err := s.svc.Checkout(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), consumer(gCtx))
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		if queued {
+			gCtx.JSON(http.StatusAccepted, secret)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, secret)
+
+	}
+}
+
+func (s *SecretsController) Checkin() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		state, err := s.svc.Checkin(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId))
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, state)
+
+	}
+}
+
+func (s *SecretsController) ForceCheckin() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		state, err := s.svc.ForceCheckin(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId))
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, state)
+
+	}
+}
+
+func (s *SecretsController) LeaveCheckoutQueue() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		state, err := s.svc.LeaveCheckoutQueue(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId))
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, state)
+
+	}
+}
+
+func (s *SecretsController) SetRotationPolicy() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var policy model.RotationPolicy
+
+		err := gCtx.BindJSON(&policy)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			s.logger.WithError(err).Error("error in decoding body in secret rotation policy")
+			return
+		}
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		sec, err := s.svc.SetRotationPolicy(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), policy)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, sec)
+
+	}
+}
+
+func (s *SecretsController) GetOverdueRotations() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := s.svc.GetOverdueRotations(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId), pageParams)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		// Overdue secrets are sorted by due date.
+		response.WriteNumberedPage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (s *SecretsController) TransferOwnership() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var transfer model.SecretOwnershipTransfer
+
+		err := gCtx.BindJSON(&transfer)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			s.logger.WithError(err).Error("error in decoding body in secret ownership transfer")
+			return
+		}
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		sec, err := s.svc.TransferOwnership(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId), transfer)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, sec)
+
+	}
+}
+
+// ReassignOwnership moves all the secrets a departing member owns in the organization to another member.
+func (s *SecretsController) ReassignOwnership() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var reassignment model.SecretOwnershipReassignment
+
+		err := gCtx.BindJSON(&reassignment)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			s.logger.WithError(err).Error("error in decoding body in secret ownership reassignment")
+			return
+		}
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		result, err := s.svc.ReassignOwnership(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId), reassignment)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, result)
+
+	}
+}
+
+func (s *SecretsController) GetHealthReport() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		report, err := s.svc.GetHealthReport(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId))
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, report)
+
+	}
+}
+
+// consumer reads the service account or device a read is made for, reads without one are made by the user.
+func consumer(gCtx *gin.Context) model.SecretConsumer {
+	return model.SecretConsumer{
+		Kind: gCtx.GetHeader("X-Consumer-Kind"),
+		ID:   gCtx.GetHeader("X-Consumer-Id"),
+	}
+}
+
+func (s *SecretsController) GetAccessSummary() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		secretId := gCtx.Param("secretId")
+		userId := gCtx.Query("userId")
+
+		summary, err := s.svc.GetAccessSummary(gCtx.Request.Context(), model.SecretID(secretId), model.UserID(userId))
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, summary)
+
+	}
+}
+
+// GetUnusedSecrets lists the secrets of the organization nobody read for ?days=, 90 by default.
+func (s *SecretsController) GetUnusedSecrets() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		days := secret.DefaultUnusedDays
+
+		if rawDays := gCtx.Query("days"); rawDays != "" {
+			parsed, err := strconv.Atoi(rawDays)
+
+			if err != nil {
+				gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+					Code:    "secret/invalid-unused-days",
+					Message: "Unused days must be between 1 and 3650",
+				})
+				return
+			}
+
+			days = parsed
+		}
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := s.svc.GetUnusedSecrets(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId), days, pageParams)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+// GetExpiringCertificates lists the certificates of the organization expiring within the days.
+func (s *SecretsController) GetExpiringCertificates() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		days := secret.DefaultCertificateExpiryDays
+
+		if rawDays := gCtx.Query("days"); rawDays != "" {
+			parsed, err := strconv.Atoi(rawDays)
+
+			if err != nil {
+				gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+					Code:    "secret/invalid-expiry-days",
+					Message: "Expiry days must be between 1 and 3650",
+				})
+				return
+			}
+
+			days = parsed
+		}
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := s.svc.GetExpiringCertificates(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId), days, pageParams)
+
+		if err != nil {
+			if s.writeAccessError(gCtx, err) {
+				return
+			}
+
+			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    "server/internal-error",
+				Message: "An Internal Server error has occurred",
+			})
+			return
+		}
+
+		// The inventory is sorted by expiry.
+		response.WriteNumberedPage(gCtx, pageParams, data, info)
+
+	}
+}
+
+// writeAccessError writes the response for the errors returned by secret access checks.
+// It returns false if the error is not an access error.
+func (s *SecretsController) writeAccessError(gCtx *gin.Context, err error) bool {
+	switch err {
+	case errors.ErrInvalidID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-id",
+			Message: "Secret ID is not valid",
+		})
+	case errors.ErrSecretNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "secret/not-found",
+			Message: "Secret not found",
+		})
+	case errors.ErrSecretUserNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "secret/user-not-found",
+			Message: "Secret is not shared with the user",
+		})
+	case errors.ErrSecretAccessDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "secret/access-denied",
+			Message: "User is not allowed to perform this action on the secret",
+		})
+	case errors.ErrInvalidShareExpiry:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-share-expiry",
+			Message: "Share expiry must be in the future",
+		})
+	case errors.ErrTeamNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "team/not-found",
+			Message: "Team not found",
+		})
+	case errors.ErrOrganizationMismatch:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/organization-mismatch",
+			Message: "Secret and target belong to different organizations",
+		})
+	case errors.ErrSecretAlreadyShared:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "secret/already-shared",
+			Message: "Secret is already shared",
+		})
+	case errors.ErrCollectionNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "collection/not-found",
+			Message: "Collection not found",
+		})
+	case errors.ErrCollectionAccessDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "collection/access-denied",
+			Message: "User is not allowed to perform this action on the collection",
+		})
+	case errors.ErrProjectNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "project/not-found",
+			Message: "Project not found",
+		})
+	case errors.ErrEnvironmentNotFound:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "project/environment-not-found",
+			Message: "Environment not found in project",
+		})
+	case errors.ErrNoNextEnvironment:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "project/no-next-environment",
+			Message: "Environment has no next environment to promote to",
+		})
+	case errors.ErrInvalidProject:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "project/invalid",
+			Message: "Project details are not valid",
+		})
+	case errors.ErrSecretNameExists:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "secret/name-exists",
+			Message: "A secret with this name already exists in the environment",
+		})
+	case errors.ErrInvalidOrganizationID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "organization/invalid-id",
+			Message: "Organization ID is not valid",
+		})
+	case errors.ErrNotOrganizationMember:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "organization/not-member",
+			Message: "User is not a member of the organization",
+		})
+	case errors.ErrInvalidSecretKind:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-kind",
+			Message: "Secret kind is not valid",
+		})
+	case errors.ErrInvalidSecretMetadata:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-metadata",
+			Message: "Secret metadata does not match the schema of the kind",
+		})
+	case errors.ErrInvalidSecretRole:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-role",
+			Message: "Secret role is not valid",
+		})
+	case errors.ErrCheckoutNotEnabled:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/checkout-not-enabled",
+			Message: "Checkout is not enabled for the secret",
+		})
+	case errors.ErrInvalidCheckoutLease:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-checkout-lease",
+			Message: "Checkout lease is not valid",
+		})
+	case errors.ErrCheckoutRequired:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "secret/checkout-required",
+			Message: "Secret must be checked out before it can be read",
+		})
+	case errors.ErrSecretCheckedOut:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "secret/checked-out",
+			Message: "Secret is checked out by another user",
+		})
+	case errors.ErrNotCheckoutHolder:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "secret/not-checkout-holder",
+			Message: "User does not hold the checkout of the secret",
+		})
+	case errors.ErrInvalidOwnershipTransfer:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-ownership-transfer",
+			Message: "New owner must be another member of the organization and the previous owner role must be shareable",
+		})
+	case errors.ErrInvalidSecretImport:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-import",
+			Message: "Import needs between 1 and 500 items and a known profile",
+		})
+	case errors.ErrInvalidSecretSignature:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-signature",
+			Message: "Signature does not verify against the signing key of the author",
+		})
+	case errors.ErrSigningKeyNotFound:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/signing-key-not-found",
+			Message: "Author had no signing key with this id when signing",
+		})
+	case errors.ErrSecretSignatureRequired:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/signature-required",
+			Message: "Secret is signed or the author has a signing key, the write has to be signed",
+		})
+	case errors.ErrUserNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "user/not-found",
+			Message: "User Not Found",
+		})
+	case errors.ErrInvalidCertificateChain:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-certificate-chain",
+			Message: "Certificate chain must be PEM encoded certificates of a TLS certificate secret",
+		})
+	case errors.ErrInvalidExpiryDays:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-expiry-days",
+			Message: "Expiry days must be between 1 and 3650",
+		})
+	case errors.ErrInvalidValueFingerprint:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-value-fingerprint",
+			Message: "Value fingerprint must be a hex or base64 encoded HMAC of 16 to 64 bytes",
+		})
+	case errors.ErrInvalidSecretConsumer:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-consumer",
+			Message: "Consumer kind must be user, service-account or device, with an id of at most 128 characters",
+		})
+	case errors.ErrInvalidUnusedDays:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-unused-days",
+			Message: "Unused days must be between 1 and 3650",
+		})
+	case errors.ErrOrganizationNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "organization/not-found",
+			Message: "Organization not found",
+		})
+	case errors.ErrInvalidRotationPolicy:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "secret/invalid-rotation-policy",
+			Message: "Rotation interval must be between 0 and 3650 days",
+		})
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,208 @@
+package sharelink
+
+import (
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/sharelink"
+	"secaas_backend/transport/controller/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type ShareLinkController struct {
+	logger *logrus.Logger
+	svc    *sharelink.ShareLinkSVC
+}
+
+func New(svc *sharelink.ShareLinkSVC, logger *logrus.Logger) *ShareLinkController {
+	sc := &ShareLinkController{logger: logger, svc: svc}
+	return sc
+}
+
+func (s *ShareLinkController) Create() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var data model.ShareLinkCreate
+
+		err := gCtx.BindJSON(&data)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			s.logger.WithError(err).Error("error in decoding body in share link create")
+			return
+		}
+
+		userId := gCtx.Query("userId")
+
+		link, err := s.svc.Create(gCtx.Request.Context(), model.UserID(userId), data)
+
+		if err != nil {
+			s.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusCreated, link)
+
+	}
+}
+
+// GetInfo is called by the recipient, it does not need an account.
+func (s *ShareLinkController) GetInfo() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		linkId := gCtx.Param("linkId")
+
+		info, err := s.svc.GetInfo(gCtx.Request.Context(), model.ShareLinkID(linkId))
+
+		if err != nil {
+			s.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, info)
+
+	}
+}
+
+// Open is called by the recipient, it does not need an account. It is a POST so link previews do not use up views.
+func (s *ShareLinkController) Open() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var open model.ShareLinkOpen
+
+		// The body is optional for links without a passphrase.
+		if gCtx.Request.ContentLength > 0 {
+			err := gCtx.BindJSON(&open)
+
+			if err != nil {
+				gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+					Code:    "data/invalid-payload",
+					Message: "Payload format is not valid",
+				})
+				return
+			}
+		}
+
+		linkId := gCtx.Param("linkId")
+
+		access := model.ShareLinkAccess{
+			IP:        gCtx.ClientIP(),
+			UserAgent: gCtx.Request.UserAgent(),
+		}
+
+		payload, err := s.svc.Open(gCtx.Request.Context(), model.ShareLinkID(linkId), open.Passphrase, access)
+
+		if err != nil {
+			s.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.Header("Cache-Control", "no-store")
+		gCtx.JSON(http.StatusOK, payload)
+
+	}
+}
+
+func (s *ShareLinkController) GetForUser() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		userId := gCtx.Param("userId")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := s.svc.GetForUser(gCtx.Request.Context(), model.UserID(userId), pageParams)
+
+		if err != nil {
+			s.writeError(gCtx, err)
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (s *ShareLinkController) Get() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		linkId := gCtx.Param("linkId")
+		userId := gCtx.Query("userId")
+
+		link, err := s.svc.GetByID(gCtx.Request.Context(), model.ShareLinkID(linkId), model.UserID(userId))
+
+		if err != nil {
+			s.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, link)
+
+	}
+}
+
+func (s *ShareLinkController) Revoke() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		linkId := gCtx.Param("linkId")
+		userId := gCtx.Query("userId")
+
+		link, err := s.svc.Revoke(gCtx.Request.Context(), model.ShareLinkID(linkId), model.UserID(userId))
+
+		if err != nil {
+			s.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, link)
+
+	}
+}
+
+func (s *ShareLinkController) writeError(gCtx *gin.Context, err error) {
+	switch err {
+	case errors.ErrInvalidID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "user/invalid-id",
+			Message: "User ID is not valid",
+		})
+	case errors.ErrInvalidShareLink:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "share-link/invalid",
+			Message: "Share link details are not valid",
+		})
+	case errors.ErrShareLinkNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "share-link/not-found",
+			Message: "Share link not found or no longer available",
+		})
+	case errors.ErrShareLinkPassphrase:
+		gCtx.JSON(http.StatusUnauthorized, response.ErrorResponse{
+			Code:    "share-link/invalid-passphrase",
+			Message: "Passphrase is not valid",
+		})
+	case errors.ErrShareLinkAccessDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "share-link/access-denied",
+			Message: "Only the creator can manage the share link",
+		})
+	case errors.ErrNotOrganizationMember:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "organization/not-member",
+			Message: "User is not a member of the organization",
+		})
+	default:
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/sync"
+	"secaas_backend/transport/controller/response"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type SyncController struct {
+	logger *logrus.Logger
+	svc    *sync.SyncSVC
+}
+
+func New(svc *sync.SyncSVC, logger *logrus.Logger) *SyncController {
+	sc := &SyncController{logger: logger, svc: svc}
+	return sc
+}
+
+// GetChanges returns the changes after the since revision. Without since only the current revision is
+// returned, which clients store after downloading everything.
+func (s *SyncController) GetChanges() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		userId := model.UserID(gCtx.Query("userId"))
+		rawSince := gCtx.Query("since")
+
+		if rawSince == "" {
+			revision, err := s.svc.GetRevision(gCtx.Request.Context(), userId)
+
+			if err != nil {
+				s.writeError(gCtx, err)
+				return
+			}
+
+			gCtx.JSON(http.StatusOK, model.SyncResult{Revision: revision, Changes: []model.SyncChange{}})
+			return
+		}
+
+		since, err := strconv.ParseInt(rawSince, 10, 64)
+
+		if err != nil {
+			s.writeError(gCtx, errors.ErrInvalidSyncRevision)
+			return
+		}
+
+		limit, err := strconv.Atoi(gCtx.Query("limit"))
+
+		if err != nil {
+			limit = sync.DefaultChangeLimit
+		}
+
+		result, err := s.svc.GetChanges(gCtx.Request.Context(), userId, since, limit)
+
+		if err != nil {
+			s.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, result)
+
+	}
+}
+
+// streamEvent is a change read from the subscription, or the error which ended it.
+type streamEvent struct {
+	resumeToken string
+	change      model.SyncChange
+	err         error
+}
+
+// Stream pushes the changes of the user as Server-Sent Events. The id of every event is its resume token,
+// browsers send it back as Last-Event-ID when they reconnect, other clients can pass it as resumeToken.
+// A reset event asks the client to catch up through GET /sync before it opens the stream again.
+func (s *SyncController) Stream() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		ctx := gCtx.Request.Context()
+		userId := model.UserID(gCtx.Query("userId"))
+		resumeToken := gCtx.GetHeader("Last-Event-ID")
+
+		if resumeToken == "" {
+			resumeToken = gCtx.Query("resumeToken")
+		}
+
+		sub, err := s.svc.Subscribe(ctx, userId, resumeToken)
+
+		if err != nil {
+			s.writeError(gCtx, err)
+			return
+		}
+
+		// The reader owns the subscription until it returns, it is closed once the reader is done
+		// and with a fresh context since the request one is cancelled by then.
+		streamCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		events := make(chan streamEvent)
+
+		defer func() {
+			cancel()
+			<-done
+			sub.Close(context.Background())
+		}()
+
+		go func() {
+			defer close(done)
+
+			for {
+				token, change, err := sub.Next(streamCtx)
+
+				select {
+				case events <- streamEvent{resumeToken: token, change: change, err: err}:
+				case <-streamCtx.Done():
+					return
+				}
+
+				if err != nil {
+					return
+				}
+			}
+		}()
+
+		heartbeat := time.NewTicker(sync.StreamHeartbeat)
+		defer heartbeat.Stop()
+
+		gCtx.Header("Content-Type", "text/event-stream")
+		gCtx.Header("Cache-Control", "no-cache")
+		gCtx.Header("Connection", "keep-alive")
+		gCtx.Header("X-Accel-Buffering", "no")
+		gCtx.Status(http.StatusOK)
+		gCtx.Writer.Flush()
+
+		gCtx.Stream(func(w io.Writer) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+				return true
+			case event := <-events:
+				if event.err == errors.ErrSyncResetRequired {
+					fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+					return false
+				}
+
+				if event.err != nil {
+					return false
+				}
+
+				data, err := json.Marshal(event.change)
+
+				if err != nil {
+					s.logger.WithError(err).Error("error while encoding sync change")
+					return false
+				}
+
+				fmt.Fprintf(w, "id: %s\nevent: change\ndata: %s\n\n", event.resumeToken, data)
+				return true
+			}
+		})
+
+	}
+}
+
+func (s *SyncController) writeError(gCtx *gin.Context, err error) {
+	switch err {
+	case errors.ErrInvalidID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "user/invalid-id",
+			Message: "User ID is not valid",
+		})
+	case errors.ErrInvalidSyncRevision:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "sync/invalid-revision",
+			Message: "Sync revision is not valid",
+		})
+	case errors.ErrSyncResetRequired:
+		gCtx.JSON(http.StatusGone, response.ErrorResponse{
+			Code:    "sync/reset-required",
+			Message: "Changes since the revision are no longer kept, sync from scratch",
+		})
+	case errors.ErrChangeStreamUnavailable:
+		gCtx.JSON(http.StatusServiceUnavailable, response.ErrorResponse{
+			Code:    "sync/stream-unavailable",
+			Message: "Change stream is not available, poll GET /sync instead",
+		})
+	case errors.ErrUserNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "user/not-found",
+			Message: "User not found",
+		})
+	default:
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
+	}
+}
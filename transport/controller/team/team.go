@@ -0,0 +1,259 @@
+package team
+
+import (
+	"net/http"
+	"secaas_backend/model"
+	"secaas_backend/svc/errors"
+	"secaas_backend/svc/team"
+	"secaas_backend/transport/controller/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type TeamController struct {
+	logger *logrus.Logger
+	svc    *team.TeamSVC
+}
+
+func New(svc *team.TeamSVC, logger *logrus.Logger) *TeamController {
+	tc := &TeamController{logger: logger, svc: svc}
+	return tc
+}
+
+func (t *TeamController) Create() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var team model.Team
+
+		err := gCtx.BindJSON(&team)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			t.logger.WithError(err).Error("error in decoding body in team create")
+			return
+		}
+
+		if team.Name == "" {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "team/invalid-name",
+				Message: "Team name is not valid",
+			})
+			return
+		}
+
+		userId := gCtx.Query("userId")
+
+		newTeam, err := t.svc.Create(gCtx.Request.Context(), team, model.UserID(userId))
+
+		if err != nil {
+			t.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusCreated, newTeam)
+
+	}
+}
+
+func (t *TeamController) Get() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		teamId := gCtx.Param("teamId")
+		userId := gCtx.Query("userId")
+
+		team, err := t.svc.GetByID(gCtx.Request.Context(), model.TeamID(teamId), model.UserID(userId))
+
+		if err != nil {
+			t.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, team)
+
+	}
+}
+
+func (t *TeamController) GetForOrganization() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		orgId := gCtx.Param("organizationId")
+		userId := gCtx.Query("userId")
+
+		pageParams, ok := response.Pagination(gCtx)
+
+		if !ok {
+			return
+		}
+
+		data, info, err := t.svc.GetForOrganization(gCtx.Request.Context(), model.OrganizationID(orgId), model.UserID(userId), pageParams)
+
+		if err != nil {
+			t.writeError(gCtx, err)
+			return
+		}
+
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+func (t *TeamController) Delete() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		teamId := gCtx.Param("teamId")
+		userId := gCtx.Query("userId")
+
+		deleteCount, err := t.svc.Delete(gCtx.Request.Context(), model.TeamID(teamId), model.UserID(userId))
+
+		if err != nil {
+			t.writeError(gCtx, err)
+			return
+		}
+
+		if deleteCount > 0 {
+			teamId = ""
+		}
+
+		gCtx.JSON(http.StatusOK, gin.H{
+			"deleted": deleteCount > 0,
+			"id":      teamId,
+		})
+
+	}
+}
+
+func (t *TeamController) AddMember() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var member model.TeamMember
+
+		err := gCtx.BindJSON(&member)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			t.logger.WithError(err).Error("error in decoding body in team member add")
+			return
+		}
+
+		teamId := gCtx.Param("teamId")
+		userId := gCtx.Query("userId")
+
+		team, err := t.svc.AddMember(gCtx.Request.Context(), model.TeamID(teamId), model.UserID(userId), member)
+
+		if err != nil {
+			t.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, team)
+
+	}
+}
+
+func (t *TeamController) SetMemberKey() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		var wrappedKey model.SymKey
+
+		err := gCtx.BindJSON(&wrappedKey)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			t.logger.WithError(err).Error("error in decoding body in team member key")
+			return
+		}
+
+		teamId := gCtx.Param("teamId")
+		memberId := gCtx.Param("memberId")
+		userId := gCtx.Query("userId")
+
+		team, err := t.svc.SetMemberKey(gCtx.Request.Context(), model.TeamID(teamId), model.UserID(userId), model.UserID(memberId), wrappedKey)
+
+		if err != nil {
+			t.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, team)
+
+	}
+}
+
+func (t *TeamController) RemoveMember() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+
+		teamId := gCtx.Param("teamId")
+		memberId := gCtx.Param("memberId")
+		userId := gCtx.Query("userId")
+
+		team, err := t.svc.RemoveMember(gCtx.Request.Context(), model.TeamID(teamId), model.UserID(userId), model.UserID(memberId))
+
+		if err != nil {
+			t.writeError(gCtx, err)
+			return
+		}
+
+		gCtx.JSON(http.StatusOK, team)
+
+	}
+}
+
+func (t *TeamController) writeError(gCtx *gin.Context, err error) {
+	switch err {
+	case errors.ErrInvalidID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "team/invalid-id",
+			Message: "ID is not valid",
+		})
+	case errors.ErrInvalidOrganizationID:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "organization/invalid-id",
+			Message: "Organization ID is not valid",
+		})
+	case errors.ErrInvalidSymmetricKey:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "security/invalid-symm-key",
+			Message: "Wrapped team key is not valid",
+		})
+	case errors.ErrTeamNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "team/not-found",
+			Message: "Team not found",
+		})
+	case errors.ErrTeamMemberNotFound:
+		gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+			Code:    "team/member-not-found",
+			Message: "User is not a member of the team",
+		})
+	case errors.ErrNotOrganizationMember:
+		gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    "organization/not-member",
+			Message: "User is not a member of the organization",
+		})
+	case errors.ErrTeamMemberExists:
+		gCtx.JSON(http.StatusConflict, response.ErrorResponse{
+			Code:    "team/member-exists",
+			Message: "User is already a member of the team",
+		})
+	case errors.ErrTeamAccessDenied:
+		gCtx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Code:    "team/access-denied",
+			Message: "User is not allowed to view or manage the team",
+		})
+	default:
+		gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    "server/internal-error",
+			Message: "An Internal Server error has occurred",
+		})
+	}
+}
@@ -1,13 +1,11 @@
 package user
 
 import (
-	"math"
 	"net/http"
 	"secaas_backend/model"
 	"secaas_backend/svc/errors"
 	"secaas_backend/svc/user"
 	"secaas_backend/transport/controller/response"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -122,27 +120,13 @@ func (s *UserController) GetUsersForOrganization() gin.HandlerFunc {
 
 		orgId := gCtx.Param("organizationId")
 
-		rawPage := gCtx.Query("page")
-		rawLimit := gCtx.Query("limit")
-		page, err := strconv.Atoi(rawPage)
+		pageParams, ok := response.Pagination(gCtx)
 
-		if err != nil || page < 0 {
-			page = 1
-		}
-
-		limit, err := strconv.Atoi(rawLimit)
-
-		if err != nil || limit < 0 || limit > 100 {
-			limit = 10
-		}
-
-		pageParams := model.PaginationParams{
-			Page:  page,
-			Limit: limit,
-			Skip:  int(math.Max(float64(page-1), 0)) * limit,
+		if !ok {
+			return
 		}
 
-		data, err := s.svc.GetUsersByOrganization(gCtx.Request.Context(), model.OrganizationID(orgId), pageParams)
+		data, info, err := s.svc.GetUsersByOrganization(gCtx.Request.Context(), model.OrganizationID(orgId), pageParams)
 
 		if err != nil {
 			gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
@@ -152,17 +136,56 @@ func (s *UserController) GetUsersForOrganization() gin.HandlerFunc {
 			return
 		}
 
-		resp := model.PaginationResponse{
-			CurrentPage: page,
-			Data:        data,
-			Limit:       limit,
-			NextPage:    page + 1,
+		response.WritePage(gCtx, pageParams, data, info)
+
+	}
+}
+
+// AddSigningKey registers a public key as the current signing key of the user.
+func (u *UserController) AddSigningKey() gin.HandlerFunc {
+	return func(gCtx *gin.Context) {
+		userId := gCtx.Query("userId")
+
+		var body model.SigningKey
+
+		err := gCtx.BindJSON(&body)
+
+		if err != nil {
+			gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    "data/invalid-payload",
+				Message: "Payload format is not valid",
+			})
+			return
 		}
 
-		if len(data) == 0 {
-			resp.Data = []model.User{}
+		key, err := u.svc.AddSigningKey(gCtx.Request.Context(), model.UserID(userId), body.Public)
+
+		if err != nil {
+			switch err {
+			case errors.ErrInvalidID:
+				gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+					Code:    "user/invalid-id",
+					Message: "User ID is not valid",
+				})
+			case errors.ErrUserNotFound:
+				gCtx.JSON(http.StatusNotFound, response.ErrorResponse{
+					Code:    "user/not-found",
+					Message: "User Not Found",
+				})
+			case errors.ErrInvalidSigningKey:
+				gCtx.JSON(http.StatusBadRequest, response.ErrorResponse{
+					Code:    "user/invalid-signing-key",
+					Message: "Signing key must be a base64 encoded Ed25519 or P-256 public key",
+				})
+			default:
+				gCtx.JSON(http.StatusInternalServerError, response.ErrorResponse{
+					Code:    "server/internal-error",
+					Message: "An Internal Server error has occurred",
+				})
+			}
+			return
 		}
-		gCtx.JSON(http.StatusOK, resp)
 
+		gCtx.JSON(http.StatusCreated, key)
 	}
 }
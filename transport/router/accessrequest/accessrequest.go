@@ -0,0 +1,24 @@
+package accessrequest
+
+import (
+	"secaas_backend/transport/controller/accessrequest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Add(router *gin.RouterGroup, controller accessrequest.AccessRequestController) {
+
+	request := router.Group("/access-requests")
+
+	request.POST("", controller.Create())
+	request.GET("/:requestId", controller.Get())
+
+	request.GET("/user/:userId", controller.GetForUser())
+	request.GET("/organization/:organizationId", controller.GetForOrganization())
+	request.GET("/secret/:secretId", controller.GetForSecret())
+
+	request.POST("/:requestId/approve", controller.Approve())
+	request.POST("/:requestId/deny", controller.Deny())
+	request.POST("/:requestId/cancel", controller.Cancel())
+
+}
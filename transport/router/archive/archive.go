@@ -0,0 +1,16 @@
+package archive
+
+import (
+	"secaas_backend/transport/controller/archive"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Add(router *gin.RouterGroup, controller archive.ArchiveController) {
+
+	archive := router.Group("/archives")
+
+	archive.GET("/organization/:organizationId", controller.Export())
+	archive.POST("/import", controller.Import())
+
+}
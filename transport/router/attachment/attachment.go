@@ -0,0 +1,28 @@
+package attachment
+
+import (
+	"secaas_backend/transport/controller/attachment"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Add(router *gin.RouterGroup, controller attachment.AttachmentController) {
+
+	attachment := router.Group("/secrets/:secretId/attachments")
+
+	attachment.GET("", controller.GetForSecret())
+	attachment.GET("/:attachmentId/content", controller.Download())
+	attachment.DELETE("/:attachmentId", controller.Delete())
+
+	attachment.POST("/uploads", controller.StartUpload())
+	attachment.GET("/uploads/:uploadId", controller.GetUpload())
+	attachment.PUT("/uploads/:uploadId/chunks", controller.UploadChunk())
+	attachment.POST("/uploads/:uploadId/complete", controller.CompleteUpload())
+	attachment.DELETE("/uploads/:uploadId", controller.CancelUpload())
+
+	usage := router.Group("/attachments")
+
+	usage.GET("/organization/:organizationId/usage", controller.GetUsage())
+	usage.PUT("/organization/:organizationId/quota", controller.SetQuota())
+
+}
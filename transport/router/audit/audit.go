@@ -0,0 +1,15 @@
+package audit
+
+import (
+	"secaas_backend/transport/controller/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Add(router *gin.RouterGroup, controller audit.AuditController) {
+
+	audit := router.Group("/audit")
+
+	audit.GET("/organization/:organizationId", controller.GetForOrganization())
+
+}
@@ -0,0 +1,26 @@
+package breakglass
+
+import (
+	"secaas_backend/transport/controller/breakglass"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Add(router *gin.RouterGroup, controller breakglass.BreakGlassController) {
+
+	breakGlass := router.Group("/break-glass")
+
+	breakGlass.POST("/vaults", controller.CreateVault())
+	breakGlass.GET("/vaults/:vaultId", controller.GetVault())
+	breakGlass.DELETE("/vaults/:vaultId", controller.DeleteVault())
+	breakGlass.POST("/vaults/:vaultId/unlocks", controller.StartUnlock())
+
+	breakGlass.GET("/organization/:organizationId/vaults", controller.GetVaultsForOrganization())
+	breakGlass.GET("/organization/:organizationId/unlocks", controller.GetUnlocksForOrganization())
+
+	breakGlass.GET("/unlocks/:unlockId", controller.GetUnlock())
+	breakGlass.POST("/unlocks/:unlockId/shares", controller.SubmitShare())
+	breakGlass.GET("/unlocks/:unlockId/shares", controller.CollectShares())
+	breakGlass.POST("/unlocks/:unlockId/cancel", controller.CancelUnlock())
+
+}
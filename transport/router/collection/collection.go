@@ -0,0 +1,23 @@
+package collection
+
+import (
+	"secaas_backend/transport/controller/collection"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Add(router *gin.RouterGroup, controller collection.CollectionController) {
+
+	collection := router.Group("/collections")
+
+	collection.POST("", controller.Create())
+	collection.GET("/:collectionId", controller.Get())
+	collection.DELETE("/:collectionId", controller.Delete())
+
+	collection.GET("/organization/:organizationId", controller.GetForOrganization())
+
+	collection.PUT("/:collectionId/grants", controller.SetGrants())
+	collection.POST("/:collectionId/move", controller.Move())
+	collection.PUT("/:collectionId/rotation-policy", controller.SetRotationPolicy())
+
+}
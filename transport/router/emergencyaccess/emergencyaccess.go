@@ -0,0 +1,26 @@
+package emergencyaccess
+
+import (
+	"secaas_backend/transport/controller/emergencyaccess"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Add(router *gin.RouterGroup, controller emergencyaccess.EmergencyAccessController) {
+
+	emergency := router.Group("/emergency-access")
+
+	emergency.POST("", controller.Create())
+	emergency.GET("/:grantId", controller.Get())
+
+	emergency.GET("/user/:userId/grantor", controller.GetForGrantor())
+	emergency.GET("/user/:userId/grantee", controller.GetForGrantee())
+
+	emergency.PUT("/:grantId/key", controller.UpdateKey())
+	emergency.POST("/:grantId/request", controller.Request())
+	emergency.POST("/:grantId/approve", controller.Approve())
+	emergency.POST("/:grantId/reject", controller.Reject())
+	emergency.POST("/:grantId/revoke", controller.Revoke())
+	emergency.GET("/:grantId/vault", controller.GetVault())
+
+}
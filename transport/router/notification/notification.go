@@ -0,0 +1,16 @@
+package notification
+
+import (
+	"secaas_backend/transport/controller/notification"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Add(router *gin.RouterGroup, controller notification.NotificationController) {
+
+	notification := router.Group("/notifications")
+
+	notification.GET("/user/:userId", controller.GetForUser())
+	notification.POST("/:notificationId/read", controller.MarkRead())
+
+}
@@ -11,7 +11,10 @@ func Add(router *gin.RouterGroup, controller organization.OrganizationController
 	organization := router.Group("/organizations")
 
 	organization.POST("", controller.CreateOrganization())
+	organization.GET("/:organizationId", controller.GetOrganization())
+	organization.PUT("/:organizationId", controller.UpdateOrganization())
 	organization.DELETE("/:organizationId", controller.DeleteOrganization())
+	organization.PUT("/:organizationId/health-policy", controller.SetHealthPolicy())
 
 	organization.GET("/user/:userId", controller.GetOrganizationsForUser())
 
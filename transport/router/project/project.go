@@ -0,0 +1,23 @@
+package project
+
+import (
+	"secaas_backend/transport/controller/project"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Add(router *gin.RouterGroup, controller project.ProjectController) {
+
+	project := router.Group("/projects")
+
+	project.POST("", controller.Create())
+	project.GET("/:projectId", controller.Get())
+	project.DELETE("/:projectId", controller.Delete())
+
+	project.GET("/organization/:organizationId", controller.GetForOrganization())
+
+	project.PUT("/:projectId/environments", controller.SetEnvironments())
+	project.GET("/:projectId/environments/:environment/secrets", controller.GetEnvironmentBundle())
+	project.POST("/:projectId/secrets/:secretId/promote", controller.PromoteSecret())
+
+}
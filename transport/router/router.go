@@ -3,9 +3,21 @@ package router
 import (
 	"secaas_backend/transport/controller"
 	"secaas_backend/transport/middleware"
+	"secaas_backend/transport/router/accessrequest"
+	"secaas_backend/transport/router/archive"
+	"secaas_backend/transport/router/attachment"
+	"secaas_backend/transport/router/audit"
+	"secaas_backend/transport/router/breakglass"
+	"secaas_backend/transport/router/collection"
+	"secaas_backend/transport/router/emergencyaccess"
 	"secaas_backend/transport/router/invite"
+	"secaas_backend/transport/router/notification"
 	"secaas_backend/transport/router/organization"
+	"secaas_backend/transport/router/project"
 	"secaas_backend/transport/router/secret"
+	"secaas_backend/transport/router/sharelink"
+	"secaas_backend/transport/router/sync"
+	"secaas_backend/transport/router/team"
 	"secaas_backend/transport/router/user"
 
 	"github.com/gin-gonic/gin"
@@ -29,6 +41,18 @@ func Init(logger *logrus.Logger, c *controller.Controller) (*httpRouter, error)
 	invite.Add(apiV1, *c.Invite)
 	organization.Add(apiV1, *c.Organization)
 	secret.Add(apiV1, *c.Secrets)
+	notification.Add(apiV1, *c.Notification)
+	team.Add(apiV1, *c.Team)
+	collection.Add(apiV1, *c.Collection)
+	project.Add(apiV1, *c.Project)
+	attachment.Add(apiV1, *c.Attachment)
+	sharelink.Add(apiV1, *c.ShareLink)
+	accessrequest.Add(apiV1, *c.AccessRequest)
+	audit.Add(apiV1, *c.Audit)
+	breakglass.Add(apiV1, *c.BreakGlass)
+	emergencyaccess.Add(apiV1, *c.EmergencyAccess)
+	archive.Add(apiV1, *c.Archive)
+	sync.Add(apiV1, *c.Sync)
 
 	r := &httpRouter{logger: logger, Router: gr, controller: c}
 
@@ -11,11 +11,47 @@ func Add(router *gin.RouterGroup, controller secret.SecretsController) {
 	secret := router.Group("/secrets")
 
 	secret.POST("", controller.Create())
+	secret.GET("/kinds", controller.GetKinds())
+	secret.POST("/import", controller.Import())
+	secret.GET("/import/profiles", controller.GetImportProfiles())
 
 	secret.GET("/organization/:organizationId/user/:userId", controller.GetForUserOrganization())
 	secret.GET("/:secretId/organization/:organizationId/users", controller.GetUsersForSecret())
 	secret.GET("/organization/:organizationId", controller.GetForOrganization())
+	secret.GET("/organization/:organizationId/shares/expiring", controller.GetExpiringShares())
+	secret.GET("/organization/:organizationId/collection", controller.GetByCollectionPath())
+	secret.GET("/organization/:organizationId/search", controller.Search())
+	secret.GET("/organization/:organizationId/rotation/overdue", controller.GetOverdueRotations())
+	secret.GET("/organization/:organizationId/health", controller.GetHealthReport())
+	secret.GET("/organization/:organizationId/unused", controller.GetUnusedSecrets())
+	secret.GET("/organization/:organizationId/certificates/expiring", controller.GetExpiringCertificates())
+
+	secret.GET("/:secretId", controller.Get())
+	secret.PUT("/:secretId", controller.Update())
+	secret.DELETE("/:secretId", controller.Delete())
+	secret.GET("/:secretId/access", controller.GetAccessSummary())
 
 	secret.POST("/:secretId/share", controller.ShareKey())
+	secret.PUT("/:secretId/users/:memberId/role", controller.ChangeRole())
+	secret.PUT("/:secretId/users/:memberId/expiry", controller.RenewShare())
+
+	secret.GET("/:secretId/teams", controller.GetTeamsForSecret())
+	secret.POST("/:secretId/teams", controller.ShareWithTeam())
+	secret.PUT("/:secretId/teams/:teamId/role", controller.ChangeTeamRole())
+	secret.DELETE("/:secretId/teams/:teamId", controller.RevokeTeamShare())
+
+	secret.PUT("/:secretId/collection", controller.MoveToCollection())
+
+	secret.PUT("/:secretId/checkout/policy", controller.SetCheckoutPolicy())
+	secret.GET("/:secretId/checkout", controller.GetCheckout())
+	secret.POST("/:secretId/checkout", controller.Checkout())
+	secret.POST("/:secretId/checkin", controller.Checkin())
+	secret.POST("/:secretId/checkout/force-checkin", controller.ForceCheckin())
+	secret.DELETE("/:secretId/checkout/queue", controller.LeaveCheckoutQueue())
+
+	secret.PUT("/:secretId/rotation-policy", controller.SetRotationPolicy())
+
+	secret.PUT("/:secretId/owner", controller.TransferOwnership())
+	secret.POST("/organization/:organizationId/owners/reassign", controller.ReassignOwnership())
 
 }
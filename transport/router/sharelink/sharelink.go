@@ -0,0 +1,23 @@
+package sharelink
+
+import (
+	"secaas_backend/transport/controller/sharelink"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Add(router *gin.RouterGroup, controller sharelink.ShareLinkController) {
+
+	link := router.Group("/links")
+
+	link.POST("", controller.Create())
+	link.GET("/user/:userId", controller.GetForUser())
+
+	link.GET("/:linkId", controller.Get())
+	link.DELETE("/:linkId", controller.Revoke())
+
+	// Recipient endpoints, they are reached without an account.
+	link.GET("/:linkId/info", controller.GetInfo())
+	link.POST("/:linkId/open", controller.Open())
+
+}
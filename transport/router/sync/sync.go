@@ -0,0 +1,14 @@
+package sync
+
+import (
+	"secaas_backend/transport/controller/sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Add(router *gin.RouterGroup, controller sync.SyncController) {
+
+	router.GET("/sync", controller.GetChanges())
+	router.GET("/sync/stream", controller.Stream())
+
+}
@@ -0,0 +1,23 @@
+package team
+
+import (
+	"secaas_backend/transport/controller/team"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Add(router *gin.RouterGroup, controller team.TeamController) {
+
+	team := router.Group("/teams")
+
+	team.POST("", controller.Create())
+	team.GET("/:teamId", controller.Get())
+	team.DELETE("/:teamId", controller.Delete())
+
+	team.GET("/organization/:organizationId", controller.GetForOrganization())
+
+	team.POST("/:teamId/members", controller.AddMember())
+	team.PUT("/:teamId/members/:memberId/key", controller.SetMemberKey())
+	team.DELETE("/:teamId/members/:memberId", controller.RemoveMember())
+
+}